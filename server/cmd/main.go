@@ -1,21 +1,60 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"brb/internal/app"
+	"brb/internal/config"
 	"brb/pkg/logger"
 )
 
 func main() {
-	logger.Info.Println("Starting server...")
+	// 加载配置
+	cfg, opts, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("配置无效", err)
+	}
+	if err := logger.Setup(os.Stdout, cfg.Log.Format, cfg.Log.Level); err != nil {
+		fatal("配置无效", err)
+	}
+	if opts.PrintConfig {
+		fmt.Println(cfg)
+		return
+	}
+
+	slog.Info("Starting server...")
+	slog.Info("生效配置", "env", cfg.Env, "config", cfg.Redacted())
+
 	// 创建应用程序实例
-	app, err := app.NewApp("file:brb.db?cache=shared&mode=rwc")
+	app, err := app.NewApp(cfg)
 	if err != nil {
-		logger.Error.Fatalf("Failed to initialize application: %v", err)
+		fatal("Failed to initialize application", err)
 	}
 
+	// 收到SIGINT或SIGTERM时优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// 启动HTTP服务器
-	err = app.Run(":5050")
-	if err != nil {
-		logger.Error.Fatalf("Failed to start server: %v", err)
+	err = app.Run(ctx)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatal("Failed to start server", err)
 	}
 }
+
+// fatal 记录错误并退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
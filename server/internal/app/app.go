@@ -1,47 +1,61 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"time"
+	"slices"
+	"sync/atomic"
 
+	"brb/internal/config"
+	"brb/internal/entity"
 	"brb/internal/handler"
 	"brb/internal/middleware"
 	"brb/internal/repo"
 	"brb/internal/router"
 	"brb/internal/service"
-	"brb/pkg/logger"
+	"brb/pkg/metrics"
+	"brb/pkg/oidc"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 // App 表示应用程序
 type App struct {
-	DB  *sql.DB
-	Mux *http.ServeMux
+	DB     *sql.DB
+	Mux    *http.ServeMux
+	Config *config.Config
+
+	lifecycle lifecycle
+	routes    func() []router.Route // 已注册的路由
+	draining  atomic.Bool           // 收到关闭信号后置为true，就绪检查随即失败
 }
 
 // NewApp 创建并初始化应用程序
-func NewApp(dbPath string) (*App, error) {
+func NewApp(cfg *config.Config) (*App, error) {
 	app := &App{
-		Mux: http.NewServeMux(),
+		Mux:    http.NewServeMux(),
+		Config: cfg,
 	}
 
 	// 初始化数据库连接
 	var err error
-	app.DB, err = sql.Open("sqlite3", dbPath)
+	app.DB, err = sql.Open("sqlite3", cfg.Database.DSN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	logger.Info.Println("数据库连接已打开")
+	slog.Info("数据库连接已打开")
 
 	// 初始化依赖
 	if err := app.initDependencies(); err != nil {
 		return nil, err
 	}
-	logger.Info.Println("依赖注入完成")
+	slog.Info("依赖注入完成")
 
 	return app, nil
 }
@@ -53,6 +67,9 @@ func (a *App) initDependencies() error {
 	if err != nil {
 		return fmt.Errorf("failed to create sign repository: %w", err)
 	}
+	if err := signRepo.EnforceUniqueSignifiers(a.Config.Signs.UniqueSignifiers); err != nil {
+		return fmt.Errorf("failed to apply signs.uniqueSignifiers: %w", err)
+	}
 
 	todoRepo, err := repo.NewTodoRepo(a.DB)
 	if err != nil {
@@ -74,26 +91,133 @@ func (a *App) initDependencies() error {
 		return fmt.Errorf("failed to create user repository: %w", err)
 	}
 
+	loginAttemptRepo, err := repo.NewLoginAttemptRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create login attempt repository: %w", err)
+	}
+
+	passwordResetRepo, err := repo.NewPasswordResetRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset repository: %w", err)
+	}
+
+	recoveryCodeRepo, err := repo.NewRecoveryCodeRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create recovery code repository: %w", err)
+	}
+
+	settingRepo, err := repo.NewSettingRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create setting repository: %w", err)
+	}
+
+	assignmentRepo, err := repo.NewAssignmentRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create assignment repository: %w", err)
+	}
+
+	identityRepo, err := repo.NewIdentityRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create identity repository: %w", err)
+	}
+
+	// 关联表的触发器依赖signs、events、tasks、todos表，须在其后创建
+	signLinkRepo, err := repo.NewSignLinkRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create sign link repository: %w", err)
+	}
+
+	tagRepo, err := repo.NewTagRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create tag repository: %w", err)
+	}
+
+	templateRepo, err := repo.NewTemplateRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create template repository: %w", err)
+	}
+
+	ontonRepo, err := repo.NewOntonRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create onton repository: %w", err)
+	}
+
+	signRelationRepo, err := repo.NewSignRelationRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create sign relation repository: %w", err)
+	}
+
+	// 全文索引的触发器依赖events、tasks、signs、sign_labels表，须在其后创建
+	searchRepo, err := repo.NewSearchRepo(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create search repository: %w", err)
+	}
+	if !searchRepo.Enabled() {
+		slog.Warn("SQLite未启用FTS5，全文搜索改用LIKE且不按相关度排序，以-tags sqlite_fts5构建可启用全文索引")
+	}
+
+	// 所有表初始化完成后记录结构版本
+	if err := repo.MarkSchemaVersion(a.DB); err != nil {
+		return err
+	}
+
 	// 初始化services
-	signService := service.NewSignService(signRepo)
+	passwordPolicy, err := a.passwordPolicy()
+	if err != nil {
+		return err
+	}
+	signPolicy := a.signPolicy()
+	signService := service.NewSignService(signRepo, signPolicy)
 	todoService := service.NewTodoService(todoRepo, taskRepo, eventRepo)
 	taskService := service.NewTaskService(taskRepo, todoRepo)
 	eventService := service.NewEventService(eventRepo, taskRepo)
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, loginAttemptRepo, passwordResetRepo, recoveryCodeRepo, settingRepo, a.loginPolicy(), passwordPolicy)
+	assignmentService := service.NewAssignmentService(assignmentRepo, taskRepo, todoRepo, userRepo)
+	signLinkService := service.NewSignLinkService(signLinkRepo, signRepo, eventRepo, taskRepo, todoRepo, signPolicy)
+	tagService := service.NewTagService(tagRepo)
+	templateService := service.NewTemplateService(eventRepo, templateRepo)
+	ontonService := service.NewOntonService(ontonRepo, signRepo, signPolicy)
+	signRelationService := service.NewSignRelationService(signRelationRepo, signRepo, signPolicy)
+	searchService := service.NewSearchService(searchRepo)
+	signExchangeService := service.NewSignExchangeService(signRepo, signRelationRepo, signPolicy)
 
-	// 获取JWT密钥
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-in-production" // 默认密钥，生产环境应该使用环境变量
-		logger.Info.Println("使用默认JWT密钥，生产环境请设置JWT_SECRET环境变量")
+	jwtSecret := a.Config.Auth.JWTSecret
+	if jwtSecret == config.DefaultJWTSecret {
+		slog.Warn("使用默认JWT密钥，生产环境请设置JWT_SECRET环境变量")
 	}
 
 	// 初始化handlers
-	signHandler := handler.NewSignHandler(signService)
+	signHandler := handler.NewSignHandler(signService, jwtSecret)
 	todoHandler := handler.NewTodoHandler(todoService)
 	taskHandler := handler.NewTaskHandler(taskService)
 	eventHandler := handler.NewEventHandler(eventService)
 	userHandler := handler.NewUserHandler(userService, jwtSecret)
+	assignmentHandler := handler.NewAssignmentHandler(assignmentService)
+	signLinkHandler := handler.NewSignLinkHandler(signLinkService)
+	tagHandler := handler.NewTagHandler(tagService)
+	templateHandler := handler.NewTemplateHandler(templateService)
+	ontonHandler := handler.NewOntonHandler(ontonService)
+	signRelationHandler := handler.NewSignRelationHandler(signRelationService, jwtSecret)
+	searchHandler := handler.NewSearchHandler(searchService)
+	signExchangeHandler := handler.NewSignExchangeHandler(signExchangeService, jwtSecret)
+
+	// 配置了OIDC时启用单点登录
+	var oidcHandler interface{ RegisterRoutes(router.Router) }
+	if oidcCfg := a.Config.OIDC; oidcCfg.Enabled() {
+		provider := oidc.NewProvider(oidc.Config{
+			IssuerURL:    oidcCfg.IssuerURL,
+			ClientID:     oidcCfg.ClientID,
+			ClientSecret: oidcCfg.ClientSecret,
+			RedirectURL:  oidcCfg.RedirectURL,
+		})
+		ssoService := service.NewSSOService(userRepo, identityRepo, service.SSOPolicy{
+			RoleClaim:    oidcCfg.RoleClaim,
+			AdminValues:  oidcCfg.AdminValues,
+			LinkExisting: oidcCfg.LinkExisting,
+		})
+		oidcHandler = handler.NewOIDCHandler(provider, ssoService, userHandler, oidcCfg.PostLoginRedirect)
+		slog.Info("已启用OIDC单点登录", "issuer", oidcCfg.IssuerURL)
+	}
 
 	// 创建路由注册器
 	reg := router.NewStandardRouter(a.Mux)
@@ -104,45 +228,194 @@ func (a *App) initDependencies() error {
 		middleware.RecoveryMiddleware,
 	)
 
+	if a.Config.Metrics.Enabled {
+		registry := metrics.NewRegistry()
+		reg.Use(middleware.NewHTTPMetrics(registry).Middleware)
+		registry.Register(dbStatsCollector(a.DB))
+		registry.Register(domainCollector(todoRepo, taskRepo))
+		registry.Register(runtimeCollector())
+
+		if addr := a.Config.Metrics.AdminAddr; addr != "" {
+			adminMux := http.NewServeMux()
+			adminMux.Handle("GET /metrics", registry)
+			a.Register(newAdminServer(addr, adminMux))
+		} else {
+			reg.GET("/metrics", registry.ServeHTTP, middleware.RequireAuth(jwtSecret), middleware.RequireAdmin())
+		}
+	}
+
+	// 存活和就绪探针
+	healthHandler := handler.NewHealthHandler(a.healthChecks)
+	healthHandler.RegisterRoutes(reg)
+
 	// API 版本分组
 	v1 := reg.Group("/v1")
 
-	// 注册公开路由（无需认证）
+	// 接口文档
+	docsHandler := handler.NewDocsHandler(reg.Routes)
+	docsHandler.RegisterRoutes(v1)
+
+	// 注册公开路由（无需认证），sign的读取公开，写入在各自的路由上要求认证
 	signHandler.RegisterRoutes(v1)
+	signRelationHandler.RegisterRoutes(v1)
+	signExchangeHandler.RegisterRoutes(v1)
 	userHandler.RegisterRoutes(v1)
+	if oidcHandler != nil {
+		oidcHandler.RegisterRoutes(v1)
+	}
 
 	// 为受保护的路由组添加认证中间件
 	protected := v1.Group("")
-	protected.Use(middleware.RequireAuth(jwtSecret))
+	protected.Use(middleware.RequireAuth(jwtSecret), middleware.UserLocation(userService.UserLocation))
 
 	// 注册受保护的路由
 	todoHandler.RegisterRoutes(protected)
 	taskHandler.RegisterRoutes(protected)
 	eventHandler.RegisterRoutes(protected)
+	tagHandler.RegisterRoutes(protected)
+	templateHandler.RegisterRoutes(protected)
+	assignmentHandler.RegisterRoutes(protected)
+	signLinkHandler.RegisterRoutes(protected)
+	ontonHandler.RegisterRoutes(protected)
+	searchHandler.RegisterRoutes(protected)
 
+	a.routes = reg.Routes
 	return nil
 }
 
-func (a *App) Run(addr string) error {
+// healthChecks 就绪检查项：数据库连通、结构版本、后台组件状态
+func (a *App) healthChecks() []handler.HealthCheck {
+	checks := []handler.HealthCheck{
+		{Name: "server", Check: func(ctx context.Context) error {
+			if a.draining.Load() {
+				return errors.New("shutting down")
+			}
+			return nil
+		}},
+		{Name: "database", Check: a.DB.PingContext},
+		{Name: "migrations", Check: func(ctx context.Context) error {
+			return repo.CheckSchemaVersion(ctx, a.DB)
+		}},
+	}
+	for _, c := range a.lifecycle.components {
+		checks = append(checks, handler.HealthCheck{
+			Name: "worker:" + c.Name(),
+			Check: func(ctx context.Context) error {
+				return a.lifecycle.check(ctx, c)
+			},
+		})
+	}
+	return checks
+}
+
+// loginPolicy 根据配置生成登录失败限制策略
+func (a *App) loginPolicy() service.LoginPolicy {
+	login := a.Config.Auth.Login
+	return service.LoginPolicy{
+		BackoffThreshold:   login.BackoffThreshold,
+		BackoffBase:        login.BackoffBase.Std(),
+		LockoutThreshold:   login.LockoutThreshold,
+		IPLockoutThreshold: login.IPLockoutThreshold,
+		LockoutDuration:    login.LockoutDuration.Std(),
+		ResetAfter:         login.ResetAfter.Std(),
+	}
+}
+
+// signPolicy 根据配置生成sign的编辑策略
+func (a *App) signPolicy() service.SignPolicy {
+	signs := a.Config.Signs
+	policy := service.SignPolicy{UniqueSignifiers: signs.UniqueSignifiers}
+	for _, role := range signs.EditorRoles {
+		policy.EditorRoles = append(policy.EditorRoles, entity.Role(role))
+	}
+	return policy
+}
+
+// passwordPolicy 根据配置生成密码策略，配置了blocklistFile时将其中的密码追加到默认的弱密码列表
+func (a *App) passwordPolicy() (service.PasswordPolicy, error) {
+	password := a.Config.Auth.Password
+	policy := service.DefaultPasswordPolicy()
+	policy.MinLength = password.MinLength
+	policy.RequireUpper = password.RequireUpper
+	policy.RequireLower = password.RequireLower
+	policy.RequireDigit = password.RequireDigit
+	policy.RequireSymbol = password.RequireSymbol
+	policy.ResetTokenTTL = password.ResetTokenTTL.Std()
+
+	if password.BlocklistFile == "" {
+		return policy, nil
+	}
+	f, err := os.Open(password.BlocklistFile)
+	if err != nil {
+		return policy, fmt.Errorf("failed to open auth.password.blocklistFile: %w", err)
+	}
+	defer f.Close()
+	blocklist, err := service.ReadPasswordBlocklist(f)
+	if err != nil {
+		return policy, err
+	}
+	policy.Blocklist = append(slices.Clone(policy.Blocklist), blocklist...)
+	slog.Info("已加载密码黑名单", "file", password.BlocklistFile, "count", len(blocklist))
+	return policy, nil
+}
+
+// Register 注册随应用启动和停止的组件，需在Run之前调用
+func (a *App) Register(c Component) {
+	a.lifecycle.register(c)
+}
+
+// Run 启动组件和HTTP服务器，直到ctx取消（如收到SIGTERM）后优雅关闭：
+// 停止接收新请求并在超时内等待处理中的请求完成，再按相反顺序停止组件并关闭数据库
+func (a *App) Run(ctx context.Context) error {
+	serverCfg := a.Config.Server
 
 	// 创建自定义的 HTTP 服务器配置
 	server := &http.Server{
-		Addr:    addr,
+		Addr:    serverCfg.Addr,
 		Handler: a.Mux,
 		// 超时时间
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  serverCfg.ReadTimeout.Std(),
+		WriteTimeout: serverCfg.WriteTimeout.Std(),
+		IdleTimeout:  serverCfg.IdleTimeout.Std(),
+		// 请求上下文在关闭时不随ctx取消，由Shutdown等待其完成
+		BaseContext: func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
 	}
-
-	logger.Info.Printf("服务器运行在 %s\n", addr)
 	defer func() {
 		a.Close()
-		logger.Info.Println("服务器已关闭")
+		slog.Info("服务器已关闭")
+	}()
+
+	if err := a.lifecycle.start(ctx); err != nil {
+		return err
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("服务器运行中", "addr", serverCfg.Addr)
+		serverErr <- server.ListenAndServe()
 	}()
 
-	// 启动服务器
-	return server.ListenAndServe()
+	var runErr error
+	select {
+	case err := <-serverErr:
+		// 服务器未能启动或意外退出
+		runErr = err
+	case <-ctx.Done():
+		slog.Info("收到关闭信号，等待处理中的请求完成...")
+	}
+	a.draining.Store(true)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverCfg.ShutdownTimeout.Std())
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("等待请求完成超时，强制关闭", "error", err)
+		server.Close()
+	}
+	if err := a.lifecycle.stop(shutdownCtx); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+	return runErr
 }
 
 // Close 关闭应用程序资源
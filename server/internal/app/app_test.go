@@ -0,0 +1,89 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"brb/internal/config"
+	"brb/internal/handler"
+)
+
+// TestOpenAPICoversAllRoutes 每个注册的路由都必须在apiEndpoints中声明文档
+func TestOpenAPICoversAllRoutes(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+	}{
+		{"default", func(*config.Config) {}},
+		{"oidc enabled", func(c *config.Config) {
+			c.OIDC.IssuerURL = "https://idp.test"
+			c.OIDC.ClientID = "brb"
+			c.OIDC.RedirectURL = "http://app.test/v1/api/auth/oidc/callback"
+		}},
+		{"metrics disabled", func(c *config.Config) { c.Metrics.Enabled = false }},
+		{"metrics on admin port", func(c *config.Config) { c.Metrics.AdminAddr = "127.0.0.1:0" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Database.DSN = "file:" + filepath.Join(t.TempDir(), "test.db")
+			tt.configure(cfg)
+
+			a, err := NewApp(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { a.DB.Close() })
+
+			if _, err := handler.BuildOpenAPI(a.routes()); err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			a.Mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET /v1/openapi.json: status = %d: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestPasswordBlocklistFile(t *testing.T) {
+	dir := t.TempDir()
+	blocklist := filepath.Join(dir, "blocklist.txt")
+	if err := os.WriteFile(blocklist, []byte("#comment1pass\n\n  Hunter2hunter2  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+		// 各密码是否被拒绝
+		rejected map[string]bool
+	}{
+		{"none", "", false, map[string]bool{"hunter2hunter2": false, "password123": true}},
+		{"merged with the defaults", blocklist, false, map[string]bool{"hunter2hunter2": true, "password123": true, "#comment1pass": false}},
+		{"missing file", filepath.Join(dir, "missing.txt"), true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Auth.Password.BlocklistFile = tt.file
+			a := &App{Config: cfg}
+
+			policy, err := a.passwordPolicy()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			for password, want := range tt.rejected {
+				if got := policy.Validate("alice", password) != nil; got != want {
+					t.Errorf("%q rejected = %v, want %v", password, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Component 随应用启动和停止的后台组件（如定时任务、通知发送）
+type Component interface {
+	Name() string
+	// Start 启动组件，长期运行的工作应在内部开启goroutine后立即返回
+	Start(ctx context.Context) error
+	// Stop 停止组件，应在ctx到期前返回
+	Stop(ctx context.Context) error
+}
+
+// HealthChecker 可由组件实现，就绪检查时除运行状态外还会调用Health
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// 组件状态
+const (
+	componentPending = "pending"
+	componentRunning = "running"
+	componentStopped = "stopped"
+	componentFailed  = "failed"
+)
+
+// lifecycle 按注册顺序启动组件，按相反顺序停止
+type lifecycle struct {
+	components []Component
+	started    []Component
+
+	mu     sync.Mutex
+	states map[string]string // 组件名 -> 状态
+}
+
+// register 注册组件
+func (l *lifecycle) register(c Component) {
+	l.components = append(l.components, c)
+	l.setState(c, componentPending)
+}
+
+func (l *lifecycle) setState(c Component, state string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.states == nil {
+		l.states = map[string]string{}
+	}
+	l.states[c.Name()] = state
+}
+
+// check 检查组件是否正常运行
+func (l *lifecycle) check(ctx context.Context, c Component) error {
+	l.mu.Lock()
+	state := l.states[c.Name()]
+	l.mu.Unlock()
+
+	if state != componentRunning {
+		return fmt.Errorf("component is %s", state)
+	}
+	if hc, ok := c.(HealthChecker); ok {
+		return hc.Health(ctx)
+	}
+	return nil
+}
+
+// start 依次启动所有组件，任一失败时停止已启动的组件
+func (l *lifecycle) start(ctx context.Context) error {
+	for _, c := range l.components {
+		if err := c.Start(ctx); err != nil {
+			l.setState(c, componentFailed)
+			stopErr := l.stop(ctx)
+			return errors.Join(fmt.Errorf("failed to start %s: %w", c.Name(), err), stopErr)
+		}
+		l.started = append(l.started, c)
+		l.setState(c, componentRunning)
+		slog.InfoContext(ctx, "组件已启动", "component", c.Name())
+	}
+	return nil
+}
+
+// stop 按启动的相反顺序停止组件
+func (l *lifecycle) stop(ctx context.Context) error {
+	var errs []error
+	for i := len(l.started) - 1; i >= 0; i-- {
+		c := l.started[i]
+		if err := c.Stop(ctx); err != nil {
+			l.setState(c, componentFailed)
+			slog.ErrorContext(ctx, "组件停止失败", "component", c.Name(), "error", err)
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.Name(), err))
+			continue
+		}
+		l.setState(c, componentStopped)
+		slog.InfoContext(ctx, "组件已停止", "component", c.Name())
+	}
+	l.started = nil
+	return errors.Join(errs...)
+}
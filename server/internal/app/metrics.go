@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime"
+	"time"
+
+	"brb/internal/entity"
+	"brb/pkg/metrics"
+)
+
+type todoCounter interface {
+	CountByStatus() (map[entity.Status]int, error)
+}
+
+type overdueTaskCounter interface {
+	CountOverdue(now time.Time) (int, error)
+}
+
+// dbStatsCollector 暴露数据库连接池状态
+func dbStatsCollector(db *sql.DB) metrics.Collector {
+	return metrics.CollectorFunc(func(w *metrics.Writer) {
+		stats := db.Stats()
+		gauge := func(name, help string, v float64) {
+			w.Header(name, help, "gauge")
+			w.Sample(name, v)
+		}
+		counter := func(name, help string, v float64) {
+			w.Header(name, help, "counter")
+			w.Sample(name, v)
+		}
+		gauge("brb_db_max_open_connections", "连接池最大连接数", float64(stats.MaxOpenConnections))
+		gauge("brb_db_open_connections", "当前打开的连接数", float64(stats.OpenConnections))
+		gauge("brb_db_in_use_connections", "正在使用的连接数", float64(stats.InUse))
+		gauge("brb_db_idle_connections", "空闲连接数", float64(stats.Idle))
+		counter("brb_db_wait_count_total", "等待连接的总次数", float64(stats.WaitCount))
+		counter("brb_db_wait_duration_seconds_total", "等待连接的总耗时（秒）", stats.WaitDuration.Seconds())
+		counter("brb_db_max_idle_closed_total", "因超过最大空闲数关闭的连接数", float64(stats.MaxIdleClosed))
+		counter("brb_db_max_lifetime_closed_total", "因超过最长生命周期关闭的连接数", float64(stats.MaxLifetimeClosed))
+	})
+}
+
+// domainCollector 暴露业务指标，每次采集时查询数据库
+func domainCollector(todos todoCounter, tasks overdueTaskCounter) metrics.Collector {
+	return metrics.CollectorFunc(func(w *metrics.Writer) {
+		if counts, err := todos.CountByStatus(); err != nil {
+			slog.Error("采集todo指标失败", "error", err)
+		} else {
+			w.Header("brb_todos", "各状态的todo数量", "gauge")
+			for _, status := range []entity.Status{entity.StatusPending, entity.StatusInProgress, entity.StatusCompleted, entity.StatusCancelled} {
+				w.Sample("brb_todos", float64(counts[status]), "status", string(status))
+			}
+		}
+
+		if overdue, err := tasks.CountOverdue(time.Now()); err != nil {
+			slog.Error("采集task指标失败", "error", err)
+		} else {
+			w.Header("brb_tasks_overdue", "已过截止时间且未完成的task数量", "gauge")
+			w.Sample("brb_tasks_overdue", float64(overdue))
+		}
+	})
+}
+
+// runtimeCollector 暴露运行时指标
+func runtimeCollector() metrics.Collector {
+	return metrics.CollectorFunc(func(w *metrics.Writer) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		w.Header("brb_goroutines", "当前goroutine数量", "gauge")
+		w.Sample("brb_goroutines", float64(runtime.NumGoroutine()))
+		w.Header("brb_memory_heap_alloc_bytes", "堆上已分配的字节数", "gauge")
+		w.Sample("brb_memory_heap_alloc_bytes", float64(mem.HeapAlloc))
+	})
+}
+
+// adminServer 在独立的管理端口上提供/metrics等运维接口，应只绑定内网地址
+type adminServer struct {
+	server *http.Server
+}
+
+func newAdminServer(addr string, handler http.Handler) *adminServer {
+	return &adminServer{
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+func (s *adminServer) Name() string {
+	return "admin-server"
+}
+
+func (s *adminServer) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		slog.Info("管理端口运行中", "addr", s.server.Addr)
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("管理端口异常退出", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (s *adminServer) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,404 @@
+// Package config 加载并校验服务配置，优先级：命令行参数 > 环境变量 > 配置文件 > 默认值
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultJWTSecret 开发环境使用的默认JWT密钥，生产环境禁止使用
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+// 运行环境
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Config 服务配置
+type Config struct {
+	Env      string         `json:"env"`
+	Log      LogConfig      `json:"log"`
+	Server   ServerConfig   `json:"server"`
+	Database DatabaseConfig `json:"database"`
+	Auth     AuthConfig     `json:"auth"`
+	OIDC     OIDCConfig     `json:"oidc"`
+	Metrics  MetricsConfig  `json:"metrics"`
+	Signs    SignsConfig    `json:"signs"`
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level  string `json:"level"`  // debug、info、warn、error
+	Format string `json:"format"` // json或text
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Addr         string   `json:"addr"`
+	ReadTimeout  Duration `json:"readTimeout"`
+	WriteTimeout Duration `json:"writeTimeout"`
+	IdleTimeout  Duration `json:"idleTimeout"`
+	// ShutdownTimeout 关闭时等待处理中请求和后台组件结束的最长时间
+	ShutdownTimeout Duration `json:"shutdownTimeout"`
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	DSN string `json:"dsn"`
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	JWTSecret string         `json:"jwtSecret"`
+	Login     LoginConfig    `json:"login"`
+	Password  PasswordConfig `json:"password"`
+}
+
+// LoginConfig 登录失败限制配置
+type LoginConfig struct {
+	BackoffThreshold   int      `json:"backoffThreshold"`
+	BackoffBase        Duration `json:"backoffBase"`
+	LockoutThreshold   int      `json:"lockoutThreshold"`
+	IPLockoutThreshold int      `json:"ipLockoutThreshold"`
+	LockoutDuration    Duration `json:"lockoutDuration"`
+	ResetAfter         Duration `json:"resetAfter"`
+}
+
+// PasswordConfig 密码策略配置
+type PasswordConfig struct {
+	MinLength     int      `json:"minLength"`
+	RequireUpper  bool     `json:"requireUpper"`
+	RequireLower  bool     `json:"requireLower"`
+	RequireDigit  bool     `json:"requireDigit"`
+	RequireSymbol bool     `json:"requireSymbol"`
+	ResetTokenTTL Duration `json:"resetTokenTTL"`
+	// BlocklistFile 额外禁止使用的密码列表文件，每行一个，忽略空行和以#开头的行；与内置的常见密码列表合并
+	BlocklistFile string `json:"blocklistFile"`
+}
+
+// OIDCConfig 单点登录配置，IssuerURL为空时不启用
+type OIDCConfig struct {
+	IssuerURL         string   `json:"issuerURL"`
+	ClientID          string   `json:"clientID"`
+	ClientSecret      string   `json:"clientSecret"`
+	RedirectURL       string   `json:"redirectURL"`
+	RoleClaim         string   `json:"roleClaim"` // 为空时不同步角色
+	AdminValues       []string `json:"adminValues"`
+	LinkExisting      bool     `json:"linkExisting"`
+	PostLoginRedirect string   `json:"postLoginRedirect"`
+}
+
+// MetricsConfig 监控指标配置
+type MetricsConfig struct {
+	Enabled bool `json:"enabled"`
+	// AdminAddr 管理端口地址（如127.0.0.1:9090），设置后/metrics在该端口上无需认证提供；
+	// 为空时挂在主端口上，仅管理员可访问
+	AdminAddr string `json:"adminAddr"`
+}
+
+// SignsConfig 词汇表配置
+type SignsConfig struct {
+	// UniqueSignifiers 为true时一个能指（默认或多语言能指）只能属于一个sign，创建、修改或导入为其他sign的能指返回409
+	UniqueSignifiers bool `json:"uniqueSignifiers"`
+	// EditorRoles 可以修改、删除他人创建的sign，修改其关系和关联，管理onton和批量导入的角色
+	// 创建者总能修改自己的sign及其关系和关联
+	EditorRoles []string `json:"editorRoles"`
+}
+
+// Enabled 是否启用单点登录
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// Duration 支持以"15s"、"1h"形式在配置文件中书写的时长
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("时长必须是字符串，如\"15s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Std 转换为time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Env: EnvDevelopment,
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Server: ServerConfig{
+			Addr:            ":5050",
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(15 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			ShutdownTimeout: Duration(15 * time.Second),
+		},
+		Database: DatabaseConfig{
+			DSN: "file:brb.db?cache=shared&mode=rwc",
+		},
+		Auth: AuthConfig{
+			JWTSecret: DefaultJWTSecret,
+			Login: LoginConfig{
+				BackoffThreshold:   3,
+				BackoffBase:        Duration(time.Second),
+				LockoutThreshold:   10,
+				IPLockoutThreshold: 50,
+				LockoutDuration:    Duration(15 * time.Minute),
+				ResetAfter:         Duration(time.Hour),
+			},
+			Password: PasswordConfig{
+				MinLength:     8,
+				RequireLower:  true,
+				RequireDigit:  true,
+				ResetTokenTTL: Duration(24 * time.Hour),
+			},
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Signs: SignsConfig{
+			EditorRoles: []string{"admin"},
+		},
+	}
+}
+
+// Options 命令行中与配置无关的选项
+type Options struct {
+	PrintConfig bool // 打印生效配置后退出
+}
+
+// Load 依次合并默认值、配置文件、环境变量和命令行参数，并校验结果
+func Load(args []string) (*Config, Options, error) {
+	var opts Options
+	cfg := Default()
+
+	fs := flag.NewFlagSet("brb", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("BRB_CONFIG"), "配置文件路径（JSON）")
+	env := fs.String("env", "", "运行环境：development 或 production")
+	addr := fs.String("addr", "", "监听地址，如 :5050")
+	dsn := fs.String("db", "", "数据库DSN")
+	logLevel := fs.String("log-level", "", "日志级别：debug、info、warn、error")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "打印生效配置（隐藏密钥）后退出")
+	if err := fs.Parse(args); err != nil {
+		return nil, opts, err
+	}
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, opts, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, opts, err
+	}
+
+	if *env != "" {
+		cfg.Env = *env
+	}
+	if *addr != "" {
+		cfg.Server.Addr = *addr
+	}
+	if *dsn != "" {
+		cfg.Database.DSN = *dsn
+	}
+	if *logLevel != "" {
+		cfg.Log.Level = *logLevel
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, opts, err
+	}
+	return cfg, opts, nil
+}
+
+// loadFile 从JSON文件加载配置，未出现的字段保留默认值
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("解析配置文件%s失败: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv 从环境变量加载配置
+func (c *Config) loadEnv() error {
+	var errs []error
+	str := func(key string, dst *string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = v
+		}
+	}
+	boolean := func(key string, dst *bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("环境变量%s不是有效的布尔值: %q", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = nil
+			for _, value := range strings.Split(v, ",") {
+				if value = strings.TrimSpace(value); value != "" {
+					*dst = append(*dst, value)
+				}
+			}
+		}
+	}
+	duration := func(key string, dst *Duration) {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("环境变量%s不是有效的时长: %q", key, v))
+				return
+			}
+			*dst = Duration(d)
+		}
+	}
+
+	str("BRB_ENV", &c.Env)
+	str("BRB_LOG_LEVEL", &c.Log.Level)
+	str("BRB_LOG_FORMAT", &c.Log.Format)
+	str("BRB_ADDR", &c.Server.Addr)
+	duration("BRB_READ_TIMEOUT", &c.Server.ReadTimeout)
+	duration("BRB_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	duration("BRB_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	duration("BRB_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	str("BRB_DB_DSN", &c.Database.DSN)
+	str("JWT_SECRET", &c.Auth.JWTSecret)
+	str("BRB_PASSWORD_BLOCKLIST_FILE", &c.Auth.Password.BlocklistFile)
+
+	boolean("BRB_METRICS_ENABLED", &c.Metrics.Enabled)
+	str("BRB_ADMIN_ADDR", &c.Metrics.AdminAddr)
+
+	boolean("BRB_SIGNS_UNIQUE_SIGNIFIERS", &c.Signs.UniqueSignifiers)
+	list("BRB_SIGNS_EDITOR_ROLES", &c.Signs.EditorRoles)
+
+	str("OIDC_ISSUER", &c.OIDC.IssuerURL)
+	str("OIDC_CLIENT_ID", &c.OIDC.ClientID)
+	str("OIDC_CLIENT_SECRET", &c.OIDC.ClientSecret)
+	str("OIDC_REDIRECT_URL", &c.OIDC.RedirectURL)
+	str("OIDC_ROLE_CLAIM", &c.OIDC.RoleClaim)
+	boolean("OIDC_LINK_EXISTING", &c.OIDC.LinkExisting)
+	str("OIDC_POST_LOGIN_REDIRECT", &c.OIDC.PostLoginRedirect)
+	list("OIDC_ADMIN_VALUES", &c.OIDC.AdminValues)
+
+	return errors.Join(errs...)
+}
+
+// Validate 校验配置，返回所有发现的问题
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Env == EnvDevelopment || c.Env == EnvProduction, "env必须是%s或%s，当前为%q", EnvDevelopment, EnvProduction, c.Env)
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level无效: %q", c.Log.Level)
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format必须是json或text，当前为%q", c.Log.Format)
+	check(c.Server.Addr != "", "server.addr不能为空")
+	check(c.Server.ReadTimeout > 0, "server.readTimeout必须大于0")
+	check(c.Server.WriteTimeout > 0, "server.writeTimeout必须大于0")
+	check(c.Server.IdleTimeout > 0, "server.idleTimeout必须大于0")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdownTimeout必须大于0")
+	check(c.Database.DSN != "", "database.dsn不能为空")
+
+	check(c.Auth.JWTSecret != "", "auth.jwtSecret不能为空")
+	if c.Env == EnvProduction {
+		check(c.Auth.JWTSecret != DefaultJWTSecret, "生产环境禁止使用默认JWT密钥，请设置JWT_SECRET")
+		check(len(c.Auth.JWTSecret) >= 32, "生产环境的JWT密钥长度不能少于32字节")
+	}
+
+	login := c.Auth.Login
+	check(login.BackoffThreshold > 0, "auth.login.backoffThreshold必须大于0")
+	check(login.BackoffBase > 0, "auth.login.backoffBase必须大于0")
+	check(login.LockoutThreshold >= login.BackoffThreshold, "auth.login.lockoutThreshold不能小于backoffThreshold")
+	check(login.IPLockoutThreshold > 0, "auth.login.ipLockoutThreshold必须大于0")
+	check(login.LockoutDuration > 0, "auth.login.lockoutDuration必须大于0")
+	check(login.ResetAfter > 0, "auth.login.resetAfter必须大于0")
+
+	check(c.Auth.Password.MinLength > 0, "auth.password.minLength必须大于0")
+	check(c.Auth.Password.ResetTokenTTL > 0, "auth.password.resetTokenTTL必须大于0")
+
+	if c.Metrics.AdminAddr != "" {
+		check(c.Metrics.AdminAddr != c.Server.Addr, "metrics.adminAddr不能与server.addr相同")
+	}
+
+	for _, role := range c.Signs.EditorRoles {
+		check(role == "user" || role == "admin", "signs.editorRoles中的角色必须是user或admin，当前为%q", role)
+	}
+
+	if c.OIDC.Enabled() {
+		check(c.OIDC.ClientID != "", "启用OIDC时oidc.clientID不能为空")
+		check(c.OIDC.RedirectURL != "", "启用OIDC时oidc.redirectURL不能为空")
+		// 未配置管理员取值时同步角色会把所有管理员降级
+		check(c.OIDC.RoleClaim == "" || len(c.OIDC.AdminValues) > 0, "设置oidc.roleClaim时oidc.adminValues不能为空")
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted 返回隐藏了密钥的配置副本，用于打印
+func (c *Config) Redacted() *Config {
+	cp := *c
+	cp.OIDC.AdminValues = append([]string(nil), c.OIDC.AdminValues...)
+	cp.Signs.EditorRoles = append([]string(nil), c.Signs.EditorRoles...)
+	cp.Auth.JWTSecret = redact(cp.Auth.JWTSecret)
+	cp.OIDC.ClientSecret = redact(cp.OIDC.ClientSecret)
+	return &cp
+}
+
+// String 以JSON格式输出隐藏密钥后的配置
+func (c *Config) String() string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(c.Redacted())
+	return strings.TrimSpace(buf.String())
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "******"
+}
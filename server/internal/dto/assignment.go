@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"brb/internal/entity"
+	"net/url"
+	"time"
+)
+
+// AssignRequest DTO for assigning a task or todo, a null assigneeId unassigns it
+type AssignRequest struct {
+	AssigneeID *uint `json:"assigneeId"`
+}
+
+// AgendaStatusQuery 解析个人议程的status查询参数，为空时不按状态筛选
+func AgendaStatusQuery(query url.Values) (entity.Status, error) {
+	status := query.Get("status")
+	if status == "" {
+		return "", nil
+	}
+	var v validator
+	v.oneOf("status", status, validStatuses...)
+	if err := v.err(); err != nil {
+		return "", err
+	}
+	return entity.Status(status), nil
+}
+
+// AssignmentResponse DTO for assignment history entries
+type AssignmentResponse struct {
+	ID           uint   `json:"id"`
+	TargetType   string `json:"targetType"`
+	TargetID     uint   `json:"targetId"`
+	FromUserID   *uint  `json:"fromUserId"`
+	ToUserID     *uint  `json:"toUserId"`
+	AssignedByID uint   `json:"assignedById"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+// FromAssignmentEntity converts entity.Assignment to AssignmentResponse
+func FromAssignmentEntity(assignment *entity.Assignment) *AssignmentResponse {
+	return &AssignmentResponse{
+		ID:           assignment.ID,
+		TargetType:   string(assignment.TargetType),
+		TargetID:     assignment.TargetID,
+		FromUserID:   assignment.FromUserID,
+		ToUserID:     assignment.ToUserID,
+		AssignedByID: assignment.AssignedByID,
+		CreatedAt:    assignment.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// FromAssignmentEntities converts a slice of entity.Assignment to a slice of AssignmentResponse
+func FromAssignmentEntities(assignments []*entity.Assignment) []*AssignmentResponse {
+	responses := make([]*AssignmentResponse, len(assignments))
+	for i, assignment := range assignments {
+		responses[i] = FromAssignmentEntity(assignment)
+	}
+	return responses
+}
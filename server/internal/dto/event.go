@@ -1,36 +1,74 @@
 package dto
 
-import "brb/internal/entity"
+import (
+	"fmt"
+	"strings"
+
+	"brb/internal/entity"
+)
 
 // EventCreateRequest DTO for creating an event
 type EventCreateRequest struct {
-	IsTemplate  bool   `json:"isTemplate" form:"isTemplate"`
-	Title       string `json:"title" form:"title"`
-	Description string `json:"description" form:"description"`
-	Location    string `json:"location" form:"location"`
-	Priority    int    `json:"priority" form:"priority"`
-	Category    string `json:"category" form:"category"`
+	IsTemplate  bool     `json:"isTemplate" form:"isTemplate"`
+	Title       string   `json:"title" form:"title"`
+	Description string   `json:"description" form:"description"`
+	Location    string   `json:"location" form:"location"`
+	Priority    int      `json:"priority" form:"priority"`
+	Category    string   `json:"category" form:"category"` // 已弃用：转换为同名标签
+	Tags        []string `json:"tags" form:"tags"`
 }
 
 // EventUpdateRequest DTO for updating an event
+// Omitting tags keeps the existing ones, an empty array removes them
 type EventUpdateRequest struct {
-	IsTemplate  bool   `json:"isTemplate"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Location    string `json:"location"`
-	Priority    int    `json:"priority"`
-	Category    string `json:"category"`
+	IsTemplate  bool     `json:"isTemplate"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Location    string   `json:"location"`
+	Priority    int      `json:"priority"`
+	Category    string   `json:"category"` // 已弃用：追加为同名标签
+	Tags        []string `json:"tags"`
+}
+
+// Validate 校验创建event请求
+func (req *EventCreateRequest) Validate() error {
+	return validateEvent(req.Title, req.Description, req.Location, req.Category, req.Priority, req.Tags)
+}
+
+// Validate 校验更新event请求
+func (req *EventUpdateRequest) Validate() error {
+	return validateEvent(req.Title, req.Description, req.Location, req.Category, req.Priority, req.Tags)
+}
+
+func validateEvent(title, description, location, category string, priority int, tags []string) error {
+	var v validator
+	if v.required("title", title) {
+		v.length("title", title, 1, maxTitleLength)
+	}
+	v.length("description", description, 0, maxDescriptionLength)
+	v.length("location", location, 0, maxShortTextLength)
+	if strings.TrimSpace(category) != "" {
+		v.tag("category", category)
+	}
+	v.between("priority", priority, 0, 5) // 0表示未设置
+	if len(tags) > entity.MaxEventTags {
+		v.add("tags", "最多%d个标签", entity.MaxEventTags)
+	}
+	for i, tag := range tags {
+		v.tag(fmt.Sprintf("tags[%d]", i), tag)
+	}
+	return v.err()
 }
 
 // EventResponse DTO for event responses
 type EventResponse struct {
-	ID          uint   `json:"id"`
-	IsTemplate  bool   `json:"isTemplate"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Location    string `json:"location"`
-	Priority    int    `json:"priority"`
-	Category    string `json:"category"`
+	ID          uint     `json:"id"`
+	IsTemplate  bool     `json:"isTemplate"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Location    string   `json:"location"`
+	Priority    int      `json:"priority"`
+	Tags        []string `json:"tags"`
 }
 
 // ToEntity converts EventCreateRequest to entity.Event
@@ -41,7 +79,8 @@ func (req *EventCreateRequest) ToEntity() *entity.Event {
 		Description: req.Description,
 		Location:    req.Location,
 		Priority:    req.Priority,
-		Category:    req.Category,
+		Category:    normalizeTag(req.Category),
+		Tags:        normalizeTags(req.Tags),
 	}
 }
 
@@ -54,7 +93,20 @@ func (req *EventUpdateRequest) ToEntity(id uint) *entity.Event {
 		Description: req.Description,
 		Location:    req.Location,
 		Priority:    req.Priority,
-		Category:    req.Category,
+		Category:    normalizeTag(req.Category),
+		Tags:        normalizeTags(req.Tags),
+	}
+}
+
+// EventUpdateRequestFromEntity converts entity.Event to the EventUpdateRequest describing its current state, used as the base of a merge patch
+func EventUpdateRequestFromEntity(event *entity.Event) *EventUpdateRequest {
+	return &EventUpdateRequest{
+		IsTemplate:  event.IsTemplate,
+		Title:       event.Title,
+		Description: event.Description,
+		Location:    event.Location,
+		Priority:    event.Priority,
+		Tags:        event.Tags,
 	}
 }
 
@@ -67,7 +119,7 @@ func FromEventEntity(event *entity.Event) *EventResponse {
 		Description: event.Description,
 		Location:    event.Location,
 		Priority:    event.Priority,
-		Category:    event.Category,
+		Tags:        event.Tags,
 	}
 }
 
@@ -0,0 +1,62 @@
+package dto
+
+import "brb/internal/entity"
+
+// OntonCreateRequest DTO for creating an onton
+type OntonCreateRequest struct {
+	Adic int `json:"adic"`
+}
+
+// OntonUpdateRequest DTO for updating an onton
+type OntonUpdateRequest struct {
+	Adic int `json:"adic"`
+}
+
+// Validate 校验创建onton请求
+func (req *OntonCreateRequest) Validate() error {
+	return validateOnton(req.Adic)
+}
+
+// Validate 校验更新onton请求
+func (req *OntonUpdateRequest) Validate() error {
+	return validateOnton(req.Adic)
+}
+
+func validateOnton(adic int) error {
+	var v validator
+	v.between("adic", adic, entity.MinAdic, entity.MaxAdic)
+	return v.err()
+}
+
+// OntonResponse DTO for onton responses
+type OntonResponse struct {
+	ID   int64 `json:"id"`
+	Adic int   `json:"adic"`
+}
+
+// ToEntity converts OntonCreateRequest to entity.Onton
+func (req *OntonCreateRequest) ToEntity() *entity.Onton {
+	return &entity.Onton{Adic: int8(req.Adic)}
+}
+
+// ToEntity converts OntonUpdateRequest to entity.Onton
+func (req *OntonUpdateRequest) ToEntity(id int64) *entity.Onton {
+	return &entity.Onton{ID: id, Adic: int8(req.Adic)}
+}
+
+// FromOntonEntity converts entity.Onton to OntonResponse
+func FromOntonEntity(onton *entity.Onton) *OntonResponse {
+	return &OntonResponse{
+		ID:   onton.ID,
+		Adic: int(onton.Adic),
+	}
+}
+
+// FromOntonEntities converts a slice of entity.Onton to a slice of OntonResponse
+func FromOntonEntities(ontons []*entity.Onton) []*OntonResponse {
+	responses := make([]*OntonResponse, len(ontons))
+	for i, onton := range ontons {
+		responses[i] = FromOntonEntity(onton)
+	}
+	return responses
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// 分页参数的默认值和上限
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// PageRequest 分页参数
+type PageRequest struct {
+	Limit  int
+	Offset int
+}
+
+// ParsePageRequest 读取查询参数limit和offset，limit缺省为50
+func ParsePageRequest(query url.Values) (PageRequest, error) {
+	var v validator
+	page := PageRequest{Limit: defaultPageLimit}
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			v.add("limit", "必须是整数")
+		} else {
+			v.between("limit", n, 1, maxPageLimit)
+			page.Limit = n
+		}
+	}
+	if s := query.Get("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			v.add("offset", "必须是非负整数")
+		} else {
+			page.Offset = n
+		}
+	}
+	return page, v.err()
+}
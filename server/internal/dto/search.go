@@ -0,0 +1,114 @@
+package dto
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"brb/internal/entity"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	maxSearchLength    = 200
+	maxSearchTerms     = 10
+)
+
+// SearchQuery converts query parameters to entity.SearchQuery
+// q is split on whitespace into terms that must all match; type is a comma separated
+// list of event, task, todo and sign; tasks and todos are limited to those assigned to
+// userID and events to those containing them, mine=false lifts this for admins only
+func SearchQuery(query url.Values, userID uint) (entity.SearchQuery, error) {
+	var v validator
+	q := strings.TrimSpace(query.Get("q"))
+	if v.required("q", q) {
+		v.length("q", q, 1, maxSearchLength)
+	}
+	terms := strings.Fields(q)
+	if len(terms) > maxSearchTerms {
+		v.add("q", "最多%d个搜索词", maxSearchTerms)
+	}
+
+	var types []entity.SearchType
+	if s := query.Get("type"); s != "" {
+		allowed := make([]string, len(entity.SearchTypes))
+		for i, t := range entity.SearchTypes {
+			allowed[i] = string(t)
+		}
+		for _, t := range strings.Split(s, ",") {
+			t = strings.TrimSpace(t)
+			v.oneOf("type", t, allowed...)
+			types = append(types, entity.SearchType(t))
+		}
+	}
+
+	limit := defaultSearchLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			v.add("limit", "必须是整数")
+		} else {
+			v.between("limit", n, 1, maxSearchLimit)
+			limit = n
+		}
+	}
+
+	scope := &userID
+	if s := query.Get("mine"); s != "" {
+		mine, err := strconv.ParseBool(s)
+		if err != nil {
+			v.add("mine", "必须是true或false")
+		} else if !mine {
+			scope = nil
+		}
+	}
+
+	if err := v.err(); err != nil {
+		return entity.SearchQuery{}, err
+	}
+	return entity.SearchQuery{Terms: terms, Types: types, UserID: scope, Limit: limit}, nil
+}
+
+// SearchHitResponse DTO for a search hit
+// Fields holds the HTML-escaped text fields of the hit with matches wrapped in <mark></mark>
+type SearchHitResponse struct {
+	ID     int64             `json:"id"`
+	Score  float64           `json:"score"`
+	Fields map[string]string `json:"fields"`
+}
+
+// SearchResponse DTO for search results grouped by type, types not searched are null
+// FullText is false when the server was built without FTS5 (go build -tags sqlite_fts5),
+// in which case terms are matched with LIKE and hits are ordered by id instead of relevance
+type SearchResponse struct {
+	Query    string               `json:"query"`
+	FullText bool                 `json:"fullText"`
+	Events   []*SearchHitResponse `json:"events"`
+	Tasks    []*SearchHitResponse `json:"tasks"`
+	Todos    []*SearchHitResponse `json:"todos"`
+	Signs    []*SearchHitResponse `json:"signs"`
+}
+
+// FromSearchResults converts entity.SearchResults to SearchResponse
+func FromSearchResults(results *entity.SearchResults, query entity.SearchQuery) *SearchResponse {
+	return &SearchResponse{
+		Query:    strings.Join(query.Terms, " "),
+		FullText: results.FullText,
+		Events:   fromSearchHits(results.Events),
+		Tasks:    fromSearchHits(results.Tasks),
+		Todos:    fromSearchHits(results.Todos),
+		Signs:    fromSearchHits(results.Signs),
+	}
+}
+
+func fromSearchHits(hits []*entity.SearchHit) []*SearchHitResponse {
+	if hits == nil {
+		return nil
+	}
+	responses := make([]*SearchHitResponse, len(hits))
+	for i, hit := range hits {
+		responses[i] = &SearchHitResponse{ID: hit.ID, Score: hit.Score, Fields: hit.Fields}
+	}
+	return responses
+}
@@ -1,24 +1,124 @@
 package dto
 
-import "brb/internal/entity"
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"brb/internal/entity"
+	"brb/pkg/langtag"
+)
 
 // SignCreateRequest DTO for creating a sign
 type SignCreateRequest struct {
-	Signifier string `json:"signifier"`
-	Signified string `json:"signified"`
+	Signifier string             `json:"signifier"`
+	Signified string             `json:"signified"`
+	Labels    []SignLabelRequest `json:"labels"`
 }
 
 // SignUpdateRequest DTO for updating a sign
+// Omitting labels keeps the existing ones, an empty array removes them
 type SignUpdateRequest struct {
-	Signifier string `json:"signifier"`
-	Signified string `json:"signified"`
+	Signifier string             `json:"signifier"`
+	Signified string             `json:"signified"`
+	Labels    []SignLabelRequest `json:"labels"`
+}
+
+// SignLabelRequest DTO for a language-tagged signifier
+// A language without a preferred label uses its first label as preferred
+type SignLabelRequest struct {
+	Lang      string `json:"lang"`
+	Value     string `json:"value"`
+	Preferred bool   `json:"preferred"`
+}
+
+// Validate 校验创建sign请求
+func (req *SignCreateRequest) Validate() error {
+	return validateSign(req.Signifier, req.Signified, req.Labels)
+}
+
+// Validate 校验更新sign请求
+func (req *SignUpdateRequest) Validate() error {
+	return validateSign(req.Signifier, req.Signified, req.Labels)
+}
+
+func validateSign(signifier, signified string, labels []SignLabelRequest) error {
+	var v validator
+	if v.required("signifier", signifier) {
+		v.length("signifier", signifier, 1, maxSignLength)
+	}
+	v.length("signified", signified, 0, maxSignLength)
+	if len(labels) > entity.MaxSignLabels {
+		v.add("labels", "最多%d个多语言能指", entity.MaxSignLabels)
+	}
+
+	seen := map[entity.SignLabel]bool{}
+	preferred := map[string]bool{}
+	for i, label := range labels {
+		field := fmt.Sprintf("labels[%d]", i)
+		lang, ok := langtag.Canonical(label.Lang)
+		if !ok {
+			v.add(field+".lang", "不是有效的BCP 47语言标签")
+			continue
+		}
+		if v.required(field+".value", label.Value) {
+			v.length(field+".value", label.Value, 1, maxSignLength)
+		}
+		key := entity.SignLabel{Lang: lang, Value: label.Value}
+		if seen[key] {
+			v.add(field+".value", "与同一语言的其他能指重复")
+		}
+		seen[key] = true
+		if label.Preferred {
+			if preferred[lang] {
+				v.add(field+".preferred", "每种语言只能有一个首选能指")
+			}
+			preferred[lang] = true
+		}
+	}
+	return v.err()
+}
+
+// toSignLabels 转换多语言能指并规范语言标签，nil保持为nil
+func toSignLabels(labels []SignLabelRequest) []entity.SignLabel {
+	if labels == nil {
+		return nil
+	}
+	result := make([]entity.SignLabel, len(labels))
+	for i, label := range labels {
+		lang, _ := langtag.Canonical(label.Lang)
+		result[i] = entity.SignLabel{Lang: lang, Value: label.Value, Preferred: label.Preferred}
+	}
+	return result
 }
 
 // SignResponse DTO for sign responses
+// Signifier is the preferred label negotiated from Accept-Language, Lang is its
+// language tag, empty when falling back to the default signifier
 type SignResponse struct {
-	ID        int64  `json:"id"`
-	Signifier string `json:"signifier"`
-	Signified string `json:"signified"`
+	ID               int64                `json:"id"`
+	Signifier        string               `json:"signifier"`
+	Lang             string               `json:"lang,omitempty"`
+	Signified        string               `json:"signified"`
+	DefaultSignifier string               `json:"defaultSignifier"`
+	Labels           []*SignLabelResponse `json:"labels"`
+	CreatedBy        uint                 `json:"createdBy,omitempty"`
+	UpdatedBy        uint                 `json:"updatedBy,omitempty"`
+}
+
+// SignLabelResponse DTO for a language-tagged signifier
+type SignLabelResponse struct {
+	Lang      string `json:"lang"`
+	Value     string `json:"value"`
+	Preferred bool   `json:"preferred"`
+}
+
+// SignListResponse DTO for a page of signs
+type SignListResponse struct {
+	Items  []*SignResponse `json:"items"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
 }
 
 // ToEntity converts SignCreateRequest to entity.Sign
@@ -26,6 +126,7 @@ func (req *SignCreateRequest) ToEntity() *entity.Sign {
 	return &entity.Sign{
 		Signifier: req.Signifier,
 		Signified: req.Signified,
+		Labels:    toSignLabels(req.Labels),
 	}
 }
 
@@ -35,23 +136,90 @@ func (req *SignUpdateRequest) ToEntity(id int64) *entity.Sign {
 		ID:        id,
 		Signifier: req.Signifier,
 		Signified: req.Signified,
+		Labels:    toSignLabels(req.Labels),
 	}
 }
 
 // FromSignEntity converts entity.Sign to SignResponse
-func FromSignEntity(sign *entity.Sign) *SignResponse {
-	return &SignResponse{
-		ID:        sign.ID,
-		Signifier: sign.Signifier,
-		Signified: sign.Signified,
+// langs are language ranges in order of preference, as from Accept-Language
+func FromSignEntity(sign *entity.Sign, langs []string) *SignResponse {
+	response := &SignResponse{
+		ID:               sign.ID,
+		Signifier:        sign.Signifier,
+		Signified:        sign.Signified,
+		DefaultSignifier: sign.Signifier,
+		Labels:           make([]*SignLabelResponse, len(sign.Labels)),
+		CreatedBy:        sign.CreatedBy,
+		UpdatedBy:        sign.UpdatedBy,
+	}
+	for i, label := range sign.Labels {
+		response.Labels[i] = &SignLabelResponse{Lang: label.Lang, Value: label.Value, Preferred: label.Preferred}
+	}
+
+	preferred := sign.PreferredLabels()
+	tags := make([]string, len(preferred))
+	for i, label := range preferred {
+		tags[i] = label.Lang
 	}
+	if lang, ok := langtag.Match(langs, tags); ok {
+		for _, label := range preferred {
+			if label.Lang == lang {
+				response.Signifier = label.Value
+				response.Lang = lang
+			}
+		}
+	}
+	return response
 }
 
 // FromSignEntities converts a slice of entity.Sign to a slice of SignResponse
-func FromSignEntities(signs []*entity.Sign) []*SignResponse {
+func FromSignEntities(signs []*entity.Sign, langs []string) []*SignResponse {
 	responses := make([]*SignResponse, len(signs))
 	for i, sign := range signs {
-		responses[i] = FromSignEntity(sign)
+		responses[i] = FromSignEntity(sign, langs)
 	}
 	return responses
-}
\ No newline at end of file
+}
+
+// SignListQuery converts query parameters to entity.SignQuery
+func SignListQuery(query url.Values) (entity.SignQuery, error) {
+	page, err := ParsePageRequest(query)
+	if err != nil {
+		return entity.SignQuery{}, err
+	}
+	return entity.SignQuery{
+		Signifier: query.Get("signifier"),
+		Prefix:    query.Get("prefix"),
+		Search:    query.Get("q"),
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+	}, nil
+}
+
+// FromSignPage converts a page of entity.Sign to SignListResponse
+func FromSignPage(signs []*entity.Sign, total int, query entity.SignQuery, langs []string) *SignListResponse {
+	return &SignListResponse{
+		Items:  FromSignEntities(signs, langs),
+		Total:  total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+}
+
+// SignItemsResponse DTO for everything linked to a sign
+type SignItemsResponse struct {
+	Sign   *SignResponse    `json:"sign"`
+	Events []*EventResponse `json:"events"`
+	Tasks  []*TaskResponse  `json:"tasks"`
+	Todos  []*TodoResponse  `json:"todos"`
+}
+
+// FromSignItems converts entity.SignItems to SignItemsResponse, times are rendered in loc
+func FromSignItems(items *entity.SignItems, loc *time.Location, langs []string) *SignItemsResponse {
+	return &SignItemsResponse{
+		Sign:   FromSignEntity(items.Sign, langs),
+		Events: FromEventEntities(items.Events),
+		Tasks:  FromTaskEntities(items.Tasks, loc),
+		Todos:  FromTodoEntities(items.Todos, loc),
+	}
+}
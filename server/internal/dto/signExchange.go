@@ -0,0 +1,221 @@
+package dto
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/pkg/skos"
+)
+
+// 词汇表导入导出格式
+const (
+	SignFormatCSV  = "csv"
+	SignFormatJSON = "json"
+	SignFormatSKOS = "skos" // 仅导出，RDF Turtle
+)
+
+// maxImportRows 单次导入的记录数上限
+const maxImportRows = 10000
+
+// SignImportRow DTO for one record of a JSON import
+// Omitting labels keeps the existing ones of an updated sign, an empty array removes them
+type SignImportRow struct {
+	Signifier string             `json:"signifier"`
+	Signified string             `json:"signified"`
+	Labels    []SignLabelRequest `json:"labels"`
+}
+
+// DecodeSignImport 按format解析导入数据并逐条校验，字段错误以rows[行号].字段标出（从0开始，不含CSV表头）
+func DecodeSignImport(format string, r io.Reader) ([]*entity.Sign, error) {
+	var rows []SignImportRow
+	var err error
+	switch format {
+	case SignFormatCSV:
+		rows, err = decodeSignCSV(r)
+	case SignFormatJSON:
+		err = json.NewDecoder(r).Decode(&rows)
+	default:
+		return nil, errs.Validation("不支持的导入格式: %s", format)
+	}
+	if err != nil {
+		return nil, errs.Validation("无法解析%s数据: %v", format, err)
+	}
+	if len(rows) > maxImportRows {
+		return nil, errs.Validation("单次最多导入%d条记录", maxImportRows)
+	}
+
+	var v validator
+	signs := make([]*entity.Sign, len(rows))
+	for i, row := range rows {
+		if err := validateSign(row.Signifier, row.Signified, row.Labels); err != nil {
+			if e, ok := errs.As(err); ok {
+				for _, field := range e.Fields {
+					v.add(fmt.Sprintf("rows[%d].%s", i, field.Field), "%s", field.Message)
+				}
+			}
+		}
+		signs[i] = &entity.Sign{Signifier: row.Signifier, Signified: row.Signified, Labels: toSignLabels(row.Labels)}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return signs, nil
+}
+
+// decodeSignCSV 第一行为表头，须包含signifier列，signified和labels列可省略，其他列忽略
+// labels列为多语言能指的JSON数组，与JSON格式的labels字段相同，空白时保持原有的多语言能指
+func decodeSignCSV(r io.Reader) ([]SignImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("缺少表头")
+		}
+		return nil, err
+	}
+	signifierCol, signifiedCol, labelsCol := -1, -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "signifier":
+			signifierCol = i
+		case "signified":
+			signifiedCol = i
+		case "labels":
+			labelsCol = i
+		}
+	}
+	if signifierCol < 0 {
+		return nil, errors.New("表头缺少signifier列")
+	}
+
+	var rows []SignImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var row SignImportRow
+		if signifierCol < len(record) {
+			row.Signifier = record[signifierCol]
+		}
+		if signifiedCol >= 0 && signifiedCol < len(record) {
+			row.Signified = record[signifiedCol]
+		}
+		if labelsCol >= 0 && labelsCol < len(record) && strings.TrimSpace(record[labelsCol]) != "" {
+			if err := json.Unmarshal([]byte(record[labelsCol]), &row.Labels); err != nil {
+				line, _ := reader.FieldPos(labelsCol)
+				return nil, fmt.Errorf("第%d行的labels不是有效的JSON数组: %v", line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// SignImportResultResponse DTO for one imported record
+type SignImportResultResponse struct {
+	Row       int    `json:"row"`
+	ID        int64  `json:"id"`
+	Signifier string `json:"signifier"`
+	Action    string `json:"action"`
+}
+
+// SignImportReport DTO for the result of an import
+type SignImportReport struct {
+	DryRun    bool                        `json:"dryRun"`
+	Created   int                         `json:"created"`
+	Updated   int                         `json:"updated"`
+	Unchanged int                         `json:"unchanged"`
+	Rows      []*SignImportResultResponse `json:"rows"`
+}
+
+// FromSignImportResults converts import results to SignImportReport
+// In a dry run the ids of created signs are not meaningful
+func FromSignImportResults(results []*entity.SignImportResult, dryRun bool) *SignImportReport {
+	report := &SignImportReport{DryRun: dryRun, Rows: make([]*SignImportResultResponse, len(results))}
+	for i, result := range results {
+		switch result.Action {
+		case entity.SignImportCreated:
+			report.Created++
+		case entity.SignImportUpdated:
+			report.Updated++
+		case entity.SignImportUnchanged:
+			report.Unchanged++
+		}
+		row := &SignImportResultResponse{Row: i, ID: result.Sign.ID, Signifier: result.Sign.Signifier, Action: string(result.Action)}
+		if dryRun && result.Action == entity.SignImportCreated {
+			row.ID = 0
+		}
+		report.Rows[i] = row
+	}
+	return report
+}
+
+// EncodeSignsCSV 以CSV输出sign，表头为id,signifier,signified,labels，labels列的格式与导入相同
+func EncodeSignsCSV(w io.Writer, signs []*entity.Sign) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "signifier", "signified", "labels"})
+	for _, sign := range signs {
+		labels := make([]SignLabelRequest, len(sign.Labels))
+		for i, label := range sign.Labels {
+			labels[i] = SignLabelRequest{Lang: label.Lang, Value: label.Value, Preferred: label.Preferred}
+		}
+		encoded, err := json.Marshal(labels)
+		if err != nil {
+			return err
+		}
+		writer.Write([]string{strconv.FormatInt(sign.ID, 10), sign.Signifier, sign.Signified, string(encoded)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// SignScheme 将sign及其关系转换为SKOS词表，概念URI为baseURI/{id}
+// 默认能指为不带语言标签的prefLabel，多语言能指按语言标注
+// 上下位关系输出为skos:broader/narrower，同义为skos:exactMatch，引用为skos:related，部分为dcterms:isPartOf
+func SignScheme(baseURI string, signs []*entity.Sign, relations []*entity.SignRelation) skos.Scheme {
+	baseURI = strings.TrimSuffix(baseURI, "/")
+	uri := func(id int64) string { return baseURI + "/" + strconv.FormatInt(id, 10) }
+
+	concepts := make([]skos.Concept, len(signs))
+	index := make(map[int64]*skos.Concept, len(signs))
+	for i, sign := range signs {
+		concepts[i] = skos.Concept{URI: uri(sign.ID), PrefLabel: sign.Signifier, Definition: sign.Signified}
+		for _, label := range sign.Labels {
+			concepts[i].Labels = append(concepts[i].Labels, skos.Label{Value: label.Value, Lang: label.Lang, Preferred: label.Preferred})
+		}
+		index[sign.ID] = &concepts[i]
+	}
+	for _, relation := range relations {
+		from, to := index[relation.FromID], index[relation.ToID]
+		if from == nil || to == nil {
+			continue
+		}
+		switch relation.Type {
+		case entity.SignRelationBroader:
+			from.Broader = append(from.Broader, to.URI)
+			to.Narrower = append(to.Narrower, from.URI)
+		case entity.SignRelationSynonym:
+			from.ExactMatch = append(from.ExactMatch, to.URI)
+			to.ExactMatch = append(to.ExactMatch, from.URI)
+		case entity.SignRelationReferences:
+			from.Related = append(from.Related, to.URI)
+			to.Related = append(to.Related, from.URI)
+		case entity.SignRelationPartOf:
+			from.PartOf = append(from.PartOf, to.URI)
+		}
+	}
+	return skos.Scheme{URI: baseURI, Title: "brb glossary", Concepts: concepts}
+}
@@ -0,0 +1,125 @@
+package dto
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"brb/internal/entity"
+)
+
+// defaultGraphDepth 未指定depth时遍历的层数
+const defaultGraphDepth = 2
+
+// SignRelationCreateRequest DTO for relating a sign to another sign
+type SignRelationCreateRequest struct {
+	Type     string `json:"type"`
+	TargetID int64  `json:"targetId"`
+}
+
+// signRelationTypes relation types accepted by the API
+var signRelationTypes = []string{
+	string(entity.SignRelationSynonym),
+	string(entity.SignRelationBroader),
+	string(entity.SignRelationNarrower),
+	string(entity.SignRelationPartOf),
+	string(entity.SignRelationHasPart),
+	string(entity.SignRelationReferences),
+	string(entity.SignRelationReferencedBy),
+}
+
+// Validate 校验添加sign关系请求
+func (req *SignRelationCreateRequest) Validate() error {
+	var v validator
+	if v.required("type", req.Type) {
+		v.oneOf("type", req.Type, signRelationTypes...)
+	}
+	if req.TargetID <= 0 {
+		v.add("targetId", "不能为空")
+	}
+	return v.err()
+}
+
+// SignRelationResponse DTO for a stored sign relation
+type SignRelationResponse struct {
+	ID     int64  `json:"id"`
+	FromID int64  `json:"fromId"`
+	ToID   int64  `json:"toId"`
+	Type   string `json:"type"`
+}
+
+// SignNeighborResponse DTO for a sign directly related to another sign
+type SignNeighborResponse struct {
+	RelationID int64         `json:"relationId"`
+	Type       string        `json:"type"`
+	Sign       *SignResponse `json:"sign"`
+}
+
+// SignGraphResponse DTO for a traversed sign subgraph
+type SignGraphResponse struct {
+	Nodes     []*SignResponse         `json:"nodes"`
+	Edges     []*SignRelationResponse `json:"edges"`
+	Truncated bool                    `json:"truncated"`
+}
+
+// FromSignRelationEntity converts entity.SignRelation to SignRelationResponse
+func FromSignRelationEntity(relation *entity.SignRelation) *SignRelationResponse {
+	return &SignRelationResponse{
+		ID:     relation.ID,
+		FromID: relation.FromID,
+		ToID:   relation.ToID,
+		Type:   string(relation.Type),
+	}
+}
+
+// FromSignNeighbors converts a slice of entity.SignNeighbor to a slice of SignNeighborResponse
+func FromSignNeighbors(neighbors []*entity.SignNeighbor, langs []string) []*SignNeighborResponse {
+	responses := make([]*SignNeighborResponse, len(neighbors))
+	for i, neighbor := range neighbors {
+		responses[i] = &SignNeighborResponse{
+			RelationID: neighbor.Relation.ID,
+			Type:       string(neighbor.Type),
+			Sign:       FromSignEntity(neighbor.Sign, langs),
+		}
+	}
+	return responses
+}
+
+// FromSignGraph converts entity.SignGraph to SignGraphResponse
+func FromSignGraph(graph *entity.SignGraph, langs []string) *SignGraphResponse {
+	response := &SignGraphResponse{
+		Nodes:     FromSignEntities(graph.Nodes, langs),
+		Edges:     make([]*SignRelationResponse, len(graph.Edges)),
+		Truncated: graph.Truncated,
+	}
+	for i, edge := range graph.Edges {
+		response.Edges[i] = FromSignRelationEntity(edge)
+	}
+	return response
+}
+
+// SignRelationTypes reads the comma separated type query parameter
+func SignRelationTypes(query url.Values) []entity.SignRelationType {
+	var types []entity.SignRelationType
+	for _, t := range strings.Split(query.Get("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, entity.SignRelationType(t))
+		}
+	}
+	return types
+}
+
+// SignGraphDepth reads the depth query parameter, defaulting to 2
+func SignGraphDepth(query url.Values) (int, error) {
+	s := query.Get("depth")
+	if s == "" {
+		return defaultGraphDepth, nil
+	}
+	depth, err := strconv.Atoi(s)
+	if err != nil {
+		var v validator
+		v.add("depth", "必须是整数")
+		return 0, v.err()
+	}
+	return depth, nil
+}
@@ -0,0 +1,113 @@
+package dto
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"brb/internal/entity"
+)
+
+// TagRenameRequest DTO for renaming a tag
+type TagRenameRequest struct {
+	Name string `json:"name"`
+}
+
+// Validate 校验重命名tag请求
+func (req *TagRenameRequest) Validate() error {
+	var v validator
+	v.tag("name", req.Name)
+	return v.err()
+}
+
+// TagName returns the normalized new name
+func (req *TagRenameRequest) TagName() string {
+	return normalizeTag(req.Name)
+}
+
+// TagMergeRequest DTO for merging a tag into another
+type TagMergeRequest struct {
+	Into int64 `json:"into"`
+}
+
+// Validate 校验合并tag请求
+func (req *TagMergeRequest) Validate() error {
+	var v validator
+	if req.Into <= 0 {
+		v.add("into", "不能为空")
+	}
+	return v.err()
+}
+
+// TagResponse DTO for tag responses, usage is the number of events using the tag
+type TagResponse struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Usage int    `json:"usage"`
+}
+
+// FromTagEntity converts entity.Tag to TagResponse
+func FromTagEntity(tag *entity.Tag) *TagResponse {
+	return &TagResponse{
+		ID:    tag.ID,
+		Name:  tag.Name,
+		Usage: tag.Usage,
+	}
+}
+
+// FromTagEntities converts a slice of entity.Tag to a slice of TagResponse
+func FromTagEntities(tags []*entity.Tag) []*TagResponse {
+	responses := make([]*TagResponse, len(tags))
+	for i, tag := range tags {
+		responses[i] = FromTagEntity(tag)
+	}
+	return responses
+}
+
+// TagFilterQuery converts query parameters to entity.TagFilter
+// tags is a comma separated list of tag names, match is any (default) or all;
+// the returned filter has no tags when the tags parameter is absent
+func TagFilterQuery(query url.Values) (entity.TagFilter, error) {
+	var v validator
+	var names []string
+	if s := query.Get("tags"); s != "" {
+		names = strings.Split(s, ",")
+		if len(names) > entity.MaxEventTags {
+			v.add("tags", "最多%d个标签", entity.MaxEventTags)
+		}
+		for _, name := range names {
+			v.tag("tags", name)
+		}
+	}
+
+	match := entity.TagMatchAny
+	if s := query.Get("match"); s != "" {
+		v.oneOf("match", s, string(entity.TagMatchAny), string(entity.TagMatchAll))
+		match = entity.TagMatch(s)
+	}
+
+	if err := v.err(); err != nil {
+		return entity.TagFilter{}, err
+	}
+	return entity.TagFilter{Tags: normalizeTags(names), Match: match}, nil
+}
+
+// normalizeTag 去掉首尾空白并将连续空白合并为一个空格
+func normalizeTag(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// normalizeTags 规范标签名并去掉不区分大小写的重复，nil保持为nil
+func normalizeTags(names []string) []string {
+	if names == nil {
+		return nil
+	}
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = normalizeTag(name)
+		if !slices.ContainsFunc(result, func(existing string) bool { return strings.EqualFold(existing, name) }) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -42,16 +42,41 @@ type TaskResponse struct {
 	PlannedTime    TimeSpan `json:"plannedTime"`
 	Status         string   `json:"status"`
 	CreatedAt      string   `json:"createdAt"`
+	AssigneeID     *uint    `json:"assigneeId"`
 }
 
 // TimeSpan represents a time range with start and end
+// AllDay为true时Start和End为日期（2006-01-02），结束日期包含当天
 type TimeSpan struct {
-	Start *string `json:"start"`
-	End   *string `json:"end"`
+	Start  *string `json:"start"`
+	End    *string `json:"end"`
+	AllDay bool    `json:"allDay"`
 }
 
-// ToEntity converts TaskCreateRequest to entity.Task
-func (req *TaskCreateRequest) ToEntity() *entity.Task {
+// Validate 校验创建task请求，本地时间按loc解释
+func (req *TaskCreateRequest) Validate(loc *time.Location) error {
+	return validateTask(loc, req.EventID, req.Description, req.Status, req.AllowedStart, req.AllowedEnd, req.PlannedStart, req.PlannedEnd)
+}
+
+// Validate 校验更新task请求，本地时间按loc解释
+func (req *TaskUpdateRequest) Validate(loc *time.Location) error {
+	return validateTask(loc, req.EventID, req.Description, req.Status, req.AllowedStart, req.AllowedEnd, req.PlannedStart, req.PlannedEnd)
+}
+
+func validateTask(loc *time.Location, eventID uint, description, status string, allowedStart, allowedEnd, plannedStart, plannedEnd *string) error {
+	v := validator{loc: loc}
+	v.positive("eventId", eventID)
+	if v.required("description", description) {
+		v.length("description", description, 1, maxDescriptionLength)
+	}
+	v.oneOf("status", status, validStatuses...)
+	v.span("allowedStart", deref(allowedStart), "allowedEnd", deref(allowedEnd))
+	v.span("plannedStart", deref(plannedStart), "plannedEnd", deref(plannedEnd))
+	return v.err()
+}
+
+// ToEntity converts TaskCreateRequest to entity.Task, local times are interpreted in loc
+func (req *TaskCreateRequest) ToEntity(loc *time.Location) *entity.Task {
 	task := &entity.Task{
 		EventID:      req.EventID,
 		ParentTaskID: req.ParentTaskID,
@@ -60,20 +85,15 @@ func (req *TaskCreateRequest) ToEntity() *entity.Task {
 		Status:       entity.Status(req.Status),
 	}
 
-	// Parse time strings into TimeSpan
-	if req.AllowedStart != nil && req.AllowedEnd != nil {
-		task.AllowedTime = parseTimeSpan(*req.AllowedStart, *req.AllowedEnd)
-	}
-
-	if req.PlannedStart != nil && req.PlannedEnd != nil {
-		task.PlannedDuration = parseTimeSpan(*req.PlannedStart, *req.PlannedEnd)
-	}
+	// Parse time strings into TimeSpan, formats are checked by Validate
+	task.AllowedTime, _ = parseSpan(deref(req.AllowedStart), deref(req.AllowedEnd), loc)
+	task.PlannedDuration, _ = parseSpan(deref(req.PlannedStart), deref(req.PlannedEnd), loc)
 
 	return task
 }
 
-// ToEntity converts TaskUpdateRequest to entity.Task
-func (req *TaskUpdateRequest) ToEntity(id uint) *entity.Task {
+// ToEntity converts TaskUpdateRequest to entity.Task, local times are interpreted in loc
+func (req *TaskUpdateRequest) ToEntity(id uint, loc *time.Location) *entity.Task {
 	task := &entity.Task{
 		ID:           id,
 		EventID:      req.EventID,
@@ -83,79 +103,51 @@ func (req *TaskUpdateRequest) ToEntity(id uint) *entity.Task {
 		Status:       entity.Status(req.Status),
 	}
 
-	// Parse time strings into TimeSpan
-	if req.AllowedStart != nil && req.AllowedEnd != nil {
-		task.AllowedTime = parseTimeSpan(*req.AllowedStart, *req.AllowedEnd)
-	}
-
-	if req.PlannedStart != nil && req.PlannedEnd != nil {
-		task.PlannedDuration = parseTimeSpan(*req.PlannedStart, *req.PlannedEnd)
-	}
+	// Parse time strings into TimeSpan, formats are checked by Validate
+	task.AllowedTime, _ = parseSpan(deref(req.AllowedStart), deref(req.AllowedEnd), loc)
+	task.PlannedDuration, _ = parseSpan(deref(req.PlannedStart), deref(req.PlannedEnd), loc)
 
 	return task
 }
 
-// FromTaskEntity converts entity.Task to TaskResponse
-func FromTaskEntity(task *entity.Task) *TaskResponse {
-	response := &TaskResponse{
-		ID:           task.ID,
+// TaskUpdateRequestFromEntity converts entity.Task to the TaskUpdateRequest describing its current state, used as the base of a merge patch
+func TaskUpdateRequestFromEntity(task *entity.Task, loc *time.Location) *TaskUpdateRequest {
+	allowed := formatSpan(task.AllowedTime, loc)
+	planned := formatSpan(task.PlannedDuration, loc)
+	return &TaskUpdateRequest{
 		EventID:      task.EventID,
 		ParentTaskID: task.ParentTaskID,
 		PreTaskIDs:   task.PreTaskIDs,
 		Description:  task.Description,
+		AllowedStart: allowed.Start,
+		AllowedEnd:   allowed.End,
+		PlannedStart: planned.Start,
+		PlannedEnd:   planned.End,
 		Status:       string(task.Status),
-		CreatedAt:    task.CreatedAt.Format(time.RFC3339),
-	}
-
-	// Convert TimeSpan to string representations
-	if task.AllowedTime.Start != nil {
-		startStr := task.AllowedTime.Start.Format(time.RFC3339)
-		response.AllowedTime.Start = &startStr
-	}
-	if task.AllowedTime.End != nil {
-		endStr := task.AllowedTime.End.Format(time.RFC3339)
-		response.AllowedTime.End = &endStr
 	}
+}
 
-	if task.PlannedDuration.Start != nil {
-		startStr := task.PlannedDuration.Start.Format(time.RFC3339)
-		response.PlannedTime.Start = &startStr
-	}
-	if task.PlannedDuration.End != nil {
-		endStr := task.PlannedDuration.End.Format(time.RFC3339)
-		response.PlannedTime.End = &endStr
+// FromTaskEntity converts entity.Task to TaskResponse, times are rendered in loc
+func FromTaskEntity(task *entity.Task, loc *time.Location) *TaskResponse {
+	return &TaskResponse{
+		ID:           task.ID,
+		EventID:      task.EventID,
+		ParentTaskID: task.ParentTaskID,
+		PreTaskIDs:   task.PreTaskIDs,
+		Description:  task.Description,
+		AllowedTime:  formatSpan(task.AllowedTime, loc),
+		PlannedTime:  formatSpan(task.PlannedDuration, loc),
+		Status:       string(task.Status),
+		CreatedAt:    task.CreatedAt.In(loc).Format(time.RFC3339),
+		AssigneeID:   task.AssigneeID,
 	}
-
-	return response
 }
 
 // FromTaskEntities converts a slice of entity.Task to a slice of TaskResponse
-func FromTaskEntities(tasks []*entity.Task) []*TaskResponse {
+func FromTaskEntities(tasks []*entity.Task, loc *time.Location) []*TaskResponse {
 	responses := make([]*TaskResponse, len(tasks))
 	for i, task := range tasks {
-		responses[i] = FromTaskEntity(task)
+		responses[i] = FromTaskEntity(task, loc)
 	}
 	return responses
 }
-
-// parseTimeSpan parses start and end strings into a TimeSpan
-func parseTimeSpan(startStr, endStr string) entity.TimeSpan {
-	var startTime, endTime *time.Time
-	
-	if startStr != "" {
-		if t, err := time.Parse(time.RFC3339, startStr); err == nil {
-			startTime = &t
-		}
-	}
-	
-	if endStr != "" {
-		if t, err := time.Parse(time.RFC3339, endStr); err == nil {
-			endTime = &t
-		}
-	}
-	
-	return entity.TimeSpan{
-		Start: startTime,
-		End:   endTime,
-	}
-}
\ No newline at end of file
@@ -0,0 +1,174 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+
+	"brb/internal/entity"
+)
+
+// maxPlanMinutes 清单项计划时间的偏移和时长上限（一年）
+const maxPlanMinutes = 366 * 24 * 60
+
+// ChecklistRequest DTO for replacing a template's checklist
+// Plans are minutes relative to the start of the instantiation window, a zero duration leaves the plan unset
+type ChecklistRequest struct {
+	Tasks []ChecklistTaskItem `json:"tasks"`
+	Todos []ChecklistTodoItem `json:"todos"`
+}
+
+// ChecklistResponse DTO for a template's checklist
+type ChecklistResponse struct {
+	Tasks []ChecklistTaskItem `json:"tasks"`
+	Todos []ChecklistTodoItem `json:"todos"`
+}
+
+// ChecklistTaskItem DTO for a subtask in a checklist, the description may contain placeholders
+type ChecklistTaskItem struct {
+	Description     string              `json:"description"`
+	OffsetMinutes   int                 `json:"offsetMinutes"`
+	DurationMinutes int                 `json:"durationMinutes"`
+	Todos           []ChecklistTodoItem `json:"todos"`
+}
+
+// ChecklistTodoItem DTO for a todo in a checklist
+type ChecklistTodoItem struct {
+	OffsetMinutes   int `json:"offsetMinutes"`
+	DurationMinutes int `json:"durationMinutes"`
+}
+
+// Validate 校验设置清单请求
+func (req *ChecklistRequest) Validate() error {
+	var v validator
+	count := len(req.Todos) + len(req.Tasks)
+	for _, task := range req.Tasks {
+		count += len(task.Todos)
+	}
+	if count > entity.MaxChecklistItems {
+		v.add("tasks", "清单最多%d项", entity.MaxChecklistItems)
+	}
+
+	for i, todo := range req.Todos {
+		v.plan(fmt.Sprintf("todos[%d]", i), todo.OffsetMinutes, todo.DurationMinutes)
+	}
+	for i, task := range req.Tasks {
+		field := fmt.Sprintf("tasks[%d]", i)
+		if v.required(field+".description", task.Description) {
+			v.length(field+".description", task.Description, 1, maxDescriptionLength)
+		}
+		v.plan(field, task.OffsetMinutes, task.DurationMinutes)
+		for j, todo := range task.Todos {
+			v.plan(fmt.Sprintf("%s.todos[%d]", field, j), todo.OffsetMinutes, todo.DurationMinutes)
+		}
+	}
+	return v.err()
+}
+
+// plan 校验清单项的计划时间（分钟）
+func (v *validator) plan(field string, offset, duration int) {
+	v.between(field+".offsetMinutes", offset, 0, maxPlanMinutes)
+	v.between(field+".durationMinutes", duration, 0, maxPlanMinutes)
+}
+
+// ToEntity converts ChecklistRequest to entity.Checklist
+func (req *ChecklistRequest) ToEntity() *entity.Checklist {
+	checklist := &entity.Checklist{
+		Tasks: make([]entity.ChecklistTask, len(req.Tasks)),
+		Todos: toChecklistTodos(req.Todos),
+	}
+	for i, task := range req.Tasks {
+		checklist.Tasks[i] = entity.ChecklistTask{
+			Description: task.Description,
+			Plan:        toChecklistPlan(task.OffsetMinutes, task.DurationMinutes),
+			Todos:       toChecklistTodos(task.Todos),
+		}
+	}
+	return checklist
+}
+
+func toChecklistTodos(items []ChecklistTodoItem) []entity.ChecklistTodo {
+	todos := make([]entity.ChecklistTodo, len(items))
+	for i, item := range items {
+		todos[i] = entity.ChecklistTodo{Plan: toChecklistPlan(item.OffsetMinutes, item.DurationMinutes)}
+	}
+	return todos
+}
+
+func toChecklistPlan(offset, duration int) entity.ChecklistPlan {
+	return entity.ChecklistPlan{
+		Offset:   time.Duration(offset) * time.Minute,
+		Duration: time.Duration(duration) * time.Minute,
+	}
+}
+
+// FromChecklistEntity converts entity.Checklist to ChecklistResponse
+func FromChecklistEntity(checklist *entity.Checklist) *ChecklistResponse {
+	response := &ChecklistResponse{
+		Tasks: make([]ChecklistTaskItem, len(checklist.Tasks)),
+		Todos: fromChecklistTodos(checklist.Todos),
+	}
+	for i, task := range checklist.Tasks {
+		response.Tasks[i] = ChecklistTaskItem{
+			Description:     task.Description,
+			OffsetMinutes:   int(task.Plan.Offset / time.Minute),
+			DurationMinutes: int(task.Plan.Duration / time.Minute),
+			Todos:           fromChecklistTodos(task.Todos),
+		}
+	}
+	return response
+}
+
+func fromChecklistTodos(todos []entity.ChecklistTodo) []ChecklistTodoItem {
+	items := make([]ChecklistTodoItem, len(todos))
+	for i, todo := range todos {
+		items[i] = ChecklistTodoItem{
+			OffsetMinutes:   int(todo.Plan.Offset / time.Minute),
+			DurationMinutes: int(todo.Plan.Duration / time.Minute),
+		}
+	}
+	return items
+}
+
+// InstantiateRequest DTO for instantiating a template within a time window
+// Both ends are required and must both be dates (an all-day window) or both be times
+type InstantiateRequest struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Validate 校验实例化模板请求，本地时间按loc解释
+func (req *InstantiateRequest) Validate(loc *time.Location) error {
+	v := validator{loc: loc}
+	hasStart, hasEnd := v.required("start", req.Start), v.required("end", req.End)
+	if hasStart && hasEnd {
+		v.span("start", req.Start, "end", req.End)
+	}
+	return v.err()
+}
+
+// Window converts the request to the time window, local times are interpreted in loc
+// Formats are checked by Validate
+func (req *InstantiateRequest) Window(loc *time.Location) entity.TimeSpan {
+	window, _ := parseSpan(req.Start, req.End, loc)
+	return window
+}
+
+// TaskTreeResponse DTO for a task created from a template with its todos and subtasks
+type TaskTreeResponse struct {
+	Task     *TaskResponse       `json:"task"`
+	Todos    []*TodoResponse     `json:"todos"`
+	Subtasks []*TaskTreeResponse `json:"subtasks"`
+}
+
+// FromTaskTree converts entity.TaskTree to TaskTreeResponse, times are rendered in loc
+func FromTaskTree(tree *entity.TaskTree, loc *time.Location) *TaskTreeResponse {
+	response := &TaskTreeResponse{
+		Task:     FromTaskEntity(tree.Task, loc),
+		Todos:    FromTodoEntities(tree.Todos, loc),
+		Subtasks: make([]*TaskTreeResponse, len(tree.Subtasks)),
+	}
+	for i, subtree := range tree.Subtasks {
+		response.Subtasks[i] = FromTaskTree(subtree, loc)
+	}
+	return response
+}
@@ -0,0 +1,99 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"brb/internal/entity"
+)
+
+// 请求中接受的时间格式：
+//   - 带时区偏移的RFC3339，如2025-03-01T09:00:00+08:00
+//   - 不带偏移的本地时间（2006-01-02T15:04或2006-01-02T15:04:05），按用户时区解释
+//   - 仅日期（2006-01-02），表示全天，时间段的开始和结束须同为日期
+//
+// 响应中的时间按用户时区以RFC3339输出，全天时间段输出日期且结束日期包含当天
+const dateLayout = "2006-01-02"
+
+var localTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+var (
+	errTimeFormat     = errors.New("时间格式错误，应为RFC3339、2006-01-02T15:04或2006-01-02")
+	errSpanIncomplete = errors.New("开始和结束时间必须同时提供")
+	errSpanMixed      = errors.New("开始和结束必须同为日期或同为时间")
+)
+
+// parseTime 解析单个时间并转换为UTC，dateOnly表示输入只有日期（返回该日期的UTC 0点）
+func parseTime(value string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), false, nil
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), false, nil
+		}
+	}
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, errTimeFormat
+}
+
+// parseSpan 解析时间段，开始和结束都为空时返回空时间段
+// 全天时间段的结束时间保存为末日次日0点，与entity.TimeSpan的约定一致
+func parseSpan(start, end string, loc *time.Location) (entity.TimeSpan, error) {
+	var span entity.TimeSpan
+	if start == "" && end == "" {
+		return span, nil
+	}
+	if start == "" || end == "" {
+		return span, errSpanIncomplete
+	}
+
+	startTime, startDate, err := parseTime(start, loc)
+	if err != nil {
+		return span, err
+	}
+	endTime, endDate, err := parseTime(end, loc)
+	if err != nil {
+		return span, err
+	}
+	if startDate != endDate {
+		return span, errSpanMixed
+	}
+	if startDate {
+		endTime = endTime.AddDate(0, 0, 1)
+		span.AllDay = true
+	}
+
+	span.Start = &startTime
+	span.End = &endTime
+	return span, nil
+}
+
+// formatTime 按用户时区输出RFC3339时间
+func formatTime(t *time.Time, loc *time.Location) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.In(loc).Format(time.RFC3339)
+	return &s
+}
+
+// formatSpan 转换时间段，全天时间段输出日期
+func formatSpan(span entity.TimeSpan, loc *time.Location) TimeSpan {
+	if !span.AllDay {
+		return TimeSpan{Start: formatTime(span.Start, loc), End: formatTime(span.End, loc)}
+	}
+
+	out := TimeSpan{AllDay: true}
+	if span.Start != nil {
+		s := span.Start.UTC().Format(dateLayout)
+		out.Start = &s
+	}
+	if span.End != nil {
+		s := span.End.UTC().AddDate(0, 0, -1).Format(dateLayout)
+		out.End = &s
+	}
+	return out
+}
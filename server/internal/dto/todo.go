@@ -36,31 +36,45 @@ type TodoResponse struct {
 	PlannedTime   TimeSpan `json:"plannedTime"`
 	ActualTime    TimeSpan `json:"actualTime"`
 	CompletedTime *string  `json:"completedTime"`
+	AssigneeID    *uint    `json:"assigneeId"`
 }
 
-// ToEntity converts TodoCreateRequest to entity.Todo
-func (req *TodoCreateRequest) ToEntity() *entity.Todo {
+// Validate 校验创建todo请求，本地时间按loc解释
+func (req *TodoCreateRequest) Validate(loc *time.Location) error {
+	return validateTodo(loc, req.TaskID, req.Status, req.PlannedStart, req.PlannedEnd, req.ActualStart, req.ActualEnd)
+}
+
+// Validate 校验更新todo请求，本地时间按loc解释
+func (req *TodoUpdateRequest) Validate(loc *time.Location) error {
+	return validateTodo(loc, req.TaskID, req.Status, req.PlannedStart, req.PlannedEnd, req.ActualStart, req.ActualEnd)
+}
+
+func validateTodo(loc *time.Location, taskID uint, status, plannedStart, plannedEnd, actualStart, actualEnd string) error {
+	v := validator{loc: loc}
+	v.positive("taskId", taskID)
+	v.oneOf("status", status, validStatuses...)
+	v.span("plannedStart", plannedStart, "plannedEnd", plannedEnd)
+	v.span("actualStart", actualStart, "actualEnd", actualEnd)
+	return v.err()
+}
+
+// ToEntity converts TodoCreateRequest to entity.Todo, local times are interpreted in loc
+func (req *TodoCreateRequest) ToEntity(loc *time.Location) *entity.Todo {
 	todo := &entity.Todo{
 		EventID: req.EventID,
 		TaskID:  req.TaskID,
 		Status:  entity.Status(req.Status),
 	}
 
-	// Parse planned time
-	if req.PlannedStart != "" && req.PlannedEnd != "" {
-		todo.PlannedTime = parseTodoTimeSpan(req.PlannedStart, req.PlannedEnd)
-	}
-
-	// Parse actual time
-	if req.ActualStart != "" && req.ActualEnd != "" {
-		todo.ActualTime = parseTodoTimeSpan(req.ActualStart, req.ActualEnd)
-	}
+	// Parse planned and actual time, formats are checked by Validate
+	todo.PlannedTime, _ = parseSpan(req.PlannedStart, req.PlannedEnd, loc)
+	todo.ActualTime, _ = parseSpan(req.ActualStart, req.ActualEnd, loc)
 
 	return todo
 }
 
-// ToEntity converts TodoUpdateRequest to entity.Todo
-func (req *TodoUpdateRequest) ToEntity(id uint) *entity.Todo {
+// ToEntity converts TodoUpdateRequest to entity.Todo, local times are interpreted in loc
+func (req *TodoUpdateRequest) ToEntity(id uint, loc *time.Location) *entity.Todo {
 	todo := &entity.Todo{
 		ID:      id,
 		EventID: req.EventID,
@@ -68,88 +82,51 @@ func (req *TodoUpdateRequest) ToEntity(id uint) *entity.Todo {
 		Status:  entity.Status(req.Status),
 	}
 
-	// Parse planned time
-	if req.PlannedStart != "" && req.PlannedEnd != "" {
-		todo.PlannedTime = parseTodoTimeSpan(req.PlannedStart, req.PlannedEnd)
-	}
-
-	// Parse actual time
-	if req.ActualStart != "" && req.ActualEnd != "" {
-		todo.ActualTime = parseTodoTimeSpan(req.ActualStart, req.ActualEnd)
-	}
+	// Parse planned and actual time, formats are checked by Validate
+	todo.PlannedTime, _ = parseSpan(req.PlannedStart, req.PlannedEnd, loc)
+	todo.ActualTime, _ = parseSpan(req.ActualStart, req.ActualEnd, loc)
 
 	return todo
 }
 
-// FromTodoEntity converts entity.Todo to TodoResponse
-func FromTodoEntity(todo *entity.Todo) *TodoResponse {
-	response := &TodoResponse{
-		ID:      todo.ID,
-		EventID: todo.EventID,
-		TaskID:  todo.TaskID,
-		Status:  string(todo.Status),
-	}
-
-	// Convert planned time
-	if todo.PlannedTime.Start != nil {
-		startStr := todo.PlannedTime.Start.Format("2006-01-02T15:04")
-		response.PlannedTime.Start = &startStr
-	}
-	if todo.PlannedTime.End != nil {
-		endStr := todo.PlannedTime.End.Format("2006-01-02T15:04")
-		response.PlannedTime.End = &endStr
-	}
-
-	// Convert actual time
-	if todo.ActualTime.Start != nil {
-		startStr := todo.ActualTime.Start.Format("2006-01-02T15:04")
-		response.ActualTime.Start = &startStr
-	}
-	if todo.ActualTime.End != nil {
-		endStr := todo.ActualTime.End.Format("2006-01-02T15:04")
-		response.ActualTime.End = &endStr
+// TodoUpdateRequestFromEntity converts entity.Todo to the TodoUpdateRequest describing its current state, used as the base of a merge patch
+func TodoUpdateRequestFromEntity(todo *entity.Todo, loc *time.Location) *TodoUpdateRequest {
+	planned := formatSpan(todo.PlannedTime, loc)
+	actual := formatSpan(todo.ActualTime, loc)
+	return &TodoUpdateRequest{
+		EventID:      todo.EventID,
+		TaskID:       todo.TaskID,
+		Status:       string(todo.Status),
+		PlannedStart: deref(planned.Start),
+		PlannedEnd:   deref(planned.End),
+		ActualStart:  deref(actual.Start),
+		ActualEnd:    deref(actual.End),
 	}
+}
 
-	// Convert completed time
-	if todo.CompletedTime != nil {
-		completedStr := todo.CompletedTime.Format("2006-01-02T15:04")
-		response.CompletedTime = &completedStr
+// FromTodoEntity converts entity.Todo to TodoResponse, times are rendered in loc
+func FromTodoEntity(todo *entity.Todo, loc *time.Location) *TodoResponse {
+	return &TodoResponse{
+		ID:            todo.ID,
+		EventID:       todo.EventID,
+		TaskID:        todo.TaskID,
+		Status:        string(todo.Status),
+		PlannedTime:   formatSpan(todo.PlannedTime, loc),
+		ActualTime:    formatSpan(todo.ActualTime, loc),
+		CompletedTime: formatTime(todo.CompletedTime, loc),
+		AssigneeID:    todo.AssigneeID,
 	}
-
-	return response
 }
 
 // FromTodoEntities converts a slice of entity.Todo to a slice of TodoResponse
-func FromTodoEntities(todos []*entity.Todo) []*TodoResponse {
+func FromTodoEntities(todos []*entity.Todo, loc *time.Location) []*TodoResponse {
 	responses := make([]*TodoResponse, len(todos))
 	for i, todo := range todos {
-		responses[i] = FromTodoEntity(todo)
+		responses[i] = FromTodoEntity(todo, loc)
 	}
 	return responses
 }
 
-// parseTodoTimeSpan parses start and end strings into a TimeSpan for todos
-func parseTodoTimeSpan(startStr, endStr string) entity.TimeSpan {
-	var startTime, endTime *time.Time
-
-	if startStr != "" {
-		if t, err := time.Parse("2006-01-02T15:04", startStr); err == nil {
-			startTime = &t
-		}
-	}
-
-	if endStr != "" {
-		if t, err := time.Parse("2006-01-02T15:04", endStr); err == nil {
-			endTime = &t
-		}
-	}
-
-	return entity.TimeSpan{
-		Start: startTime,
-		End:   endTime,
-	}
-}
-
 // TodoWithDetailsCreateRequest DTO for creating a todo with task and event details
 type TodoWithDetailsCreateRequest struct {
 	// Event fields
@@ -23,15 +23,161 @@ type UserUpdateRequest struct {
 	Username string `json:"username" form:"username"`
 	Password string `json:"password" form:"password"`
 	Role     string `json:"role" form:"role"`
+	Timezone string `json:"timezone" form:"timezone"` // IANA时区名，如Asia/Shanghai
+}
+
+// PasswordChangeRequest 修改密码请求DTO（已登录）
+type PasswordChangeRequest struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+// ExpiredPasswordChangeRequest 被要求修改密码时的请求DTO（未登录）
+type ExpiredPasswordChangeRequest struct {
+	Username    string `json:"username"`
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+// PasswordResetRequest 使用重置令牌修改密码的请求DTO
+type PasswordResetRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
+// PasswordResetTokenResponse 管理员签发重置令牌的响应DTO，令牌明文仅返回这一次
+type PasswordResetTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// LoginChallengeResponse 需要两步验证时的登录响应DTO
+type LoginChallengeResponse struct {
+	TwoFactor      string `json:"twoFactor"` // verify：输入验证码；enroll：需先绑定认证器
+	ChallengeToken string `json:"challengeToken"`
+	ExpiresIn      int    `json:"expiresIn"` // 挑战令牌有效期（秒）
+}
+
+// OIDCLinkResponse 开始关联外部身份的响应DTO，前端跳转到该授权地址
+type OIDCLinkResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+// TwoFactorVerifyRequest 提交两步验证码的请求DTO
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challengeToken,omitempty"`
+	Code           string `json:"code"` // TOTP验证码或恢复码
+}
+
+// TOTPEnrollResponse 开始绑定TOTP的响应DTO
+type TOTPEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// RecoveryCodesResponse 恢复码响应DTO，明文仅返回这一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string      `json:"recoveryCodes"`
+	User          *UserResponse `json:"user,omitempty"`
+	Token         string        `json:"token,omitempty"`
+}
+
+// TwoFactorPolicyRequest 设置角色两步验证要求的请求DTO
+type TwoFactorPolicyRequest struct {
+	Role     string `json:"role"`
+	Required bool   `json:"required"`
+}
+
+// 用户名长度限制
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 32
+)
+
+// Validate 校验注册请求，密码强度由密码策略检查
+func (req *UserRegisterRequest) Validate() error {
+	var v validator
+	if v.required("username", req.Username) {
+		v.length("username", req.Username, minUsernameLength, maxUsernameLength)
+	}
+	v.required("password", req.Password)
+	return v.err()
+}
+
+// Validate 校验登录请求
+func (req *UserLoginRequest) Validate() error {
+	var v validator
+	v.required("username", req.Username)
+	v.required("password", req.Password)
+	return v.err()
+}
+
+// Validate 校验用户更新请求，空字段表示不修改
+func (req *UserUpdateRequest) Validate() error {
+	var v validator
+	if req.Username != "" {
+		v.length("username", req.Username, minUsernameLength, maxUsernameLength)
+	}
+	if req.Role != "" {
+		v.oneOf("role", req.Role, string(entity.RoleUser), string(entity.RoleAdmin))
+	}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			v.add("timezone", "无效的时区")
+		}
+	}
+	return v.err()
+}
+
+// Validate 校验修改密码请求
+func (req *PasswordChangeRequest) Validate() error {
+	var v validator
+	v.required("oldPassword", req.OldPassword)
+	v.required("newPassword", req.NewPassword)
+	return v.err()
+}
+
+// Validate 校验过期密码修改请求
+func (req *ExpiredPasswordChangeRequest) Validate() error {
+	var v validator
+	v.required("username", req.Username)
+	v.required("oldPassword", req.OldPassword)
+	v.required("newPassword", req.NewPassword)
+	return v.err()
+}
+
+// Validate 校验重置密码请求
+func (req *PasswordResetRequest) Validate() error {
+	var v validator
+	v.required("token", req.Token)
+	v.required("newPassword", req.NewPassword)
+	return v.err()
+}
+
+// Validate 校验两步验证码请求，challengeToken在登录流程中由处理器检查
+func (req *TwoFactorVerifyRequest) Validate() error {
+	var v validator
+	v.required("code", req.Code)
+	return v.err()
+}
+
+// Validate 校验两步验证策略请求
+func (req *TwoFactorPolicyRequest) Validate() error {
+	var v validator
+	v.oneOf("role", req.Role, string(entity.RoleUser), string(entity.RoleAdmin))
+	return v.err()
 }
 
 // UserResponse 用户响应DTO
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID                 uint      `json:"id"`
+	Username           string    `json:"username"`
+	Role               string    `json:"role"`
+	MustChangePassword bool      `json:"mustChangePassword"`
+	TwoFactorEnabled   bool      `json:"twoFactorEnabled"`
+	Timezone           string    `json:"timezone"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
 }
 
 // LoginResponse 登录响应DTO
@@ -72,11 +218,14 @@ func (req *UserUpdateRequest) ToEntity(id uint) *entity.User {
 // FromUserEntity 将entity.User转换为UserResponse
 func FromUserEntity(user *entity.User) *UserResponse {
 	return &UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Role:      string(user.Role),
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:                 user.ID,
+		Username:           user.Username,
+		Role:               string(user.Role),
+		MustChangePassword: user.MustChangePassword,
+		TwoFactorEnabled:   user.TOTPEnabled,
+		Timezone:           user.Location().String(),
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
 	}
 }
 
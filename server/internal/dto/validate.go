@@ -0,0 +1,142 @@
+package dto
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+// 字段长度上限
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 2000
+	maxShortTextLength   = 100
+	maxSignLength        = 500
+	maxTagLength         = 50
+)
+
+// validStatuses 允许的task/todo状态
+var validStatuses = []string{
+	string(entity.StatusPending),
+	string(entity.StatusInProgress),
+	string(entity.StatusCompleted),
+	string(entity.StatusCancelled),
+}
+
+// validator 收集字段级错误，Validate方法在转换为实体之前调用
+// loc为解释不带偏移的本地时间所用的用户时区，为nil时按UTC
+type validator struct {
+	fields []errs.FieldError
+	loc    *time.Location
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.fields = append(v.fields, errs.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// required 字符串不能为空白
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "不能为空")
+		return false
+	}
+	return true
+}
+
+// length 限制字符数（按字符而非字节计算）
+func (v *validator) length(field, value string, min, max int) {
+	n := utf8.RuneCountInString(value)
+	switch {
+	case n < min:
+		v.add(field, "长度不能少于%d个字符", min)
+	case n > max:
+		v.add(field, "长度不能超过%d个字符", max)
+	}
+}
+
+// oneOf 值必须在允许列表中，空值由调用方决定是否允许
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.add(field, "必须是以下之一: %s", strings.Join(allowed, ", "))
+	}
+}
+
+// between 整数范围
+func (v *validator) between(field string, value, min, max int) {
+	if value < min || value > max {
+		v.add(field, "必须在%d到%d之间", min, max)
+	}
+}
+
+// positive ID等必须大于0的字段
+func (v *validator) positive(field string, value uint) {
+	if value == 0 {
+		v.add(field, "不能为空")
+	}
+}
+
+// tag 标签名不能为空白，且不能包含用于分隔筛选参数的逗号
+func (v *validator) tag(field, name string) {
+	if !v.required(field, name) {
+		return
+	}
+	v.length(field, normalizeTag(name), 1, maxTagLength)
+	if strings.Contains(name, ",") {
+		v.add(field, "不能包含逗号")
+	}
+}
+
+// span 校验一对开始/结束时间：须同时提供、格式正确，且开始不能晚于结束
+// 与parseSpan一样按v.loc解释本地时间，一端带偏移、另一端为本地时间时也能正确比较先后
+func (v *validator) span(startField, start, endField, end string) {
+	if (start == "") != (end == "") {
+		v.add(startField, "%s和%s必须同时提供", startField, endField)
+		return
+	}
+	if start == "" {
+		return
+	}
+
+	loc := v.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	startTime, startDate, startErr := parseTime(start, loc)
+	if startErr != nil {
+		v.add(startField, "%s", startErr)
+	}
+	endTime, endDate, endErr := parseTime(end, loc)
+	if endErr != nil {
+		v.add(endField, "%s", endErr)
+	}
+	if startErr != nil || endErr != nil {
+		return
+	}
+
+	switch {
+	case startDate != endDate:
+		v.add(endField, "必须与%s同为日期或同为时间", startField)
+	case endTime.Before(startTime):
+		v.add(endField, "不能早于%s", startField)
+	}
+}
+
+// err 有字段错误时返回Validation错误
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return errs.Validation("请求参数校验失败").WithFields(v.fields...)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
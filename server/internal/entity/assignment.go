@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// AssignmentTarget 指派对象的类型
+type AssignmentTarget string
+
+const (
+	AssignmentTargetTask AssignmentTarget = "task"
+	AssignmentTargetTodo AssignmentTarget = "todo"
+)
+
+// Assignment 指派变更记录，记录一次负责人的变化
+type Assignment struct {
+	ID           uint             // 主键ID
+	TargetType   AssignmentTarget // 指派对象类型（task/todo）
+	TargetID     uint             // 指派对象ID
+	FromUserID   *uint            // 原负责人（可空，表示原先未指派）
+	ToUserID     *uint            // 新负责人（可空，表示取消指派）
+	AssignedByID uint             // 操作人用户ID
+	CreatedAt    time.Time        // 变更时间
+}
@@ -0,0 +1,39 @@
+package entity
+
+// SearchType 全文搜索的对象类型
+type SearchType string
+
+const (
+	SearchEvent SearchType = "event"
+	SearchTask  SearchType = "task"
+	SearchTodo  SearchType = "todo" // 按所属task的描述匹配
+	SearchSign  SearchType = "sign"
+)
+
+// SearchTypes 全部可搜索的类型
+var SearchTypes = []SearchType{SearchEvent, SearchTask, SearchTodo, SearchSign}
+
+// SearchQuery 全文搜索条件，多个词之间为AND关系
+type SearchQuery struct {
+	Terms  []string
+	Types  []SearchType // 为空时搜索全部类型
+	UserID *uint        // 不为nil时只搜索分配给该用户的task和todo，以及包含它们的event；sign不受限。为nil仅限管理员
+	Limit  int          // 每种类型最多返回的条数
+}
+
+// SearchHit 一条搜索结果，Fields为各文本字段HTML转义并以<mark>标记匹配位置后的内容
+type SearchHit struct {
+	Type   SearchType
+	ID     int64
+	Score  float64 // 相关度，越大越相关
+	Fields map[string]string
+}
+
+// SearchResults 按类型分组的搜索结果，未搜索的类型为nil
+type SearchResults struct {
+	FullText bool // 是否使用全文索引；SQLite未启用FTS5时为false，结果按LIKE匹配且不按相关度排序
+	Events   []*SearchHit
+	Tasks    []*SearchHit
+	Todos    []*SearchHit
+	Signs    []*SearchHit
+}
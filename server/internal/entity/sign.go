@@ -1,14 +1,146 @@
 package entity
 
 // Sign 表示能指/所指实体
+// Signifier为默认能指，Labels为按语言标注的其他能指
 type Sign struct {
-	ID        int64  `json:"id"`
-	Signifier string `json:"signifier"` // 能指
-	Signified string `json:"signified"` // 所指
+	ID        int64       `json:"id"`
+	Signifier string      `json:"signifier"` // 能指
+	Signified string      `json:"signified"` // 所指
+	Labels    []SignLabel `json:"labels"`    // 为nil时更新sign不改动已有的能指
+	CreatedBy uint        `json:"createdBy"` // 创建者的用户ID，0表示未知（早于记录创建者的数据）
+	UpdatedBy uint        `json:"updatedBy"` // 最后修改者的用户ID
 }
 
+// SignLabel 带语言标签的能指，每种语言至多一个首选能指
+type SignLabel struct {
+	Lang      string `json:"lang"` // BCP 47语言标签，如zh-Hans、en
+	Value     string `json:"value"`
+	Preferred bool   `json:"preferred"`
+}
+
+// MaxSignLabels 一个sign最多的多语言能指数
+const MaxSignLabels = 50
+
+// PreferredLabels 返回各语言的首选能指
+func (s *Sign) PreferredLabels() []SignLabel {
+	var labels []SignLabel
+	for _, label := range s.Labels {
+		if label.Preferred {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// SignQuery sign列表的查询条件，字符串条件为空时不参与筛选
+type SignQuery struct {
+	Signifier string // 默认能指或任一多语言能指精确匹配
+	Prefix    string // 默认能指或任一多语言能指前缀匹配
+	Search    string // 所指包含的子串
+	Limit     int
+	Offset    int
+}
+
+// SignImportAction 批量导入时对一条记录的处理
+type SignImportAction string
+
+const (
+	SignImportCreated   SignImportAction = "created"   // 能指不存在，新建
+	SignImportUpdated   SignImportAction = "updated"   // 能指已存在，更新所指或多语言能指
+	SignImportUnchanged SignImportAction = "unchanged" // 能指已存在且所指和多语言能指都相同
+)
+
+// SignImportResult 一条导入记录及其处理结果，Sign.ID为新建或更新的sign
+type SignImportResult struct {
+	Sign   *Sign
+	Action SignImportAction
+}
+
+// SignTarget 可以关联sign的对象类型
+type SignTarget string
+
+const (
+	SignTargetEvent SignTarget = "event"
+	SignTargetTask  SignTarget = "task"
+	SignTargetTodo  SignTarget = "todo"
+)
+
+// SignItems 关联了某个sign的全部event、task和todo
+type SignItems struct {
+	Sign   *Sign
+	Events []*Event
+	Tasks  []*Task
+	Todos  []*Todo
+}
+
+// Onton 被sign指称的存在物，Adic为其元数（一元、二元、三元关系）
 type Onton struct {
 	ID   int64 `json:"id"`
 	Adic int8  `json:"adic"`
 }
 
+// Onton元数的取值范围，更高元的关系可以分解为三元关系
+const (
+	MinAdic = 1
+	MaxAdic = 3
+)
+
+// SignRelationType sign之间关系的类型，关系从FromID指向ToID
+type SignRelationType string
+
+const (
+	SignRelationSynonym      SignRelationType = "synonym"      // 同义，无方向
+	SignRelationBroader      SignRelationType = "broader"      // To是From的上位概念
+	SignRelationNarrower     SignRelationType = "narrower"     // To是From的下位概念，保存时转换为反向的broader
+	SignRelationPartOf       SignRelationType = "partOf"       // From是To的一部分
+	SignRelationHasPart      SignRelationType = "hasPart"      // To是From的一部分，保存时转换为反向的partOf
+	SignRelationReferences   SignRelationType = "references"   // From引用To
+	SignRelationReferencedBy SignRelationType = "referencedBy" // From被To引用，保存时转换为反向的references
+)
+
+// Hierarchical 层级关系，沿同一类型的关系不允许出现环
+func (t SignRelationType) Hierarchical() bool {
+	return t == SignRelationBroader || t == SignRelationPartOf
+}
+
+// Inverse 从另一端看同一条关系时的类型
+func (t SignRelationType) Inverse() SignRelationType {
+	switch t {
+	case SignRelationBroader:
+		return SignRelationNarrower
+	case SignRelationNarrower:
+		return SignRelationBroader
+	case SignRelationPartOf:
+		return SignRelationHasPart
+	case SignRelationHasPart:
+		return SignRelationPartOf
+	case SignRelationReferences:
+		return SignRelationReferencedBy
+	case SignRelationReferencedBy:
+		return SignRelationReferences
+	}
+	return t
+}
+
+// SignRelation sign之间的一条有向关系，只保存synonym、broader、partOf、references四种类型
+type SignRelation struct {
+	ID     int64
+	FromID int64
+	ToID   int64
+	Type   SignRelationType
+}
+
+// SignNeighbor 与某个sign直接相连的sign，Type为从该sign看出去的关系类型
+type SignNeighbor struct {
+	Relation *SignRelation
+	Type     SignRelationType
+	Sign     *Sign
+}
+
+// SignGraph 从某个sign出发遍历得到的子图
+type SignGraph struct {
+	Nodes []*Sign
+	Edges []*SignRelation
+	// Truncated 为true时因节点数上限未能遍历完指定深度
+	Truncated bool
+}
@@ -0,0 +1,25 @@
+package entity
+
+// MaxEventTags 单个event的标签数上限
+const MaxEventTags = 20
+
+// Tag event的标签，名称不区分大小写唯一
+type Tag struct {
+	ID    int64
+	Name  string
+	Usage int // 使用该标签的event数
+}
+
+// TagMatch 按多个标签筛选时的匹配方式
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any" // 带有任一标签
+	TagMatchAll TagMatch = "all" // 带有全部标签
+)
+
+// TagFilter 按标签筛选event及由其派生的task
+type TagFilter struct {
+	Tags  []string
+	Match TagMatch
+}
@@ -0,0 +1,47 @@
+package entity
+
+import "time"
+
+// MaxChecklistItems 模板清单中子task和todo的总数上限
+const MaxChecklistItems = 100
+
+// Checklist 模板event预设的清单，实例化时在生成的task下创建子task和todo
+type Checklist struct {
+	Tasks []ChecklistTask // 子task
+	Todos []ChecklistTodo // 直接属于生成的task的todo
+}
+
+// ChecklistTask 清单中的子task，描述可含占位符
+type ChecklistTask struct {
+	Description string
+	Plan        ChecklistPlan
+	Todos       []ChecklistTodo
+}
+
+// ChecklistTodo 清单中的todo
+type ChecklistTodo struct {
+	Plan ChecklistPlan
+}
+
+// ChecklistPlan 相对实例化时间窗口开始的计划时间，Duration为0时不设置计划时间
+type ChecklistPlan struct {
+	Offset   time.Duration
+	Duration time.Duration
+}
+
+// Span 以start为起点计算计划时间段
+func (p ChecklistPlan) Span(start time.Time) TimeSpan {
+	if p.Duration == 0 {
+		return TimeSpan{}
+	}
+	planStart := start.Add(p.Offset)
+	planEnd := planStart.Add(p.Duration)
+	return TimeSpan{Start: &planStart, End: &planEnd}
+}
+
+// TaskTree 实例化模板生成的task及其todo和子task
+type TaskTree struct {
+	Task     *Task
+	Todos    []*Todo
+	Subtasks []*TaskTree
+}
@@ -10,7 +10,10 @@ type Event struct {
 	Description string // 描述
 	Location    string // 地点
 	Priority    int    // 优先级（1-5）
-	Category    string // 分类
+	Category    string // 已弃用：写入时转换为同名标签，读取时总为空
+
+	// 标签名，更新时为nil表示保持原有标签
+	Tags []string
 }
 
 // Task 任务实体,描述了任务本身
@@ -32,6 +35,7 @@ type Task struct {
 	EventID      uint   // 事件ID(描述了该任务的内容)
 	ParentTaskID *uint  // 父任务ID（可空）
 	PreTaskIDs   []uint // 前置任务ID（可空）
+	AssigneeID   *uint  // 负责人用户ID（可空）
 }
 
 // Todo 待办事项,描述了我如何做任务
@@ -48,8 +52,9 @@ type Todo struct {
 	CompletedTime *time.Time
 
 	// 关联关系
-	EventID *uint //默认为空(使用任务的事件,除了当Todo需要与Task不同,如临时不同的地点等)
-	TaskID  uint  // 所属任务ID
+	EventID    *uint //默认为空(使用任务的事件,除了当Todo需要与Task不同,如临时不同的地点等)
+	TaskID     uint  // 所属任务ID
+	AssigneeID *uint // 负责人用户ID（可空）
 }
 
 type Status string
@@ -61,9 +66,12 @@ const (
 	StatusCancelled  Status = "cancelled" // 已取消
 )
 
+// TimeSpan 时间段，时间均以UTC保存
+// AllDay为true时表示按日期的全天时间段：Start为首日0点，End为末日次日0点（不含），均为UTC日期，不随用户时区变化
 type TimeSpan struct {
-	Start *time.Time
-	End   *time.Time
+	Start  *time.Time
+	End    *time.Time
+	AllDay bool
 }
 
 func (ts TimeSpan) Duration() time.Duration {
@@ -1,6 +1,10 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"brb/internal/errs"
+)
 
 type Role string
 
@@ -10,10 +14,101 @@ const (
 )
 
 type User struct {
+	ID                 uint      // 主键ID
+	Username           string    // 用户名
+	Password           string    // 密码（应加密存储）
+	Role               Role      // 角色
+	MustChangePassword bool      // 下次登录时必须修改密码
+	TOTPSecret         string    // TOTP密钥（Base32，启用前为待确认状态）
+	TOTPEnabled        bool      // 是否已启用TOTP两步验证
+	TOTPLastCounter    int64     // 最近一次使用的TOTP时间步，防止验证码重放
+	Timezone           string    // IANA时区名，响应中的时间按此时区输出
+	CreatedAt          time.Time // 创建时间
+	UpdatedAt          time.Time // 更新时间
+}
+
+// DefaultTimezone 未设置时区的用户使用UTC
+const DefaultTimezone = "UTC"
+
+// Location 用户时区，未设置或无法识别时返回UTC
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// SecondFactor 登录时第二因素的要求
+type SecondFactor string
+
+const (
+	SecondFactorNone   SecondFactor = ""       // 无需两步验证
+	SecondFactorVerify SecondFactor = "verify" // 需要输入TOTP验证码
+	SecondFactorEnroll SecondFactor = "enroll" // 角色要求两步验证但尚未启用，需要先绑定
+)
+
+// RecoveryCode 两步验证的一次性恢复码
+type RecoveryCode struct {
+	ID       uint       // 主键ID
+	UserID   uint       // 所属用户ID
+	CodeHash string     // 恢复码的SHA-256摘要
+	UsedAt   *time.Time // 使用时间（可空，未使用）
+}
+
+// ErrPasswordChangeRequired 用户必须先修改密码才能登录
+var ErrPasswordChangeRequired = errs.Forbidden("密码已过期，请先修改密码").WithCode("password_change_required")
+
+// PasswordResetToken 管理员签发的一次性密码重置令牌
+type PasswordResetToken struct {
+	ID          uint       // 主键ID
+	UserID      uint       // 目标用户ID
+	TokenHash   string     // 令牌的SHA-256摘要（不保存明文）
+	ExpiresAt   time.Time  // 过期时间
+	UsedAt      *time.Time // 使用时间（可空，未使用）
+	CreatedByID uint       // 签发的管理员ID
+	CreatedAt   time.Time  // 签发时间
+}
+
+// LoginAttemptScope 登录失败计数的维度
+type LoginAttemptScope string
+
+const (
+	LoginScopeUsername LoginAttemptScope = "username"
+	LoginScopeIP       LoginAttemptScope = "ip"
+)
+
+// LoginAttempt 记录某个用户名或IP的连续登录失败情况
+type LoginAttempt struct {
+	Scope         LoginAttemptScope // 计数维度
+	Key           string            // 用户名或IP
+	Failures      int               // 连续失败次数
+	LastFailureAt *time.Time        // 最近一次失败时间
+	LockedUntil   *time.Time        // 锁定截止时间（可空）
+	LastIP        string            // 最近一次失败的来源IP（仅用户名维度记录）
+}
+
+// LoginThrottledError 登录因失败次数过多被暂时拒绝
+type LoginThrottledError struct {
+	Until  time.Time // 可以再次尝试的时间
+	Locked bool      // true表示已锁定，false表示处于退避等待中
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "登录失败次数过多，账户已被临时锁定"
+	}
+	return "登录尝试过于频繁，请稍后再试"
+}
+
+// ExternalIdentity 外部身份提供方（OIDC）账户与本地用户的关联
+type ExternalIdentity struct {
 	ID        uint      // 主键ID
-	Username  string    // 用户名
-	Password  string    // 密码（应加密存储）
-	Role      Role      // 角色
-	CreatedAt time.Time // 创建时间
-	UpdatedAt time.Time // 更新时间
+	UserID    uint      // 本地用户ID
+	Issuer    string    // 身份提供方issuer
+	Subject   string    // 身份提供方中的用户标识（sub）
+	CreatedAt time.Time // 关联时间
 }
@@ -0,0 +1,118 @@
+// Package errs 定义服务层使用的带类型错误，handler据此统一映射HTTP状态码
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind 错误类型
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"
+	KindNotFound     Kind = "not_found"
+	KindValidation   Kind = "validation"
+	KindConflict     Kind = "conflict"
+	KindForbidden    Kind = "forbidden"
+	KindUnauthorized Kind = "unauthorized"
+	KindUnavailable  Kind = "unavailable"
+)
+
+// FieldError 字段级错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error 带类型的业务错误，Message会返回给调用方
+type Error struct {
+	Kind    Kind
+	Code    string // 细分的错误码，为空时使用Kind
+	Message string
+	Fields  []FieldError
+	Err     error // 内部原因，不返回给调用方
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithCode 设置细分错误码
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+// WithFields 附加字段级错误
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
+
+// Wrap 附加内部原因
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func newError(kind Kind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// NotFound 资源不存在
+func NotFound(format string, args ...any) *Error {
+	return newError(KindNotFound, format, args...)
+}
+
+// Validation 请求参数或业务规则校验失败
+func Validation(format string, args ...any) *Error {
+	return newError(KindValidation, format, args...)
+}
+
+// Conflict 与现有数据冲突（如重名、状态不允许）
+func Conflict(format string, args ...any) *Error {
+	return newError(KindConflict, format, args...)
+}
+
+// Forbidden 无权执行该操作
+func Forbidden(format string, args ...any) *Error {
+	return newError(KindForbidden, format, args...)
+}
+
+// Unauthorized 身份校验失败
+func Unauthorized(format string, args ...any) *Error {
+	return newError(KindUnauthorized, format, args...)
+}
+
+// Unavailable 功能在当前部署中不可用
+func Unavailable(format string, args ...any) *Error {
+	return newError(KindUnavailable, format, args...)
+}
+
+// As 取出错误链中的*Error
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf 返回错误类型，未分类的错误视为内部错误
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// IsNotFound 是否为资源不存在错误
+func IsNotFound(err error) bool {
+	return KindOf(err) == KindNotFound
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/respond"
+	"brb/internal/router"
+)
+
+// assignmentHandler 处理task/todo指派相关的HTTP请求
+type assignmentHandler struct {
+	assignmentService AssignmentService
+}
+
+type AssignmentService interface {
+	AssignTask(ctx context.Context, taskID uint, assigneeID *uint, operatorID uint) (*entity.Task, error)
+	AssignTodo(ctx context.Context, todoID uint, assigneeID *uint, operatorID uint) (*entity.Todo, error)
+	GetAssignmentHistory(targetType entity.AssignmentTarget, targetID uint) ([]*entity.Assignment, error)
+	GetTodosByAssignee(userID uint, status entity.Status) ([]*entity.Todo, error)
+	GetTasksByAssignee(userID uint) ([]*entity.Task, error)
+}
+
+// NewAssignmentHandler 创建新的AssignmentHandler
+func NewAssignmentHandler(assignmentService AssignmentService) *assignmentHandler {
+	return &assignmentHandler{assignmentService: assignmentService}
+}
+
+// AssignTask 指派task
+func (h *assignmentHandler) AssignTask(w http.ResponseWriter, r *http.Request) {
+	operatorID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	var id uint
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	var req dto.AssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	task, err := h.assignmentService.AssignTask(r.Context(), id, req.AssigneeID, operatorID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTaskEntity(task, userLocation(r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AssignTodo 指派todo
+func (h *assignmentHandler) AssignTodo(w http.ResponseWriter, r *http.Request) {
+	operatorID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	var id uint
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	var req dto.AssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	todo, err := h.assignmentService.AssignTodo(r.Context(), id, req.AssigneeID, operatorID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTodoEntity(todo, userLocation(r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTaskAssignments 获取task的指派历史
+func (h *assignmentHandler) GetTaskAssignments(w http.ResponseWriter, r *http.Request) {
+	h.getAssignments(w, r, entity.AssignmentTargetTask)
+}
+
+// GetTodoAssignments 获取todo的指派历史
+func (h *assignmentHandler) GetTodoAssignments(w http.ResponseWriter, r *http.Request) {
+	h.getAssignments(w, r, entity.AssignmentTargetTodo)
+}
+
+func (h *assignmentHandler) getAssignments(w http.ResponseWriter, r *http.Request, targetType entity.AssignmentTarget) {
+	var id uint
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	assignments, err := h.assignmentService.GetAssignmentHistory(targetType, id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromAssignmentEntities(assignments)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMyTodos 获取当前用户的待办议程，可通过status参数过滤
+func (h *assignmentHandler) GetMyTodos(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	status, err := dto.AgendaStatusQuery(r.URL.Query())
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	todos, err := h.assignmentService.GetTodosByAssignee(userID, status)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTodoEntities(todos, userLocation(r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMyTasks 获取指派给当前用户的task
+func (h *assignmentHandler) GetMyTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	tasks, err := h.assignmentService.GetTasksByAssignee(userID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTaskEntities(tasks, userLocation(r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RegisterRoutes 注册指派相关路由
+func (h *assignmentHandler) RegisterRoutes(r router.Router) {
+	tasks := r.Group("/api/tasks")
+	tasks.PUT("/{id}/assignee", h.AssignTask)
+	tasks.GET("/{id}/assignments", h.GetTaskAssignments)
+
+	todos := r.Group("/api/todos")
+	todos.PUT("/{id}/assignee", h.AssignTodo)
+	todos.GET("/{id}/assignments", h.GetTodoAssignments)
+
+	// 个人视图
+	me := r.Group("/me")
+	me.GET("/todos", h.GetMyTodos)
+	me.GET("/tasks", h.GetMyTasks)
+}
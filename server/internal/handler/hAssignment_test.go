@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"brb/internal/entity"
+	"brb/internal/router"
+)
+
+// agendaStub 记录个人议程收到的status参数
+type agendaStub struct {
+	AssignmentService
+	status *entity.Status
+}
+
+func (s agendaStub) GetTodosByAssignee(userID uint, status entity.Status) ([]*entity.Todo, error) {
+	*s.status = status
+	return nil, nil
+}
+
+func TestMyTodosStatusQuery(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantCode   int
+		wantStatus entity.Status
+	}{
+		{"", http.StatusOK, ""},
+		{"?status=done", http.StatusOK, entity.StatusCompleted},
+		{"?status=finished", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var status entity.Status
+			mux := http.NewServeMux()
+			NewAssignmentHandler(agendaStub{status: &status}).RegisterRoutes(router.NewStandardRouter(mux))
+
+			req := httptest.NewRequest(http.MethodGet, "/me/todos"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), "userID", uint(1)))
+			rec := serve(mux, req)
+			if rec.Code != tt.wantCode || status != tt.wantStatus {
+				t.Fatalf("status code = %d, status = %q, want %d, %q: %s", rec.Code, status, tt.wantCode, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"brb/internal/dto"
+	"brb/internal/respond"
+	"brb/internal/router"
+	"brb/pkg/openapi"
+)
+
+// docsHandler 提供OpenAPI文档和文档页面
+type docsHandler struct {
+	routes func() []router.Route
+
+	once sync.Once
+	spec []byte
+	err  error
+}
+
+// NewDocsHandler 创建文档处理器，routes在首次请求时调用，此时所有路由都已注册
+func NewDocsHandler(routes func() []router.Route) *docsHandler {
+	return &docsHandler{routes: routes}
+}
+
+// OpenAPI 返回OpenAPI文档，只包含当前实际注册的路由
+func (h *docsHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		doc, err := BuildOpenAPI(h.routes())
+		if doc == nil {
+			h.err = err
+			return
+		}
+		if err != nil {
+			slog.Warn("OpenAPI文档不完整", "error", err)
+		}
+		h.spec, h.err = json.Marshal(doc)
+	})
+	if h.err != nil {
+		respond.Error(w, r, h.err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.spec)
+}
+
+// Docs 返回文档页面
+func (h *docsHandler) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(openapi.DocsHTML)
+}
+
+// RegisterRoutes 注册文档路由
+func (h *docsHandler) RegisterRoutes(r router.Router) {
+	r.GET("/openapi.json", h.OpenAPI)
+	r.GET("/docs", h.Docs)
+}
+
+// BuildOpenAPI 为已注册的路由生成文档，存在未声明文档的路由时仍返回其余路由的文档，同时返回列出这些路由的错误
+func BuildOpenAPI(routes []router.Route) (*openapi.Document, error) {
+	declared := make(map[string]openapi.Endpoint, len(apiEndpoints))
+	for _, e := range apiEndpoints {
+		declared[e.Method+" "+e.Path] = e
+	}
+
+	b := openapi.NewBuilder(openapi.Info{Title: "brb API", Version: "v1"}, respond.ErrorResponse{})
+	var missing []string
+	for _, route := range routes {
+		e, ok := declared[route.Method+" "+route.Path]
+		if !ok {
+			missing = append(missing, route.Method+" "+route.Path)
+			continue
+		}
+		if err := b.Add(e); err != nil {
+			return nil, err
+		}
+	}
+	if len(missing) > 0 {
+		return b.Document(), fmt.Errorf("以下路由缺少OpenAPI文档，请在apiEndpoints中补充: %s", strings.Join(missing, ", "))
+	}
+	return b.Document(), nil
+}
+
+// statusQuery 按状态筛选的查询参数
+var statusQuery = []openapi.Parameter{{Name: "status", Description: "按状态筛选：pending、doing、done、cancelled"}}
+
+// pageQuery 分页查询参数
+var pageQuery = []openapi.Parameter{
+	{Name: "limit", Description: "每页数量，默认50，最大200", Schema: &openapi.Schema{Type: "integer"}},
+	{Name: "offset", Description: "跳过的条数，默认0", Schema: &openapi.Schema{Type: "integer"}},
+}
+
+// acceptLanguageHeader 返回sign时按此选择各sign的首选能指
+var acceptLanguageHeader = openapi.Parameter{Name: "Accept-Language", In: "header", Description: "偏好的语言，sign的signifier取匹配语言的首选能指，无匹配时为默认能指"}
+
+// signListQuery sign列表的查询参数
+var signListQuery = append([]openapi.Parameter{
+	{Name: "signifier", Description: "按默认或任一多语言能指精确查找"},
+	{Name: "prefix", Description: "按默认或任一多语言能指前缀查找"},
+	{Name: "q", Description: "搜索所指中包含的文本"},
+	acceptLanguageHeader,
+}, pageQuery...)
+
+// signFormatQuery 词汇表导入导出格式，缺省时按Content-Type或Accept判断
+var signFormatQuery = openapi.Parameter{Name: "format", Description: "csv、json，导出还支持skos（RDF Turtle）"}
+
+// relationTypeQuery 按sign关系类型筛选的查询参数
+var relationTypeQuery = openapi.Parameter{Name: "type", Description: "关系类型，多个以逗号分隔：synonym、broader、narrower、partOf、hasPart、references、referencedBy"}
+
+// searchQuery 全文搜索的查询参数
+var searchQuery = []openapi.Parameter{
+	{Name: "q", Required: true, Description: "搜索词，以空格分隔的多个词须全部匹配，启用FTS5时不少于3个字符的词使用全文索引"},
+	{Name: "type", Description: "搜索的类型，多个以逗号分隔：event、task、todo、sign，默认全部；todo按所属task的描述匹配"},
+	{Name: "limit", Description: "每种类型最多返回的条数，默认10，最大50", Schema: &openapi.Schema{Type: "integer"}},
+	{Name: "mine", Description: "默认为true，只搜索分配给自己的task和todo及包含它们的event；仅管理员可设为false以搜索全部，sign总是搜索全部", Schema: &openapi.Schema{Type: "boolean"}},
+}
+
+// tagFilterQuery 按标签筛选event和task的查询参数
+var tagFilterQuery = []openapi.Parameter{
+	{Name: "tags", Description: "标签名，多个以逗号分隔，不区分大小写"},
+	{Name: "match", Description: "any（默认）：带有任一标签；all：带有全部标签"},
+}
+
+// apiEndpoints 所有路由的文档声明，新增路由时须在此补充，否则启动检查失败
+var apiEndpoints = []openapi.Endpoint{
+	// 探针和监控
+	{Method: "GET", Path: "/healthz", Tag: "ops", Summary: "存活探针", Response: healthResponse{}},
+	{Method: "GET", Path: "/readyz", Tag: "ops", Summary: "就绪探针，检查失败时返回503", Response: healthResponse{}},
+	{Method: "GET", Path: "/metrics", Tag: "ops", Summary: "Prometheus指标（仅管理员）", Auth: true, ContentType: "text/plain"},
+
+	// 文档
+	{Method: "GET", Path: "/v1/openapi.json", Tag: "docs", Summary: "OpenAPI文档", ContentType: "application/json"},
+	{Method: "GET", Path: "/v1/docs", Tag: "docs", Summary: "文档页面", ContentType: "text/html"},
+
+	// 认证
+	{Method: "POST", Path: "/v1/api/auth/register", Tag: "auth", Summary: "注册", Request: dto.UserRegisterRequest{}, Response: dto.LoginResponse{}, Status: http.StatusCreated},
+	{Method: "POST", Path: "/v1/api/auth/login", Tag: "auth", Summary: "登录，需要两步验证时返回202和挑战令牌（LoginChallengeResponse）", Request: dto.UserLoginRequest{}, Response: dto.LoginResponse{}},
+	{Method: "POST", Path: "/v1/api/auth/change-password", Tag: "auth", Summary: "修改已过期的密码并登录", Request: dto.ExpiredPasswordChangeRequest{}, Response: dto.LoginResponse{}},
+	{Method: "POST", Path: "/v1/api/auth/reset-password", Tag: "auth", Summary: "使用重置令牌设置新密码", Request: dto.PasswordResetRequest{}, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/auth/2fa/verify", Tag: "auth", Summary: "提交两步验证码完成登录", Request: dto.TwoFactorVerifyRequest{}, Response: dto.LoginResponse{}},
+	{Method: "POST", Path: "/v1/api/auth/2fa/enroll", Tag: "auth", Summary: "登录流程中开始绑定TOTP", Request: dto.TwoFactorVerifyRequest{}, Response: dto.TOTPEnrollResponse{}},
+	{Method: "POST", Path: "/v1/api/auth/2fa/enroll/confirm", Tag: "auth", Summary: "登录流程中确认绑定TOTP并登录", Request: dto.TwoFactorVerifyRequest{}, Response: dto.RecoveryCodesResponse{}},
+	{Method: "GET", Path: "/v1/api/auth/oidc/login", Tag: "auth", Summary: "跳转到身份提供方登录", Status: http.StatusFound},
+	{Method: "GET", Path: "/v1/api/auth/oidc/callback", Tag: "auth", Summary: "身份提供方回调，需要两步验证时返回202及挑战令牌，配置了跳转地址时返回302", Response: dto.LoginResponse{}},
+	{Method: "POST", Path: "/v1/api/auth/oidc/link", Tag: "auth", Summary: "已登录用户开始关联外部身份", Auth: true, Response: dto.OIDCLinkResponse{}},
+
+	// 用户
+	{Method: "GET", Path: "/v1/api/users/me", Tag: "users", Summary: "当前用户", Auth: true, Response: dto.UserResponse{}},
+	{Method: "PUT", Path: "/v1/api/users/password", Tag: "users", Summary: "修改密码", Auth: true, Request: dto.PasswordChangeRequest{}, Status: http.StatusNoContent},
+	{Method: "PUT", Path: "/v1/api/users/{id}", Tag: "users", Summary: "更新用户信息", Auth: true, Request: dto.UserUpdateRequest{}, Response: dto.UserResponse{}},
+	{Method: "POST", Path: "/v1/api/users/me/2fa/enroll", Tag: "users", Summary: "开始绑定TOTP", Auth: true, Response: dto.TOTPEnrollResponse{}},
+	{Method: "POST", Path: "/v1/api/users/me/2fa/confirm", Tag: "users", Summary: "确认绑定TOTP", Auth: true, Request: dto.TwoFactorVerifyRequest{}, Response: dto.RecoveryCodesResponse{}},
+	{Method: "POST", Path: "/v1/api/users/me/2fa/disable", Tag: "users", Summary: "停用TOTP", Auth: true, Request: dto.TwoFactorVerifyRequest{}, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/users/me/2fa/recovery-codes", Tag: "users", Summary: "重新生成恢复码", Auth: true, Request: dto.TwoFactorVerifyRequest{}, Response: dto.RecoveryCodesResponse{}},
+	{Method: "GET", Path: "/v1/api/users", Tag: "users", Summary: "所有用户（仅管理员）", Auth: true, Response: []dto.UserResponse{}},
+	{Method: "DELETE", Path: "/v1/api/users/{id}", Tag: "users", Summary: "删除用户（仅管理员）", Auth: true, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/users/{id}/promote", Tag: "users", Summary: "设为管理员（仅管理员）", Auth: true, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/users/{id}/demote", Tag: "users", Summary: "设为普通用户（仅管理员）", Auth: true, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/users/{id}/unlock", Tag: "users", Summary: "解除登录锁定（仅管理员）", Auth: true, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/users/{id}/reset-token", Tag: "users", Summary: "签发密码重置令牌（仅管理员）", Auth: true, Response: dto.PasswordResetTokenResponse{}, Status: http.StatusCreated},
+	{Method: "POST", Path: "/v1/api/users/{id}/force-password-change", Tag: "users", Summary: "要求下次登录修改密码（仅管理员）", Auth: true, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/users/{id}/2fa/reset", Tag: "users", Summary: "重置用户的TOTP（仅管理员）", Auth: true, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/v1/api/users/2fa-policy", Tag: "users", Summary: "各角色是否要求两步验证（仅管理员）", Auth: true, Response: map[string]bool{}},
+	{Method: "PUT", Path: "/v1/api/users/2fa-policy", Tag: "users", Summary: "设置角色是否要求两步验证（仅管理员）", Auth: true, Request: dto.TwoFactorPolicyRequest{}, Status: http.StatusNoContent},
+
+	// sign
+	{Method: "POST", Path: "/v1/api/signs", Tag: "signs", Summary: "创建sign", Auth: true, Request: dto.SignCreateRequest{}, Response: dto.SignResponse{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/v1/api/signs", Tag: "signs", Summary: "分页查询sign，可按能指精确或前缀查找、按所指搜索", Query: signListQuery, Response: dto.SignListResponse{}},
+	{Method: "GET", Path: "/v1/api/signs/{id}", Tag: "signs", Summary: "获取sign", Query: []openapi.Parameter{acceptLanguageHeader}, Response: dto.SignResponse{}},
+	{Method: "PUT", Path: "/v1/api/signs/{id}", Tag: "signs", Summary: "更新sign，非创建者须具有signs.editorRoles中的角色", Auth: true, Request: dto.SignUpdateRequest{}, Status: http.StatusNoContent},
+	{Method: "DELETE", Path: "/v1/api/signs/{id}", Tag: "signs", Summary: "删除sign，非创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/signs/{id}/relations", Tag: "signs", Summary: "添加从该sign指向targetId的关系，非该sign的创建者须具有signs.editorRoles中的角色", Auth: true, Request: dto.SignRelationCreateRequest{}, Response: dto.SignRelationResponse{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/v1/api/signs/{id}/relations", Tag: "signs", Summary: "与该sign直接相连的sign", Query: []openapi.Parameter{relationTypeQuery}, Response: []dto.SignNeighborResponse{}},
+	{Method: "DELETE", Path: "/v1/api/signs/{id}/relations/{relationId}", Tag: "signs", Summary: "删除与该sign相连的关系，非该sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/v1/api/signs/{id}/graph", Tag: "signs", Summary: "从该sign出发广度优先遍历的子图", Query: []openapi.Parameter{relationTypeQuery, {Name: "depth", Description: "遍历层数，默认2，最大5", Schema: &openapi.Schema{Type: "integer"}}}, Response: dto.SignGraphResponse{}},
+	{Method: "POST", Path: "/v1/api/signs/import", Tag: "signs", Summary: "从CSV或JSON批量导入sign，按能指新建或更新，须具有signs.editorRoles中的角色", Auth: true, Query: []openapi.Parameter{signFormatQuery, {Name: "dryRun", Description: "为true时只报告将进行的变更，不写入", Schema: &openapi.Schema{Type: "boolean"}}}, Request: []dto.SignImportRow{}, Consumes: []string{"text/csv"}, Response: dto.SignImportReport{}},
+	{Method: "GET", Path: "/v1/api/signs/export", Tag: "signs", Summary: "导出全部sign（CSV、JSON或SKOS Turtle）", Query: []openapi.Parameter{signFormatQuery}, Response: []dto.SignResponse{}},
+	{Method: "GET", Path: "/v1/api/signs/{id}/items", Tag: "signs", Summary: "关联了sign的全部event、task和todo", Auth: true, Response: dto.SignItemsResponse{}},
+
+	// event
+	{Method: "GET", Path: "/v1/api/events", Tag: "events", Summary: "所有event，可按标签筛选", Auth: true, Query: tagFilterQuery, Response: []dto.EventResponse{}},
+	{Method: "GET", Path: "/v1/api/events/{id}", Tag: "events", Summary: "获取event", Auth: true, Response: dto.EventResponse{}},
+	{Method: "POST", Path: "/v1/api/events", Tag: "events", Summary: "创建event", Auth: true, Request: dto.EventCreateRequest{}, Response: dto.EventResponse{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/v1/api/events/{id}", Tag: "events", Summary: "更新event", Auth: true, Request: dto.EventUpdateRequest{}, Status: http.StatusNoContent},
+	{Method: "PATCH", Path: "/v1/api/events/{id}", Tag: "events", Summary: "部分更新event（JSON Merge Patch），返回更新后的event", Auth: true, Request: dto.EventUpdateRequest{}, MergePatch: true, Response: dto.EventResponse{}},
+	{Method: "DELETE", Path: "/v1/api/events/{id}", Tag: "events", Summary: "删除event及其task和todo", Auth: true, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/v1/api/events/{id}/checklist", Tag: "events", Summary: "模板event的清单", Auth: true, Response: dto.ChecklistResponse{}},
+	{Method: "PUT", Path: "/v1/api/events/{id}/checklist", Tag: "events", Summary: "替换模板event的清单，计划时间为相对实例化时间窗口开始的分钟数", Auth: true, Request: dto.ChecklistRequest{}, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/api/events/{id}/instantiate", Tag: "events", Summary: "按模板在时间窗口内创建task及清单中的子task和todo，描述中的{{date}}、{{start}}、{{end}}、{{weekday}}、{{title}}按窗口填充", Auth: true, Request: dto.InstantiateRequest{}, Response: dto.TaskTreeResponse{}, Status: http.StatusCreated},
+
+	// tag
+	{Method: "GET", Path: "/v1/api/tags", Tag: "tags", Summary: "所有标签及使用次数", Auth: true, Response: []dto.TagResponse{}},
+	{Method: "GET", Path: "/v1/api/tags/{id}", Tag: "tags", Summary: "获取标签", Auth: true, Response: dto.TagResponse{}},
+	{Method: "PUT", Path: "/v1/api/tags/{id}", Tag: "tags", Summary: "重命名标签，与其他标签重名时返回409", Auth: true, Request: dto.TagRenameRequest{}, Response: dto.TagResponse{}},
+	{Method: "POST", Path: "/v1/api/tags/{id}/merge", Tag: "tags", Summary: "将标签合并到into指定的标签并删除原标签，返回目标标签", Auth: true, Request: dto.TagMergeRequest{}, Response: dto.TagResponse{}},
+	{Method: "DELETE", Path: "/v1/api/tags/{id}", Tag: "tags", Summary: "删除标签，event不再带有该标签", Auth: true, Status: http.StatusNoContent},
+
+	// task
+	{Method: "POST", Path: "/v1/api/tasks", Tag: "tasks", Summary: "创建task", Auth: true, Request: dto.TaskCreateRequest{}, Response: dto.TaskResponse{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/v1/api/tasks", Tag: "tasks", Summary: "所有task，可按sign或所属event的标签筛选（不能同时使用）", Auth: true, Query: append([]openapi.Parameter{{Name: "sign", Description: "只返回关联了该sign的task", Schema: &openapi.Schema{Type: "integer"}}}, tagFilterQuery...), Response: []dto.TaskResponse{}},
+	{Method: "GET", Path: "/v1/api/tasks/{id}", Tag: "tasks", Summary: "获取task", Auth: true, Response: dto.TaskResponse{}},
+	{Method: "PUT", Path: "/v1/api/tasks/{id}", Tag: "tasks", Summary: "更新task", Auth: true, Request: dto.TaskUpdateRequest{}, Status: http.StatusNoContent},
+	{Method: "PATCH", Path: "/v1/api/tasks/{id}", Tag: "tasks", Summary: "部分更新task（JSON Merge Patch），返回更新后的task", Auth: true, Request: dto.TaskUpdateRequest{}, MergePatch: true, Response: dto.TaskResponse{}},
+	{Method: "DELETE", Path: "/v1/api/tasks/{id}", Tag: "tasks", Summary: "删除task及其todo", Auth: true, Status: http.StatusNoContent},
+	{Method: "PUT", Path: "/v1/api/tasks/{id}/assignee", Tag: "tasks", Summary: "指派task，assigneeId为null时取消指派", Auth: true, Request: dto.AssignRequest{}, Response: dto.TaskResponse{}},
+	{Method: "GET", Path: "/v1/api/tasks/{id}/assignments", Tag: "tasks", Summary: "task的指派记录", Auth: true, Response: []dto.AssignmentResponse{}},
+
+	// todo
+	{Method: "POST", Path: "/v1/api/todos", Tag: "todos", Summary: "创建todo", Auth: true, Request: dto.TodoCreateRequest{}, Form: true, Response: dto.TodoResponse{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/v1/api/todos", Tag: "todos", Summary: "所有todo", Auth: true, Response: []dto.TodoResponse{}},
+	{Method: "GET", Path: "/v1/api/todos/{id}", Tag: "todos", Summary: "获取todo", Auth: true, Response: dto.TodoResponse{}},
+	{Method: "PUT", Path: "/v1/api/todos/{id}", Tag: "todos", Summary: "更新todo", Auth: true, Request: dto.TodoUpdateRequest{}, Status: http.StatusNoContent},
+	{Method: "PATCH", Path: "/v1/api/todos/{id}", Tag: "todos", Summary: "部分更新todo（JSON Merge Patch），返回更新后的todo", Auth: true, Request: dto.TodoUpdateRequest{}, MergePatch: true, Response: dto.TodoResponse{}},
+	{Method: "DELETE", Path: "/v1/api/todos/{id}", Tag: "todos", Summary: "删除todo", Auth: true, Status: http.StatusNoContent},
+	{Method: "PUT", Path: "/v1/api/todos/{id}/assignee", Tag: "todos", Summary: "指派todo，assigneeId为null时取消指派", Auth: true, Request: dto.AssignRequest{}, Response: dto.TodoResponse{}},
+	{Method: "GET", Path: "/v1/api/todos/{id}/assignments", Tag: "todos", Summary: "todo的指派记录", Auth: true, Response: []dto.AssignmentResponse{}},
+
+	// sign关联
+	{Method: "GET", Path: "/v1/api/events/{id}/signs", Tag: "signs", Summary: "event关联的sign", Auth: true, Response: []dto.SignResponse{}},
+	{Method: "PUT", Path: "/v1/api/events/{id}/signs/{signId}", Tag: "signs", Summary: "为event关联sign，非sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "DELETE", Path: "/v1/api/events/{id}/signs/{signId}", Tag: "signs", Summary: "取消event与sign的关联，非sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/v1/api/tasks/{id}/signs", Tag: "signs", Summary: "task关联的sign", Auth: true, Response: []dto.SignResponse{}},
+	{Method: "PUT", Path: "/v1/api/tasks/{id}/signs/{signId}", Tag: "signs", Summary: "为task关联sign，非sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "DELETE", Path: "/v1/api/tasks/{id}/signs/{signId}", Tag: "signs", Summary: "取消task与sign的关联，非sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/v1/api/todos/{id}/signs", Tag: "signs", Summary: "todo关联的sign", Auth: true, Response: []dto.SignResponse{}},
+	{Method: "PUT", Path: "/v1/api/todos/{id}/signs/{signId}", Tag: "signs", Summary: "为todo关联sign，非sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "DELETE", Path: "/v1/api/todos/{id}/signs/{signId}", Tag: "signs", Summary: "取消todo与sign的关联，非sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+
+	// onton
+	{Method: "POST", Path: "/v1/api/ontons", Tag: "ontons", Summary: "创建onton，须具有signs.editorRoles中的角色", Auth: true, Request: dto.OntonCreateRequest{}, Response: dto.OntonResponse{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/v1/api/ontons", Tag: "ontons", Summary: "所有onton", Auth: true, Response: []dto.OntonResponse{}},
+	{Method: "GET", Path: "/v1/api/ontons/{id}", Tag: "ontons", Summary: "获取onton", Auth: true, Response: dto.OntonResponse{}},
+	{Method: "PUT", Path: "/v1/api/ontons/{id}", Tag: "ontons", Summary: "更新onton，须具有signs.editorRoles中的角色", Auth: true, Request: dto.OntonUpdateRequest{}, Status: http.StatusNoContent},
+	{Method: "DELETE", Path: "/v1/api/ontons/{id}", Tag: "ontons", Summary: "删除onton，须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/v1/api/ontons/{id}/signs", Tag: "ontons", Summary: "指称onton的sign", Auth: true, Response: []dto.SignResponse{}},
+	{Method: "PUT", Path: "/v1/api/ontons/{id}/signs/{signId}", Tag: "ontons", Summary: "记录sign指称onton，非sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "DELETE", Path: "/v1/api/ontons/{id}/signs/{signId}", Tag: "ontons", Summary: "取消sign与onton的关联，非sign的创建者须具有signs.editorRoles中的角色", Auth: true, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/v1/api/signs/{id}/ontons", Tag: "ontons", Summary: "sign指称的onton", Auth: true, Response: []dto.OntonResponse{}},
+
+	// 全文搜索
+	{Method: "GET", Path: "/v1/api/search", Tag: "search", Summary: "全文搜索event、task、todo和sign，按类型分组、按相关度排序（未以-tags sqlite_fts5构建时fullText为false，按LIKE匹配、按ID倒序），文本经HTML转义，匹配处以<mark>标记", Auth: true, Query: searchQuery, Response: dto.SearchResponse{}},
+
+	// 当前用户的工作
+	{Method: "GET", Path: "/v1/me/todos", Tag: "me", Summary: "指派给我的todo", Auth: true, Query: statusQuery, Response: []dto.TodoResponse{}},
+	{Method: "GET", Path: "/v1/me/tasks", Tag: "me", Summary: "指派给我的task", Auth: true, Response: []dto.TaskResponse{}},
+}
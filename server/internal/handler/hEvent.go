@@ -3,12 +3,13 @@ package handler
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	"brb/internal/dto"
 	"brb/internal/entity"
+	"brb/internal/respond"
 	"brb/internal/router"
-	"brb/pkg/logger"
 )
 
 // eventHandler 处理event相关的HTTP请求
@@ -19,6 +20,7 @@ type eventHandler struct {
 type eventService interface {
 	CreateEvent(event *entity.Event) error
 	GetAllEvents() ([]*entity.Event, error)
+	GetEventsByTags(f entity.TagFilter) ([]*entity.Event, error)
 	GetEventByID(id uint) (*entity.Event, error)
 	UpdateEvent(event *entity.Event) error
 	DeleteEvent(id uint) error
@@ -33,15 +35,19 @@ func NewEventHandler(eventService eventService) *eventHandler {
 func (h *eventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	var req dto.EventCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Tip.Println("Invalid request body:", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		slog.DebugContext(r.Context(), "invalid request body", "error", err)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
 	event := req.ToEntity()
 	if err := h.eventService.CreateEvent(event); err != nil {
-		logger.Tip.Println("Failed to create event:", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -51,11 +57,22 @@ func (h *eventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetAllEvents 获取所有event
+// GetAllEvents 获取所有event，可通过tags参数只返回带有任一（match=all时为全部）标签的event
 func (h *eventHandler) GetAllEvents(w http.ResponseWriter, r *http.Request) {
-	events, err := h.eventService.GetAllEvents()
+	filter, err := dto.TagFilterQuery(r.URL.Query())
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	var events []*entity.Event
+	if len(filter.Tags) > 0 {
+		events, err = h.eventService.GetEventsByTags(filter)
+	} else {
+		events, err = h.eventService.GetAllEvents()
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 	response := dto.FromEventEntities(events)
@@ -67,19 +84,19 @@ func (h *eventHandler) GetAllEvents(w http.ResponseWriter, r *http.Request) {
 func (h *eventHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
 
 	event, err := h.eventService.GetEventByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -92,47 +109,92 @@ func (h *eventHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
 func (h *eventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
 
 	var req dto.EventUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
 	event := req.ToEntity(id)
 	if err := h.eventService.UpdateEvent(event); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// PatchEvent 按JSON Merge Patch部分更新event，未提供的字段保持不变
+func (h *eventHandler) PatchEvent(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	var id uint
+	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	current, err := h.eventService.GetEventByID(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	req := dto.EventUpdateRequestFromEntity(current)
+	if !applyMergePatch(w, r, req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	event := req.ToEntity(id)
+	if err := h.eventService.UpdateEvent(event); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.FromEventEntity(event))
+}
+
 // DeleteEvent 删除event
 func (h *eventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
 
 	if err := h.eventService.DeleteEvent(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -147,5 +209,6 @@ func (h *eventHandler) RegisterRoutes(r router.Router) {
 	api.GET("/{id}", h.GetEvent)
 	api.POST("", h.CreateEvent)
 	api.PUT("/{id}", h.UpdateEvent)
+	api.PATCH("/{id}", h.PatchEvent)
 	api.DELETE("/{id}", h.DeleteEvent)
 }
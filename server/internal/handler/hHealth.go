@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"brb/internal/router"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck 就绪检查项
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// healthHandler 提供存活和就绪探针
+type healthHandler struct {
+	started time.Time
+	checks  func() []HealthCheck // 每次探测时获取，以包含运行时注册的后台组件
+}
+
+// NewHealthHandler 创建新的HealthHandler
+func NewHealthHandler(checks func() []HealthCheck) *healthHandler {
+	return &healthHandler{
+		started: time.Now(),
+		checks:  checks,
+	}
+}
+
+type checkResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Uptime string                 `json:"uptime,omitempty"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+// Healthz 存活探针：进程能处理请求即返回200，不检查依赖
+func (h *healthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, healthResponse{
+		Status: "ok",
+		Uptime: time.Since(h.started).Truncate(time.Second).String(),
+	})
+}
+
+// Readyz 就绪探针：并发执行所有检查，任一失败返回503
+func (h *healthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	checks := h.checks()
+	results := make(map[string]checkResult, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := c.Check(ctx)
+			result := checkResult{
+				Status:    "ok",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			results[c.Name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	response := healthResponse{Status: "ok", Checks: results}
+	status := http.StatusOK
+	for _, result := range results {
+		if result.Status != "ok" {
+			response.Status = "fail"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeHealth(w, status, response)
+}
+
+func writeHealth(w http.ResponseWriter, status int, response healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RegisterRoutes 注册探针路由
+func (h *healthHandler) RegisterRoutes(r router.Router) {
+	r.GET("/healthz", h.Healthz)
+	r.GET("/readyz", h.Readyz)
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/middleware"
+	"brb/internal/respond"
+	"brb/internal/router"
+	"brb/pkg/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	oidcStateCookie = "brb_oidc_state"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+// oidcHandler 处理OpenID Connect单点登录
+type oidcHandler struct {
+	provider          *oidc.Provider
+	ssoService        SSOService
+	logins            loginCompleter
+	jwtSecret         string
+	postLoginRedirect string // 登录成功后跳转的前端地址，为空时直接返回JSON
+}
+
+type SSOService interface {
+	ExternalLogin(ctx context.Context, issuer, subject string, claims map[string]any) (*entity.User, error)
+	LinkIdentity(ctx context.Context, userID uint, issuer, subject string) (*entity.User, error)
+}
+
+// loginCompleter 完成身份校验后的登录流程（两步验证挑战或签发JWT），由userHandler实现
+type loginCompleter interface {
+	completeLogin(user *entity.User) (*dto.LoginResponse, *dto.LoginChallengeResponse, error)
+}
+
+// NewOIDCHandler 创建新的OIDCHandler，SSO登录与密码登录共用users的两步验证流程
+func NewOIDCHandler(provider *oidc.Provider, ssoService SSOService, users *userHandler, postLoginRedirect string) *oidcHandler {
+	return &oidcHandler{
+		provider:          provider,
+		ssoService:        ssoService,
+		logins:            users,
+		jwtSecret:         users.jwtSecret,
+		postLoginRedirect: postLoginRedirect,
+	}
+}
+
+// Login 生成state、nonce和PKCE参数并跳转到身份提供方
+func (h *oidcHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.begin(w, r, nil)
+	if err != nil {
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// StartLink 已登录用户开始关联外部身份，返回授权地址，由前端跳转
+func (h *oidcHandler) StartLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	authURL, err := h.begin(w, r, jwt.MapClaims{"linkUserID": userID})
+	if err != nil {
+		return
+	}
+	respond.JSON(w, http.StatusOK, dto.OIDCLinkResponse{AuthorizationURL: authURL})
+}
+
+// begin 生成登录参数并写入签名的state Cookie，返回授权地址；失败时已写出错误响应
+func (h *oidcHandler) begin(w http.ResponseWriter, r *http.Request, extra jwt.MapClaims) (string, error) {
+	state, err1 := oidc.RandomString()
+	nonce, err2 := oidc.RandomString()
+	verifier, err3 := oidc.RandomString()
+	if err := errors.Join(err1, err2, err3); err != nil {
+		respond.Fail(w, r, http.StatusInternalServerError, "生成登录参数失败")
+		return "", err
+	}
+
+	authURL, err := h.provider.AuthCodeURL(r.Context(), state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "OIDC授权地址生成失败", "error", err)
+		respond.Fail(w, r, http.StatusBadGateway, "身份提供方不可用")
+		return "", err
+	}
+
+	// 登录参数签名后放在Cookie中，回调时校验，防止登录CSRF
+	claims := jwt.MapClaims{
+		"purpose":  "oidc-state",
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+		"exp":      jwt.NewNumericDate(time.Now().Add(oidcStateTTL)),
+	}
+	for key, value := range extra {
+		claims[key] = value
+	}
+	cookieValue, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.jwtSecret))
+	if err != nil {
+		respond.Fail(w, r, http.StatusInternalServerError, "生成登录参数失败")
+		return "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    cookieValue,
+		Path:     "/",
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return authURL, nil
+}
+
+// Callback 处理身份提供方回调：校验state，换取并校验ID Token，开通或关联用户后按两步验证要求签发JWT或挑战令牌
+func (h *oidcHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	// 回调只能使用一次
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Value: "", Path: "/", MaxAge: -1})
+
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		respond.Fail(w, r, http.StatusUnauthorized, "身份提供方拒绝了登录: "+errCode)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "登录会话已过期，请重新登录")
+		return
+	}
+	state, err := h.parseState(cookie.Value)
+	if err != nil || state["state"] != query.Get("state") {
+		respond.Fail(w, r, http.StatusBadRequest, "登录状态校验失败")
+		return
+	}
+
+	nonce, _ := state["nonce"].(string)
+	verifier, _ := state["verifier"].(string)
+	claims, err := h.provider.Exchange(r.Context(), query.Get("code"), verifier, nonce)
+	if err != nil {
+		slog.WarnContext(r.Context(), "OIDC登录失败", "error", err)
+		respond.Fail(w, r, http.StatusUnauthorized, "身份校验失败")
+		return
+	}
+
+	issuer, _ := claims["iss"].(string)
+	subject, _ := claims["sub"].(string)
+
+	// 由已登录用户发起的关联流程
+	if linkUserID, ok := state["linkUserID"].(float64); ok {
+		user, err := h.ssoService.LinkIdentity(r.Context(), uint(linkUserID), issuer, subject)
+		if err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+		if h.postLoginRedirect != "" {
+			http.Redirect(w, r, h.postLoginRedirect+"#linked=true", http.StatusFound)
+			return
+		}
+		respond.JSON(w, http.StatusOK, dto.FromUserEntity(user))
+		return
+	}
+
+	user, err := h.ssoService.ExternalLogin(r.Context(), issuer, subject, claims)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	login, challenge, err := h.logins.completeLogin(user)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	// 配置了前端地址时通过URL片段传递令牌，避免出现在服务器日志中
+	if h.postLoginRedirect != "" {
+		fragment := url.Values{}
+		if challenge != nil {
+			fragment.Set("twoFactor", challenge.TwoFactor)
+			fragment.Set("challengeToken", challenge.ChallengeToken)
+		} else {
+			fragment.Set("token", login.Token)
+		}
+		http.Redirect(w, r, h.postLoginRedirect+"#"+fragment.Encode(), http.StatusFound)
+		return
+	}
+
+	if challenge != nil {
+		respond.JSON(w, http.StatusAccepted, challenge)
+		return
+	}
+	respond.JSON(w, http.StatusOK, login)
+}
+
+// parseState 校验Cookie中签名的登录参数
+func (h *oidcHandler) parseState(value string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(value, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("意外的签名方法: %v", token.Header["alg"])
+		}
+		return []byte(h.jwtSecret), nil
+	})
+	if err != nil || claims["purpose"] != "oidc-state" {
+		return nil, fmt.Errorf("invalid oidc state")
+	}
+	return claims, nil
+}
+
+// RegisterRoutes 注册OIDC登录路由
+func (h *oidcHandler) RegisterRoutes(r router.Router) {
+	api := r.Group("/api/auth/oidc")
+	api.GET("/login", h.Login)
+	api.GET("/callback", h.Callback)
+	api.POST("/link", h.StartLink, middleware.RequireAuth(h.jwtSecret))
+}
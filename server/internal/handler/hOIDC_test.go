@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/repo"
+	"brb/internal/router"
+	"brb/internal/service"
+	"brb/pkg/oidc"
+	"brb/pkg/oidc/oidctest"
+)
+
+// oidcTestEnv 身份提供方、用户服务和注册了用户与OIDC路由的mux
+type oidcTestEnv struct {
+	idp   *oidctest.Server
+	mux   *http.ServeMux
+	users testUserService
+}
+
+func newOIDCTestEnv(t *testing.T, postLoginRedirect string) *oidcTestEnv {
+	t.Helper()
+	db, userService := newTestUserService(t)
+	userRepo, err := repo.NewUserRepo(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityRepo, err := repo.NewIdentityRepo(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssoService := service.NewSSOService(userRepo, identityRepo, service.SSOPolicy{})
+
+	idp := oidctest.NewServer("brb", "client-secret")
+	t.Cleanup(idp.Close)
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    idp.URL,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  "http://app.test/api/auth/oidc/callback",
+	})
+
+	mux := http.NewServeMux()
+	reg := router.NewStandardRouter(mux)
+	userHandler := NewUserHandler(userService, testJWTSecret)
+	userHandler.RegisterRoutes(reg)
+	NewOIDCHandler(provider, ssoService, userHandler, postLoginRedirect).RegisterRoutes(reg)
+
+	return &oidcTestEnv{idp: idp, mux: mux, users: userService}
+}
+
+func (env *oidcTestEnv) do(req *http.Request) *httptest.ResponseRecorder {
+	return serve(env.mux, req)
+}
+
+// stateCookie 取出响应中设置的state Cookie
+func stateCookie(t *testing.T, rec *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == oidcStateCookie && c.Value != "" {
+			return c
+		}
+	}
+	t.Fatalf("no %s cookie in response", oidcStateCookie)
+	return nil
+}
+
+// signIn 完成一次登录流程，返回回调的响应；tamper可在回调前修改回调地址
+func (env *oidcTestEnv) signIn(t *testing.T, claims map[string]any, tamper func(url.Values)) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := env.do(httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login: status %d: %s", rec.Code, rec.Body)
+	}
+	return env.callback(t, rec.Header().Get("Location"), stateCookie(t, rec), claims, tamper)
+}
+
+// callback 在身份提供方完成授权并带着state Cookie请求回调地址
+func (env *oidcTestEnv) callback(t *testing.T, authURL string, cookie *http.Cookie, claims map[string]any, tamper func(url.Values)) *httptest.ResponseRecorder {
+	t.Helper()
+	callbackURL, err := env.idp.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(callbackURL)
+	if tamper != nil {
+		query := u.Query()
+		tamper(query)
+		u.RawQuery = query.Encode()
+	}
+
+	req := httptest.NewRequest(http.MethodGet, u.RequestURI(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return env.do(req)
+}
+
+func TestOIDCCallback(t *testing.T) {
+	claims := map[string]any{"sub": "ext-1", "preferred_username": "alice"}
+	tests := []struct {
+		name       string
+		require2FA bool
+		tamper     func(url.Values)
+		wantStatus int
+	}{
+		{name: "login", wantStatus: http.StatusOK},
+		{name: "two factor required by role", require2FA: true, wantStatus: http.StatusAccepted},
+		{name: "state mismatch", tamper: func(q url.Values) { q.Set("state", "forged") }, wantStatus: http.StatusBadRequest},
+		{name: "invalid code", tamper: func(q url.Values) { q.Set("code", "forged") }, wantStatus: http.StatusUnauthorized},
+		{name: "provider error", tamper: func(q url.Values) { q.Set("error", "access_denied") }, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOIDCTestEnv(t, "")
+			if tt.require2FA {
+				if err := env.users.SetTwoFactorRequired(t.Context(), entity.RoleUser, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rec := env.signIn(t, claims, tt.tamper)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			switch rec.Code {
+			case http.StatusOK:
+				var login dto.LoginResponse
+				if err := json.NewDecoder(rec.Body).Decode(&login); err != nil {
+					t.Fatal(err)
+				}
+				if login.Token == "" || login.User.Username != "alice" {
+					t.Fatalf("unexpected login response %+v", login)
+				}
+			case http.StatusAccepted:
+				var challenge dto.LoginChallengeResponse
+				if err := json.NewDecoder(rec.Body).Decode(&challenge); err != nil {
+					t.Fatal(err)
+				}
+				if challenge.TwoFactor != string(entity.SecondFactorEnroll) || challenge.ChallengeToken == "" {
+					t.Fatalf("unexpected challenge %+v", challenge)
+				}
+				if strings.Contains(rec.Body.String(), `"token"`) {
+					t.Fatal("access token issued before the second factor")
+				}
+			}
+		})
+	}
+
+	t.Run("missing state cookie", func(t *testing.T) {
+		env := newOIDCTestEnv(t, "")
+		rec := env.do(httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+		rec = env.callback(t, rec.Header().Get("Location"), nil, claims, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", rec.Code)
+		}
+	})
+}
+
+func TestOIDCCallbackRedirect(t *testing.T) {
+	tests := []struct {
+		name         string
+		require2FA   bool
+		wantFragment string
+	}{
+		{"token", false, "token"},
+		{"challenge", true, "challengeToken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOIDCTestEnv(t, "http://web.test/sso")
+			if tt.require2FA {
+				if err := env.users.SetTwoFactorRequired(t.Context(), entity.RoleUser, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rec := env.signIn(t, map[string]any{"sub": "ext-1"}, nil)
+			if rec.Code != http.StatusFound {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			location, err := url.Parse(rec.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fragment, _ := url.ParseQuery(location.Fragment)
+			if location.Host != "web.test" || fragment.Get(tt.wantFragment) == "" {
+				t.Fatalf("unexpected redirect %s", location)
+			}
+			if tt.require2FA && fragment.Get("token") != "" {
+				t.Fatal("access token issued before the second factor")
+			}
+		})
+	}
+}
+
+func TestOIDCLink(t *testing.T) {
+	env := newOIDCTestEnv(t, "")
+	bob, err := env.users.Register(t.Context(), "bob", "local-pass-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := signUserToken(testJWTSecret, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未登录不能发起关联
+	rec := env.do(httptest.NewRequest(http.MethodPost, "/api/auth/oidc/link", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("link without auth: status = %d, want 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/link", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = env.do(req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("link: status = %d: %s", rec.Code, rec.Body)
+	}
+	var link dto.OIDCLinkResponse
+	if err := json.NewDecoder(rec.Body).Decode(&link); err != nil {
+		t.Fatal(err)
+	}
+
+	// 外部身份的用户名与本地用户无关，关联以发起关联的会话为准
+	claims := map[string]any{"sub": "ext-bob", "preferred_username": "robert"}
+	rec = env.callback(t, link.AuthorizationURL, stateCookie(t, rec), claims, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("link callback: status = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = env.signIn(t, claims, nil)
+	var login dto.LoginResponse
+	if err := json.NewDecoder(rec.Body).Decode(&login); err != nil {
+		t.Fatal(err)
+	}
+	if login.User == nil || login.User.ID != bob.ID {
+		t.Fatalf("login after linking returned %+v, want user %d", login.User, bob.ID)
+	}
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/respond"
+	"brb/internal/router"
+)
+
+// ontonHandler 处理onton相关的HTTP请求
+type ontonHandler struct {
+	ontonService ontonService
+}
+
+type ontonService interface {
+	CreateOnton(onton *entity.Onton, role entity.Role) error
+	GetAllOntons() ([]*entity.Onton, error)
+	GetOntonByID(id int64) (*entity.Onton, error)
+	UpdateOnton(onton *entity.Onton, role entity.Role) error
+	DeleteOnton(id int64, role entity.Role) error
+	LinkSign(ontonID, signID int64, userID uint, role entity.Role) error
+	UnlinkSign(ontonID, signID int64, userID uint, role entity.Role) error
+	GetOntonSigns(ontonID int64) ([]*entity.Sign, error)
+	GetSignOntons(signID int64) ([]*entity.Onton, error)
+}
+
+// NewOntonHandler 创建新的OntonHandler
+func NewOntonHandler(ontonService ontonService) *ontonHandler {
+	return &ontonHandler{ontonService: ontonService}
+}
+
+// pathInt64 读取路由中的整数参数
+func pathInt64(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	return id, err == nil
+}
+
+// CreateOnton 创建新onton
+func (h *ontonHandler) CreateOnton(w http.ResponseWriter, r *http.Request) {
+	var req dto.OntonCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	_, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	onton := req.ToEntity()
+	if err := h.ontonService.CreateOnton(onton, role); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromOntonEntity(onton)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetAllOntons 获取所有onton
+func (h *ontonHandler) GetAllOntons(w http.ResponseWriter, r *http.Request) {
+	ontons, err := h.ontonService.GetAllOntons()
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromOntonEntities(ontons)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetOnton 获取单个onton
+func (h *ontonHandler) GetOnton(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	onton, err := h.ontonService.GetOntonByID(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromOntonEntity(onton)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateOnton 更新onton
+func (h *ontonHandler) UpdateOnton(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	var req dto.OntonUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	_, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	onton := req.ToEntity(id)
+	if err := h.ontonService.UpdateOnton(onton, role); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteOnton 删除onton
+func (h *ontonHandler) DeleteOnton(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	_, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	if err := h.ontonService.DeleteOnton(id, role); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetOntonSigns 获取指称onton的sign
+func (h *ontonHandler) GetOntonSigns(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	signs, err := h.ontonService.GetOntonSigns(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromSignEntities(signs, acceptLanguages(w, r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// LinkSign 记录sign指称onton，重复关联不报错
+func (h *ontonHandler) LinkSign(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	signID, signOK := pathInt64(r, "signId")
+	if !ok || !signOK {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	userID, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	if err := h.ontonService.LinkSign(id, signID, userID, role); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlinkSign 取消sign与onton的关联
+func (h *ontonHandler) UnlinkSign(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	signID, signOK := pathInt64(r, "signId")
+	if !ok || !signOK {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	userID, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	if err := h.ontonService.UnlinkSign(id, signID, userID, role); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSignOntons 获取sign指称的onton
+func (h *ontonHandler) GetSignOntons(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	ontons, err := h.ontonService.GetSignOntons(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromOntonEntities(ontons)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RegisterRoutes 注册onton相关路由
+func (h *ontonHandler) RegisterRoutes(r router.Router) {
+	api := r.Group("/api/ontons")
+
+	api.POST("", h.CreateOnton)
+	api.GET("", h.GetAllOntons)
+	api.GET("/{id}", h.GetOnton)
+	api.PUT("/{id}", h.UpdateOnton)
+	api.DELETE("/{id}", h.DeleteOnton)
+	api.GET("/{id}/signs", h.GetOntonSigns)
+	api.PUT("/{id}/signs/{signId}", h.LinkSign)
+	api.DELETE("/{id}/signs/{signId}", h.UnlinkSign)
+
+	signs := r.Group("/api/signs")
+	signs.GET("/{id}/ontons", h.GetSignOntons)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"brb/internal/respond"
+	"brb/pkg/mergepatch"
+)
+
+// maxPatchBodySize PATCH请求体大小上限
+const maxPatchBodySize = 1 << 20
+
+// applyMergePatch 将请求体作为JSON Merge Patch合并到target（当前状态的请求DTO）
+// 出错时写出错误响应并返回false
+func applyMergePatch(w http.ResponseWriter, r *http.Request, target any) bool {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, _ := mime.ParseMediaType(ct)
+		if mediaType != mergepatch.ContentType && mediaType != "application/json" {
+			respond.Fail(w, r, http.StatusUnsupportedMediaType, "PATCH请求的Content-Type应为"+mergepatch.ContentType)
+			return false
+		}
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBodySize))
+	if err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return false
+	}
+	if err := mergepatch.ApplyTo(target, patch); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体: "+err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/respond"
+	"brb/internal/router"
+)
+
+// searchHandler 处理全文搜索的HTTP请求
+type searchHandler struct {
+	searchService searchService
+}
+
+type searchService interface {
+	Search(q entity.SearchQuery, role entity.Role) (*entity.SearchResults, error)
+}
+
+// NewSearchHandler 创建新的SearchHandler
+func NewSearchHandler(searchService searchService) *searchHandler {
+	return &searchHandler{searchService: searchService}
+}
+
+// Search 全文搜索event、task、todo和sign，q中以空格分隔的词须全部匹配
+func (h *searchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	query, err := dto.SearchQuery(r.URL.Query(), userID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	results, err := h.searchService.Search(query, role)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromSearchResults(results, query)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RegisterRoutes 注册全文搜索路由
+func (h *searchHandler) RegisterRoutes(r router.Router) {
+	r.GET("/api/search", h.Search)
+}
@@ -7,67 +7,102 @@ import (
 
 	"brb/internal/dto"
 	"brb/internal/entity"
+	"brb/internal/middleware"
+	"brb/internal/respond"
 	"brb/internal/router"
+	"brb/pkg/langtag"
 )
 
 // signHandler 处理sign相关的HTTP请求
 type signHandler struct {
 	signService signService
+	jwtSecret   string
 }
 
 type signService interface {
-	CreateSign(sign *entity.Sign) error
+	CreateSign(sign *entity.Sign, userID uint) error
 	GetSignByID(id int64) (*entity.Sign, error)
-	UpdateSign(sign *entity.Sign) error
-	DeleteSign(id int64) error
+	ListSigns(q entity.SignQuery) ([]*entity.Sign, int, error)
+	UpdateSign(sign *entity.Sign, userID uint, role entity.Role) error
+	DeleteSign(id int64, userID uint, role entity.Role) error
 }
 
-// NewSignHandler 创建新的SignHandler
-func NewSignHandler(signService signService) *signHandler {
-	return &signHandler{signService: signService}
+// NewSignHandler 创建新的SignHandler，jwtSecret用于写入路由的认证
+func NewSignHandler(signService signService, jwtSecret string) *signHandler {
+	return &signHandler{signService: signService, jwtSecret: jwtSecret}
 }
 
 // CreateSign 创建新sign
 func (h *signHandler) CreateSign(w http.ResponseWriter, r *http.Request) {
 	var req dto.SignCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	userID, _, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
 		return
 	}
 
 	sign := req.ToEntity()
-	if err := h.signService.CreateSign(sign); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.signService.CreateSign(sign, userID); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
-	response := dto.FromSignEntity(sign)
+	response := dto.FromSignEntity(sign, acceptLanguages(w, r))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
 
+// ListSigns 分页查询sign，支持按能指精确(signifier)或前缀(prefix)查找、按所指搜索(q)
+func (h *signHandler) ListSigns(w http.ResponseWriter, r *http.Request) {
+	query, err := dto.SignListQuery(r.URL.Query())
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	signs, total, err := h.signService.ListSigns(query)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromSignPage(signs, total, query, acceptLanguages(w, r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // GetSign 获取单个sign
 func (h *signHandler) GetSign(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	sign, err := h.signService.GetSignByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		respond.Error(w, r, err)
 		return
 	}
 
-	response := dto.FromSignEntity(sign)
+	response := dto.FromSignEntity(sign, acceptLanguages(w, r))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -76,25 +111,36 @@ func (h *signHandler) GetSign(w http.ResponseWriter, r *http.Request) {
 func (h *signHandler) UpdateSign(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var req dto.SignUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	userID, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
 		return
 	}
 
 	sign := req.ToEntity(id)
-	if err := h.signService.UpdateSign(sign); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.signService.UpdateSign(sign, userID, role); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -105,32 +151,54 @@ func (h *signHandler) UpdateSign(w http.ResponseWriter, r *http.Request) {
 func (h *signHandler) DeleteSign(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
-	if err := h.signService.DeleteSign(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	userID, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	if err := h.signService.DeleteSign(id, userID, role); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// RegisterRoutes 注册sign相关路由（新接口）
+// currentUser 获取认证中间件放入上下文的用户ID和角色
+func currentUser(r *http.Request) (uint, entity.Role, bool) {
+	userID, ok := r.Context().Value("userID").(uint)
+	role, _ := r.Context().Value("userRole").(entity.Role)
+	return userID, role, ok
+}
+
+// acceptLanguages 按Accept-Language解析调用方偏好的语言，用于选择sign的首选能指
+// 响应内容随之变化，同时设置Vary头
+func acceptLanguages(w http.ResponseWriter, r *http.Request) []string {
+	w.Header().Add("Vary", "Accept-Language")
+	return langtag.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// RegisterRoutes 注册sign相关路由（新接口），读取公开，写入需要认证
 func (h *signHandler) RegisterRoutes(r router.Router) {
     // 为所有sign路由添加统一中间件
     api := r.Group("/api/signs")
+    auth := middleware.RequireAuth(h.jwtSecret)
     
-    api.POST("", h.CreateSign)
+    api.POST("", h.CreateSign, auth)
+    api.GET("", h.ListSigns)
     api.GET("/{id}", h.GetSign)
-    api.PUT("/{id}", h.UpdateSign)
-    api.DELETE("/{id}", h.DeleteSign)
+    api.PUT("/{id}", h.UpdateSign, auth)
+    api.DELETE("/{id}", h.DeleteSign, auth)
 }
 
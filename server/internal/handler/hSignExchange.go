@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/middleware"
+	"brb/internal/respond"
+	"brb/internal/router"
+	"brb/pkg/skos"
+)
+
+// maxImportBodySize 导入请求体的大小上限
+const maxImportBodySize = 10 << 20
+
+// signExchangeHandler 处理词汇表导入导出的HTTP请求
+type signExchangeHandler struct {
+	signExchangeService signExchangeService
+	jwtSecret           string
+}
+
+type signExchangeService interface {
+	ImportSigns(signs []*entity.Sign, userID uint, role entity.Role, dryRun bool) ([]*entity.SignImportResult, error)
+	ExportSigns() ([]*entity.Sign, []*entity.SignRelation, error)
+}
+
+// NewSignExchangeHandler 创建新的SignExchangeHandler，jwtSecret用于导入路由的认证
+func NewSignExchangeHandler(signExchangeService signExchangeService, jwtSecret string) *signExchangeHandler {
+	return &signExchangeHandler{signExchangeService: signExchangeService, jwtSecret: jwtSecret}
+}
+
+// ImportSigns 从CSV或JSON批量导入sign，按能指新建或更新
+// 格式取自format参数，缺省时按Content-Type判断；dryRun=true时只报告将进行的变更
+func (h *signExchangeHandler) ImportSigns(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = dto.SignFormatCSV
+		case "application/json", "":
+			format = dto.SignFormatJSON
+		default:
+			respond.Fail(w, r, http.StatusUnsupportedMediaType, "请求体必须是text/csv或application/json")
+			return
+		}
+	}
+	if format != dto.SignFormatCSV && format != dto.SignFormatJSON {
+		respond.Fail(w, r, http.StatusBadRequest, "format必须是csv或json")
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dryRun"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			respond.Fail(w, r, http.StatusBadRequest, "无效的dryRun参数")
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respond.Fail(w, r, http.StatusRequestEntityTooLarge, "请求体过大")
+			return
+		}
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	signs, err := dto.DecodeSignImport(format, bytes.NewReader(body))
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	userID, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	results, err := h.signExchangeService.ImportSigns(signs, userID, role, dryRun)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromSignImportResults(results, dryRun)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ExportSigns 导出全部sign，格式取自format参数，缺省时按Accept判断，默认JSON
+// skos格式输出RDF Turtle，包含sign之间的关系
+func (h *signExchangeHandler) ExportSigns(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormat(r.Header.Get("Accept"))
+	}
+
+	var contentType string
+	switch format {
+	case dto.SignFormatJSON:
+		contentType = "application/json"
+	case dto.SignFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case dto.SignFormatSKOS:
+		contentType = "text/turtle; charset=utf-8"
+	default:
+		respond.Fail(w, r, http.StatusBadRequest, "format必须是csv、json或skos")
+		return
+	}
+
+	signs, relations, err := h.signExchangeService.ExportSigns()
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	// 先写入缓冲区，编码失败时仍能返回错误响应
+	var buf bytes.Buffer
+	switch format {
+	case dto.SignFormatJSON:
+		err = json.NewEncoder(&buf).Encode(dto.FromSignEntities(signs, nil))
+	case dto.SignFormatCSV:
+		err = dto.EncodeSignsCSV(&buf, signs)
+	case dto.SignFormatSKOS:
+		err = skos.WriteTurtle(&buf, dto.SignScheme(signBaseURI(r), signs, relations))
+	}
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	extension := format
+	if format == dto.SignFormatSKOS {
+		extension = "ttl"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="signs.`+extension+`"`)
+	w.Write(buf.Bytes())
+}
+
+// exportFormat 按Accept请求头选择导出格式，无法识别时为JSON
+func exportFormat(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(part))
+		switch mediaType {
+		case "text/csv":
+			return dto.SignFormatCSV
+		case "text/turtle":
+			return dto.SignFormatSKOS
+		case "application/json":
+			return dto.SignFormatJSON
+		}
+	}
+	return dto.SignFormatJSON
+}
+
+// signBaseURI 由导出请求的地址推出sign资源的URI前缀，如http://host/v1/api/signs
+func signBaseURI(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + strings.TrimSuffix(r.URL.Path, "/export")
+}
+
+// RegisterRoutes 注册词汇表导入导出路由，导出公开，导入需要认证
+func (h *signExchangeHandler) RegisterRoutes(r router.Router) {
+	api := r.Group("/api/signs")
+
+	api.POST("/import", h.ImportSigns, middleware.RequireAuth(h.jwtSecret))
+	api.GET("/export", h.ExportSigns)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/repo"
+	"brb/internal/router"
+	"brb/internal/service"
+)
+
+func TestSignLabelsRoundTrip(t *testing.T) {
+	labels := []entity.SignLabel{
+		{Lang: "en-GB", Value: "moggy"},
+		{Lang: "zh-Hans", Value: "猫", Preferred: true},
+		{Lang: "zh-Hans", Value: "猫咪"},
+	}
+
+	for _, format := range []string{dto.SignFormatCSV, dto.SignFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			db, users := newTestUserService(t)
+			alice, err := users.Register(t.Context(), "alice", "secret-pass-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := signUserToken(testJWTSecret, alice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signRepo, err := repo.NewSignRepo(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			relationRepo, err := repo.NewSignRelationRepo(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy := service.SignPolicy{EditorRoles: []entity.Role{entity.RoleUser}}
+			mux := http.NewServeMux()
+			NewSignExchangeHandler(service.NewSignExchangeService(signRepo, relationRepo, policy), testJWTSecret).
+				RegisterRoutes(router.NewStandardRouter(mux))
+
+			cat := &entity.Sign{Signifier: "cat", Signified: "a small feline", Labels: labels}
+			if err := signRepo.Create(cat); err != nil {
+				t.Fatal(err)
+			}
+
+			rec := serve(mux, httptest.NewRequest(http.MethodGet, "/api/signs/export?format="+format, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("export: status = %d: %s", rec.Code, rec.Body)
+			}
+			exported := rec.Body.Bytes()
+
+			importSigns := func() *dto.SignImportReport {
+				t.Helper()
+				req := httptest.NewRequest(http.MethodPost, "/api/signs/import?format="+format, bytes.NewReader(exported))
+				req.Header.Set("Authorization", "Bearer "+token)
+				rec := serve(mux, req)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("import: status = %d: %s", rec.Code, rec.Body)
+				}
+				var report dto.SignImportReport
+				if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+					t.Fatal(err)
+				}
+				return &report
+			}
+
+			// 导出后删除的多语言能指由导入恢复
+			if _, err := db.Exec("DELETE FROM sign_labels"); err != nil {
+				t.Fatal(err)
+			}
+			if report := importSigns(); report.Updated != 1 {
+				t.Fatalf("first import: %+v, want 1 updated", report)
+			}
+			stored, err := signRepo.GetByID(cat.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(stored.Labels, labels) {
+				t.Fatalf("labels = %+v, want %+v", stored.Labels, labels)
+			}
+
+			if report := importSigns(); report.Unchanged != 1 {
+				t.Fatalf("second import: %+v, want 1 unchanged", report)
+			}
+		})
+	}
+}
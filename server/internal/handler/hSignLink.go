@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/respond"
+	"brb/internal/router"
+)
+
+// signLinkHandler 处理sign与event/task/todo关联的HTTP请求
+type signLinkHandler struct {
+	signLinkService signLinkService
+}
+
+type signLinkService interface {
+	LinkSign(signID int64, targetType entity.SignTarget, targetID, userID uint, role entity.Role) error
+	UnlinkSign(signID int64, targetType entity.SignTarget, targetID, userID uint, role entity.Role) error
+	GetSigns(targetType entity.SignTarget, targetID uint) ([]*entity.Sign, error)
+	GetSignItems(signID int64) (*entity.SignItems, error)
+}
+
+// NewSignLinkHandler 创建新的SignLinkHandler
+func NewSignLinkHandler(signLinkService signLinkService) *signLinkHandler {
+	return &signLinkHandler{signLinkService: signLinkService}
+}
+
+// signLinkPath 路由中的对象ID和signId
+func signLinkPath(r *http.Request) (targetID uint, signID int64, err error) {
+	if _, err = fmt.Sscanf(r.PathValue("id"), "%d", &targetID); err != nil {
+		return 0, 0, err
+	}
+	if signIDStr := r.PathValue("signId"); signIDStr != "" {
+		if _, err = fmt.Sscanf(signIDStr, "%d", &signID); err != nil {
+			return 0, 0, err
+		}
+	}
+	return targetID, signID, nil
+}
+
+// getSigns 获取对象关联的sign
+func (h *signLinkHandler) getSigns(targetType entity.SignTarget) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		targetID, _, err := signLinkPath(r)
+		if err != nil {
+			respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+			return
+		}
+
+		signs, err := h.signLinkService.GetSigns(targetType, targetID)
+		if err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+
+		response := dto.FromSignEntities(signs, acceptLanguages(w, r))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// linkSign 为对象关联sign，重复关联不报错
+func (h *signLinkHandler) linkSign(targetType entity.SignTarget) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		targetID, signID, err := signLinkPath(r)
+		if err != nil {
+			respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+			return
+		}
+
+		userID, role, ok := currentUser(r)
+		if !ok {
+			respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+			return
+		}
+
+		if err := h.signLinkService.LinkSign(signID, targetType, targetID, userID, role); err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// unlinkSign 取消对象与sign的关联
+func (h *signLinkHandler) unlinkSign(targetType entity.SignTarget) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		targetID, signID, err := signLinkPath(r)
+		if err != nil {
+			respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+			return
+		}
+
+		userID, role, ok := currentUser(r)
+		if !ok {
+			respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+			return
+		}
+
+		if err := h.signLinkService.UnlinkSign(signID, targetType, targetID, userID, role); err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetSignItems 获取关联了sign的全部event、task和todo
+func (h *signLinkHandler) GetSignItems(w http.ResponseWriter, r *http.Request) {
+	var id int64
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	items, err := h.signLinkService.GetSignItems(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromSignItems(items, userLocation(r), acceptLanguages(w, r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RegisterRoutes 注册sign关联相关路由
+func (h *signLinkHandler) RegisterRoutes(r router.Router) {
+	targets := []struct {
+		prefix     string
+		targetType entity.SignTarget
+	}{
+		{"/api/events", entity.SignTargetEvent},
+		{"/api/tasks", entity.SignTargetTask},
+		{"/api/todos", entity.SignTargetTodo},
+	}
+	for _, target := range targets {
+		api := r.Group(target.prefix)
+		api.GET("/{id}/signs", h.getSigns(target.targetType))
+		api.PUT("/{id}/signs/{signId}", h.linkSign(target.targetType))
+		api.DELETE("/{id}/signs/{signId}", h.unlinkSign(target.targetType))
+	}
+
+	signs := r.Group("/api/signs")
+	signs.GET("/{id}/items", h.GetSignItems)
+}
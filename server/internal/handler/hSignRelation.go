@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/middleware"
+	"brb/internal/respond"
+	"brb/internal/router"
+)
+
+// signRelationHandler 处理sign之间关系的HTTP请求
+type signRelationHandler struct {
+	signRelationService signRelationService
+	jwtSecret           string
+}
+
+type signRelationService interface {
+	AddRelation(fromID, toID int64, relationType entity.SignRelationType, userID uint, role entity.Role) (*entity.SignRelation, error)
+	RemoveRelation(signID, relationID int64, userID uint, role entity.Role) error
+	GetNeighbors(signID int64, types []entity.SignRelationType) ([]*entity.SignNeighbor, error)
+	Traverse(signID int64, depth int, types []entity.SignRelationType) (*entity.SignGraph, error)
+}
+
+// NewSignRelationHandler 创建新的SignRelationHandler，jwtSecret用于写入路由的认证
+func NewSignRelationHandler(signRelationService signRelationService, jwtSecret string) *signRelationHandler {
+	return &signRelationHandler{signRelationService: signRelationService, jwtSecret: jwtSecret}
+}
+
+// AddRelation 添加从当前sign指向targetId的关系
+func (h *signRelationHandler) AddRelation(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	var req dto.SignRelationCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	userID, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	relation, err := h.signRelationService.AddRelation(id, req.TargetID, entity.SignRelationType(req.Type), userID, role)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromSignRelationEntity(relation)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RemoveRelation 删除与当前sign相连的关系
+func (h *signRelationHandler) RemoveRelation(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	relationID, relationOK := pathInt64(r, "relationId")
+	if !ok || !relationOK {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	userID, role, ok := currentUser(r)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	if err := h.signRelationService.RemoveRelation(id, relationID, userID, role); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetNeighbors 获取与当前sign直接相连的sign，可通过type参数筛选
+func (h *signRelationHandler) GetNeighbors(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	neighbors, err := h.signRelationService.GetNeighbors(id, dto.SignRelationTypes(r.URL.Query()))
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromSignNeighbors(neighbors, acceptLanguages(w, r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetGraph 从当前sign出发广度优先遍历，返回子图
+func (h *signRelationHandler) GetGraph(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	depth, err := dto.SignGraphDepth(r.URL.Query())
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	graph, err := h.signRelationService.Traverse(id, depth, dto.SignRelationTypes(r.URL.Query()))
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromSignGraph(graph, acceptLanguages(w, r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RegisterRoutes 注册sign关系相关路由，读取公开，写入需要认证
+func (h *signRelationHandler) RegisterRoutes(r router.Router) {
+	api := r.Group("/api/signs")
+	auth := middleware.RequireAuth(h.jwtSecret)
+
+	api.POST("/{id}/relations", h.AddRelation, auth)
+	api.GET("/{id}/relations", h.GetNeighbors)
+	api.DELETE("/{id}/relations/{relationId}", h.RemoveRelation, auth)
+	api.GET("/{id}/graph", h.GetGraph)
+}
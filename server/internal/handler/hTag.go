@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/respond"
+	"brb/internal/router"
+)
+
+// tagHandler 处理tag相关的HTTP请求，tag随event的tags字段创建
+type tagHandler struct {
+	tagService tagService
+}
+
+type tagService interface {
+	GetAllTags() ([]*entity.Tag, error)
+	GetTagByID(id int64) (*entity.Tag, error)
+	RenameTag(id int64, name string) (*entity.Tag, error)
+	MergeTag(sourceID, targetID int64) (*entity.Tag, error)
+	DeleteTag(id int64) error
+}
+
+// NewTagHandler 创建新的TagHandler
+func NewTagHandler(tagService tagService) *tagHandler {
+	return &tagHandler{tagService: tagService}
+}
+
+// GetAllTags 获取所有tag及其使用次数
+func (h *tagHandler) GetAllTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tagService.GetAllTags()
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTagEntities(tags)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTag 获取单个tag
+func (h *tagHandler) GetTag(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	tag, err := h.tagService.GetTagByID(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTagEntity(tag)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RenameTag 重命名tag，所有使用它的event随之改变
+func (h *tagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	var req dto.TagRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	tag, err := h.tagService.RenameTag(id, req.TagName())
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTagEntity(tag)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// MergeTag 将tag合并到into指定的tag，返回合并后的目标tag
+func (h *tagHandler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	var req dto.TagMergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	tag, err := h.tagService.MergeTag(id, req.Into)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTagEntity(tag)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteTag 删除tag
+func (h *tagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathInt64(r, "id")
+	if !ok {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	if err := h.tagService.DeleteTag(id); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterRoutes 注册tag相关路由
+func (h *tagHandler) RegisterRoutes(r router.Router) {
+	api := r.Group("/api/tags")
+
+	api.GET("", h.GetAllTags)
+	api.GET("/{id}", h.GetTag)
+	api.PUT("/{id}", h.RenameTag)
+	api.POST("/{id}/merge", h.MergeTag)
+	api.DELETE("/{id}", h.DeleteTag)
+}
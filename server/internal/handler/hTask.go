@@ -7,6 +7,7 @@ import (
 
 	"brb/internal/dto"
 	"brb/internal/entity"
+	"brb/internal/respond"
 	"brb/internal/router"
 )
 
@@ -18,6 +19,8 @@ type taskHandler struct {
 type TaskService interface {
 	CreateTask(task *entity.Task) error
 	GetAllTasks() ([]*entity.Task, error)
+	GetTasksBySign(signID int64) ([]*entity.Task, error)
+	GetTasksByEventTags(f entity.TagFilter) ([]*entity.Task, error)
 	GetTaskByID(id uint) (*entity.Task, error)
 	UpdateTask(task *entity.Task) error
 	DeleteTask(id uint) error
@@ -32,30 +35,59 @@ func NewTaskHandler(taskService TaskService) *taskHandler {
 func (h *taskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	var req dto.TaskCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体: "+err.Error())
 		return
 	}
 
-	task := req.ToEntity()
+	if err := req.Validate(userLocation(r)); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	task := req.ToEntity(userLocation(r))
 	if err := h.taskService.CreateTask(task); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
-	response := dto.FromTaskEntity(task)
+	response := dto.FromTaskEntity(task, userLocation(r))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetAllTasks 获取所有task
+// GetAllTasks 获取所有task，可通过sign参数只返回关联了该sign的task，
+// 或通过tags参数只返回所属event带有任一（match=all时为全部）标签的task
 func (h *taskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	tasks, err := h.taskService.GetAllTasks()
+	filter, err := dto.TagFilterQuery(r.URL.Query())
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	var tasks []*entity.Task
+	signStr := r.URL.Query().Get("sign")
+	switch {
+	case signStr != "" && len(filter.Tags) > 0:
+		respond.Fail(w, r, http.StatusBadRequest, "sign和tags参数不能同时使用")
+		return
+	case signStr != "":
+		var signID int64
+		if _, err := fmt.Sscanf(signStr, "%d", &signID); err != nil {
+			respond.Fail(w, r, http.StatusBadRequest, "无效的sign参数")
+			return
+		}
+		tasks, err = h.taskService.GetTasksBySign(signID)
+	case len(filter.Tags) > 0:
+		tasks, err = h.taskService.GetTasksByEventTags(filter)
+	default:
+		tasks, err = h.taskService.GetAllTasks()
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
-	response := dto.FromTaskEntities(tasks)
+	response := dto.FromTaskEntities(tasks, userLocation(r))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -64,23 +96,23 @@ func (h *taskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 func (h *taskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
 
 	task, err := h.taskService.GetTaskByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		respond.Error(w, r, err)
 		return
 	}
 
-	response := dto.FromTaskEntity(task)
+	response := dto.FromTaskEntity(task, userLocation(r))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -89,47 +121,95 @@ func (h *taskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 func (h *taskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
 
 	var req dto.TaskUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(userLocation(r)); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
-	task := req.ToEntity(id)
+	task := req.ToEntity(id, userLocation(r))
 	if err := h.taskService.UpdateTask(task); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// PatchTask 按JSON Merge Patch部分更新task，未提供的字段保持不变
+func (h *taskHandler) PatchTask(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	var id uint
+	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	current, err := h.taskService.GetTaskByID(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	loc := userLocation(r)
+	req := dto.TaskUpdateRequestFromEntity(current, loc)
+	if !applyMergePatch(w, r, req) {
+		return
+	}
+
+	if err := req.Validate(loc); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	task := req.ToEntity(id, loc)
+	task.CreatedAt = current.CreatedAt
+	task.AssigneeID = current.AssigneeID
+	if err := h.taskService.UpdateTask(task); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.FromTaskEntity(task, loc))
+}
+
 // DeleteTask 删除task
 func (h *taskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
 
 	if err := h.taskService.DeleteTask(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -145,5 +225,6 @@ func (h *taskHandler) RegisterRoutes(r router.Router) {
     api.GET("", h.GetAllTasks)
     api.GET("/{id}", h.GetTask)
     api.PUT("/{id}", h.UpdateTask)
+    api.PATCH("/{id}", h.PatchTask)
     api.DELETE("/{id}", h.DeleteTask)
 }
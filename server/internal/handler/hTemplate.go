@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/respond"
+	"brb/internal/router"
+)
+
+// templateHandler 处理模板event的清单和实例化请求
+type templateHandler struct {
+	templateService templateService
+}
+
+type templateService interface {
+	GetChecklist(eventID uint) (*entity.Checklist, error)
+	SetChecklist(eventID uint, checklist *entity.Checklist) error
+	Instantiate(eventID uint, window entity.TimeSpan, loc *time.Location) (*entity.TaskTree, error)
+}
+
+// NewTemplateHandler 创建新的TemplateHandler
+func NewTemplateHandler(templateService templateService) *templateHandler {
+	return &templateHandler{templateService: templateService}
+}
+
+// GetChecklist 获取模板event的清单
+func (h *templateHandler) GetChecklist(w http.ResponseWriter, r *http.Request) {
+	var id uint
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	checklist, err := h.templateService.GetChecklist(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromChecklistEntity(checklist)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetChecklist 替换模板event的清单
+func (h *templateHandler) SetChecklist(w http.ResponseWriter, r *http.Request) {
+	var id uint
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	var req dto.ChecklistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	if err := h.templateService.SetChecklist(id, req.ToEntity()); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Instantiate 按模板event在时间窗口内创建task及清单中的子task和todo，返回创建的task树
+func (h *templateHandler) Instantiate(w http.ResponseWriter, r *http.Request) {
+	var id uint
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+
+	var req dto.InstantiateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	loc := userLocation(r)
+	if err := req.Validate(loc); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	tree, err := h.templateService.Instantiate(id, req.Window(loc), loc)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.FromTaskTree(tree, loc)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RegisterRoutes 注册模板相关路由
+func (h *templateHandler) RegisterRoutes(r router.Router) {
+	api := r.Group("/api/events")
+
+	api.GET("/{id}/checklist", h.GetChecklist)
+	api.PUT("/{id}/checklist", h.SetChecklist)
+	api.POST("/{id}/instantiate", h.Instantiate)
+}
@@ -1,15 +1,17 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"brb/internal/dto"
 	"brb/internal/entity"
+	"brb/internal/respond"
 	"brb/internal/router"
-	"brb/pkg/logger"
 )
 
 // todoHandler 处理todo相关的HTTP请求
@@ -22,7 +24,7 @@ type TodoService interface {
 	CreateTodoWithDetails(event *entity.Event, task *entity.Task, todo *entity.Todo) error
 	GetAllTodo() ([]*entity.Todo, error)
 	GetTodoByID(id uint) (*entity.Todo, error)
-	UpdateTodo(todo *entity.Todo) error
+	UpdateTodo(ctx context.Context, todo *entity.Todo) error
 	DeleteTodo(id uint) error
 }
 
@@ -39,13 +41,13 @@ func (h *todoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	if contentType == "application/json" {
 		// 处理JSON请求
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			respond.Fail(w, r, http.StatusBadRequest, "无效的请求体: "+err.Error())
 			return
 		}
 	} else {
 		// 处理表单数据
 		if err := r.ParseForm(); err != nil {
-			http.Error(w, "Invalid form data: "+err.Error(), http.StatusBadRequest)
+			respond.Fail(w, r, http.StatusBadRequest, "无效的表单数据: "+err.Error())
 			return
 		}
 
@@ -62,15 +64,20 @@ func (h *todoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 		req.ActualEnd = r.FormValue("actualEnd")
 	}
 
-	logger.Tip.Printf("Received CreateTodo request:%+v", req)
+	if err := req.Validate(userLocation(r)); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
 
-	todo := req.ToEntity()
+	slog.DebugContext(r.Context(), "received CreateTodo request", "request", req)
+
+	todo := req.ToEntity(userLocation(r))
 	if err := h.todoService.CreateTodo(todo); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
-	response := dto.FromTodoEntity(todo)
+	response := dto.FromTodoEntity(todo, userLocation(r))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
@@ -78,13 +85,13 @@ func (h *todoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 
 // GetAllTodo 获取所有todo
 func (h *todoHandler) GetAllTodo(w http.ResponseWriter, r *http.Request) {
-	logger.Tip.Println("Received GetAllTodo request")
+	slog.DebugContext(r.Context(), "received GetAllTodo request")
 	todos, err := h.todoService.GetAllTodo()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
-	responses := dto.FromTodoEntities(todos)
+	responses := dto.FromTodoEntities(todos, userLocation(r))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(responses)
 }
@@ -93,24 +100,24 @@ func (h *todoHandler) GetAllTodo(w http.ResponseWriter, r *http.Request) {
 func (h *todoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
-	logger.Tip.Println("Received GetTodo request for ID:", id)
+	slog.DebugContext(r.Context(), "received GetTodo request", "id", id)
 
 	todo, err := h.todoService.GetTodoByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		respond.Error(w, r, err)
 		return
 	}
 
-	response := dto.FromTodoEntity(todo)
+	response := dto.FromTodoEntity(todo, userLocation(r))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -119,50 +126,98 @@ func (h *todoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
 func (h *todoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
-	logger.Tip.Println("Received UpdateTodo request for ID:", id)
+	slog.DebugContext(r.Context(), "received UpdateTodo request", "id", id)
 
 	var req dto.TodoUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Error.Println("Error decoding request body:", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		slog.DebugContext(r.Context(), "error decoding request body", "error", err)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(userLocation(r)); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
-	todo := req.ToEntity(id)
-	if err := h.todoService.UpdateTodo(todo); err != nil {
-		logger.Error.Println("Error updating todo:", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	todo := req.ToEntity(id, userLocation(r))
+	if err := h.todoService.UpdateTodo(r.Context(), todo); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// PatchTodo 按JSON Merge Patch部分更新todo，未提供的字段保持不变
+func (h *todoHandler) PatchTodo(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	var id uint
+	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
+		return
+	}
+	slog.DebugContext(r.Context(), "received PatchTodo request", "id", id)
+
+	current, err := h.todoService.GetTodoByID(id)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	loc := userLocation(r)
+	req := dto.TodoUpdateRequestFromEntity(current, loc)
+	if !applyMergePatch(w, r, req) {
+		return
+	}
+
+	if err := req.Validate(loc); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	todo := req.ToEntity(id, loc)
+	todo.CompletedTime = current.CompletedTime
+	todo.AssigneeID = current.AssigneeID
+	if err := h.todoService.UpdateTodo(r.Context(), todo); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.FromTodoEntity(todo, loc))
+}
+
 // DeleteTodo 删除todo
 func (h *todoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID")
 		return
 	}
 
 	var id uint
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的ID格式")
 		return
 	}
 
 	if err := h.todoService.DeleteTodo(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -178,5 +233,6 @@ func (h *todoHandler) RegisterRoutes(r router.Router) {
 	api.GET("", h.GetAllTodo)
 	api.GET("/{id}", h.GetTodo)
 	api.PUT("/{id}", h.UpdateTodo)
+	api.PATCH("/{id}", h.PatchTodo)
 	api.DELETE("/{id}", h.DeleteTodo)
 }
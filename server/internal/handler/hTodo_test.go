@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/router"
+)
+
+// todoStub 记录创建的todo
+type todoStub struct {
+	TodoService
+	created **entity.Todo
+}
+
+func (s todoStub) CreateTodo(todo *entity.Todo) error {
+	*s.created = todo
+	return nil
+}
+
+func TestCreateTodoSpanInUserLocation(t *testing.T) {
+	// 用户时区为UTC+8，本地时间09:00即UTC 01:00
+	tests := []struct {
+		name       string
+		start, end string
+		wantCode   int
+		wantStart  string // 保存的开始时间（UTC）
+	}{
+		{"local start before offset end", "2025-03-01T09:00", "2025-03-01T02:00:00Z", http.StatusCreated, "2025-03-01T01:00:00Z"},
+		{"offset start after local end", "2025-03-01T09:00:00Z", "2025-03-01T10:00", http.StatusBadRequest, ""},
+		{"local times", "2025-03-01T09:00", "2025-03-01T10:00", http.StatusCreated, "2025-03-01T01:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *entity.Todo
+			mux := http.NewServeMux()
+			NewTodoHandler(todoStub{created: &created}).RegisterRoutes(router.NewStandardRouter(mux))
+
+			body := `{"taskId": 1, "status": "pending", "plannedStart": "` + tt.start + `", "plannedEnd": "` + tt.end + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/todos", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), "userLocation", time.FixedZone("UTC+8", 8*3600)))
+			rec := serve(mux, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantStart == "" {
+				if created != nil {
+					t.Fatalf("created %+v for an inverted span", created)
+				}
+				return
+			}
+			planned := created.PlannedTime
+			if got := planned.Start.Format(time.RFC3339); got != tt.wantStart || planned.End.Before(*planned.Start) {
+				t.Fatalf("planned = %s..%s, want start %s", got, planned.End.Format(time.RFC3339), tt.wantStart)
+			}
+		})
+	}
+}
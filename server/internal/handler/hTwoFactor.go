@@ -0,0 +1,397 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"brb/internal/dto"
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/respond"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// challengeTTL 两步验证挑战令牌的有效期
+const challengeTTL = 5 * time.Minute
+
+// respondLogin 根据两步验证要求返回JWT或挑战令牌
+func (h *userHandler) respondLogin(w http.ResponseWriter, r *http.Request, user *entity.User, status int) {
+	login, challenge, err := h.completeLogin(user)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	if challenge != nil {
+		respond.JSON(w, http.StatusAccepted, challenge)
+		return
+	}
+	respond.JSON(w, status, login)
+}
+
+// completeLogin 完成身份校验后的登录：角色或用户要求两步验证时返回挑战令牌，否则签发JWT
+// 密码登录和SSO登录都经过这里，保证两步验证要求一致
+func (h *userHandler) completeLogin(user *entity.User) (*dto.LoginResponse, *dto.LoginChallengeResponse, error) {
+	factor, err := h.userService.SecondFactorRequirement(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if factor != entity.SecondFactorNone {
+		challenge, err := h.generateChallenge(user, factor)
+		if err != nil {
+			return nil, nil, fmt.Errorf("生成挑战令牌失败: %w", err)
+		}
+		return nil, &dto.LoginChallengeResponse{
+			TwoFactor:      string(factor),
+			ChallengeToken: challenge,
+			ExpiresIn:      int(challengeTTL.Seconds()),
+		}, nil
+	}
+
+	// 生成JWT token
+	token, err := h.generateJWT(user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成token失败: %w", err)
+	}
+	return &dto.LoginResponse{
+		User:  dto.FromUserEntity(user),
+		Token: token,
+	}, nil, nil
+}
+
+// VerifyTwoFactor 提交TOTP验证码或恢复码完成登录
+func (h *userHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req dto.TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	userID, err := h.parseChallenge(req.ChallengeToken, entity.SecondFactorVerify)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	user, err := h.userService.VerifySecondFactor(r.Context(), userID, req.Code, clientIP(r))
+	if err != nil {
+		if writeThrottled(w, r, err) {
+			return
+		}
+		respond.Error(w, r, err)
+		return
+	}
+
+	token, err := h.generateJWT(user)
+	if err != nil {
+		respond.Fail(w, r, http.StatusInternalServerError, "生成token失败")
+		return
+	}
+
+	response := dto.LoginResponse{
+		User:  dto.FromUserEntity(user),
+		Token: token,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ChallengeEnrollTOTP 角色要求两步验证的用户在登录过程中开始绑定认证器
+func (h *userHandler) ChallengeEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	var req dto.TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	userID, err := h.parseChallenge(req.ChallengeToken, entity.SecondFactorEnroll)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	h.beginEnrollment(w, r, userID)
+}
+
+// ChallengeConfirmTOTP 登录过程中确认绑定，启用两步验证并完成登录
+func (h *userHandler) ChallengeConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var req dto.TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	userID, err := h.parseChallenge(req.ChallengeToken, entity.SecondFactorEnroll)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	codes, err := h.userService.ConfirmTOTPEnrollment(r.Context(), userID, req.Code)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	user, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	token, err := h.generateJWT(user)
+	if err != nil {
+		respond.Fail(w, r, http.StatusInternalServerError, "生成token失败")
+		return
+	}
+
+	response := dto.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+		User:          dto.FromUserEntity(user),
+		Token:         token,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// EnrollTOTP 已登录用户开始绑定认证器
+func (h *userHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	h.beginEnrollment(w, r, userID)
+}
+
+// ConfirmTOTP 已登录用户确认绑定，返回恢复码
+func (h *userHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	var req dto.TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	codes, err := h.userService.ConfirmTOTPEnrollment(r.Context(), userID, req.Code)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP 已登录用户关闭两步验证
+func (h *userHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	var req dto.TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	if err := h.userService.DisableTOTP(r.Context(), userID, req.Code); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes 已登录用户重新生成恢复码
+func (h *userHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
+		return
+	}
+
+	var req dto.TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetTOTP 管理员清除用户的两步验证
+func (h *userHandler) ResetTOTP(w http.ResponseWriter, r *http.Request) {
+	// 只有管理员可以清除两步验证
+	userRole, ok := r.Context().Value("userRole").(entity.Role)
+	if !ok || userRole != entity.RoleAdmin {
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID格式")
+		return
+	}
+
+	if err := h.userService.ResetTOTP(r.Context(), uint(id)); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTwoFactorPolicy 查询各角色的两步验证要求
+func (h *userHandler) GetTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	userRole, ok := r.Context().Value("userRole").(entity.Role)
+	if !ok || userRole != entity.RoleAdmin {
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
+		return
+	}
+
+	policy := make(map[string]bool)
+	for _, role := range []entity.Role{entity.RoleAdmin, entity.RoleUser} {
+		required, err := h.userService.IsTwoFactorRequired(role)
+		if err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+		policy[string(role)] = required
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// SetTwoFactorPolicy 设置某个角色是否必须启用两步验证
+func (h *userHandler) SetTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	userRole, ok := r.Context().Value("userRole").(entity.Role)
+	if !ok || userRole != entity.RoleAdmin {
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
+		return
+	}
+
+	var req dto.TwoFactorPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	if err := h.userService.SetTwoFactorRequired(r.Context(), entity.Role(req.Role), req.Required); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// beginEnrollment 生成TOTP密钥并返回绑定信息
+func (h *userHandler) beginEnrollment(w http.ResponseWriter, r *http.Request, userID uint) {
+	secret, uri, err := h.userService.BeginTOTPEnrollment(userID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.TOTPEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// generateChallenge 生成两步验证挑战令牌，带purpose声明，不能用于访问受保护接口
+func (h *userHandler) generateChallenge(user *entity.User, factor entity.SecondFactor) (string, error) {
+	claims := jwt.MapClaims{
+		"userID":  user.ID,
+		"purpose": "2fa-" + string(factor),
+		"exp":     jwt.NewNumericDate(time.Now().Add(challengeTTL)),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(h.jwtSecret))
+}
+
+// parseChallenge 校验挑战令牌并返回用户ID
+func (h *userHandler) parseChallenge(tokenString string, factor entity.SecondFactor) (uint, error) {
+	if tokenString == "" {
+		return 0, errs.Unauthorized("缺少挑战令牌")
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("意外的签名方法: %v", token.Header["alg"])
+		}
+		return []byte(h.jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, errs.Unauthorized("挑战令牌无效或已过期")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "2fa-"+string(factor) {
+		return 0, errs.Unauthorized("挑战令牌无效或已过期")
+	}
+
+	userID, ok := claims["userID"].(float64)
+	if !ok {
+		return 0, errs.Unauthorized("挑战令牌无效或已过期")
+	}
+	return uint(userID), nil
+}
@@ -1,13 +1,18 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
 
 	"brb/internal/dto"
 	"brb/internal/entity"
+	"brb/internal/middleware"
+	"brb/internal/respond"
 	"brb/internal/router"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,15 +25,29 @@ type userHandler struct {
 }
 
 type UserService interface {
-	Register(username, password string) (*entity.User, error)
-	Login(username, password string) (*entity.User, error)
+	Register(ctx context.Context, username, password string) (*entity.User, error)
+	Login(ctx context.Context, username, password, ip string) (*entity.User, error)
 	GetUserByID(id uint) (*entity.User, error)
 	GetAllUsers() ([]*entity.User, error)
-	UpdateUser(id uint, username, password string, role entity.Role) (*entity.User, error)
-	DeleteUser(id uint) error
-	ChangePassword(id uint, oldPassword, newPassword string) error
-	PromoteToAdmin(id uint) error
-	DemoteToUser(id uint) error
+	UpdateUser(ctx context.Context, id uint, username, password string, role entity.Role, timezone string) (*entity.User, error)
+	DeleteUser(ctx context.Context, id uint) error
+	ChangePassword(ctx context.Context, id uint, oldPassword, newPassword string) error
+	PromoteToAdmin(ctx context.Context, id uint) error
+	DemoteToUser(ctx context.Context, id uint) error
+	UnlockUser(ctx context.Context, id uint) error
+	IssuePasswordResetToken(ctx context.Context, userID, adminID uint) (string, time.Time, error)
+	ResetPassword(ctx context.Context, plainToken, newPassword string) error
+	ForcePasswordChange(ctx context.Context, id uint) error
+	ChangeExpiredPassword(ctx context.Context, username, oldPassword, newPassword, ip string) (*entity.User, error)
+	SecondFactorRequirement(user *entity.User) (entity.SecondFactor, error)
+	IsTwoFactorRequired(role entity.Role) (bool, error)
+	SetTwoFactorRequired(ctx context.Context, role entity.Role, required bool) error
+	BeginTOTPEnrollment(userID uint) (string, string, error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID uint, code string) ([]string, error)
+	VerifySecondFactor(ctx context.Context, userID uint, code, ip string) (*entity.User, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uint, code string) error
+	ResetTOTP(ctx context.Context, userID uint) error
 }
 
 // NewUserHandler 创建新的UserHandler
@@ -43,73 +62,47 @@ func NewUserHandler(userService UserService, jwtSecret string) *userHandler {
 func (h *userHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req dto.UserRegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "无效的请求体: "+err.Error(), http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体: "+err.Error())
 		return
 	}
 
-	// 验证请求数据
-	if req.Username == "" || req.Password == "" {
-		http.Error(w, "用户名和密码不能为空", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
-	user, err := h.userService.Register(req.Username, req.Password)
+	user, err := h.userService.Register(r.Context(), req.Username, req.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		respond.Error(w, r, err)
 		return
 	}
 
-	// 生成JWT token
-	token, err := h.generateJWT(user)
-	if err != nil {
-		http.Error(w, "生成token失败", http.StatusInternalServerError)
-		return
-	}
-
-	response := dto.LoginResponse{
-		User:  dto.FromUserEntity(user),
-		Token: token,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	h.respondLogin(w, r, user, http.StatusCreated)
 }
 
 // Login 用户登录
 func (h *userHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req dto.UserLoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "无效的请求体: "+err.Error(), http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体: "+err.Error())
 		return
 	}
 
-	// 验证请求数据
-	if req.Username == "" || req.Password == "" {
-		http.Error(w, "用户名和密码不能为空", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
-	user, err := h.userService.Login(req.Username, req.Password)
+	user, err := h.userService.Login(r.Context(), req.Username, req.Password, clientIP(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		if writeThrottled(w, r, err) {
+			return
+		}
+		respond.Error(w, r, err)
 		return
 	}
 
-	// 生成JWT token
-	token, err := h.generateJWT(user)
-	if err != nil {
-		http.Error(w, "生成token失败", http.StatusInternalServerError)
-		return
-	}
-
-	response := dto.LoginResponse{
-		User:  dto.FromUserEntity(user),
-		Token: token,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	h.respondLogin(w, r, user, http.StatusOK)
 }
 
 // GetCurrentUser 获取当前用户信息
@@ -117,13 +110,13 @@ func (h *userHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	// 从上下文获取用户ID
 	userID, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w, "未授权访问", http.StatusUnauthorized)
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
 		return
 	}
 
 	user, err := h.userService.GetUserByID(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -137,13 +130,13 @@ func (h *userHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	// 检查用户角色
 	userRole, ok := r.Context().Value("userRole").(entity.Role)
 	if !ok || userRole != entity.RoleAdmin {
-		http.Error(w, "权限不足", http.StatusForbidden)
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
 		return
 	}
 
 	users, err := h.userService.GetAllUsers()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -157,7 +150,7 @@ func (h *userHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	// 检查权限：用户只能更新自己的信息，管理员可以更新任何用户
 	userID, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w, "未授权访问", http.StatusUnauthorized)
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
 		return
 	}
 
@@ -166,37 +159,42 @@ func (h *userHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	// 获取要更新的用户ID
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "无效的用户ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID")
 		return
 	}
 
 	targetID, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		http.Error(w, "无效的用户ID格式", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID格式")
 		return
 	}
 
 	// 普通用户只能更新自己的信息
 	if userRole != entity.RoleAdmin && userID != uint(targetID) {
-		http.Error(w, "权限不足", http.StatusForbidden)
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
 		return
 	}
 
 	var req dto.UserUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "无效的请求体", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
 	// 普通用户不能修改角色
 	if userRole != entity.RoleAdmin && req.Role != "" {
-		http.Error(w, "普通用户不能修改角色", http.StatusForbidden)
+		respond.Fail(w, r, http.StatusForbidden, "普通用户不能修改角色")
 		return
 	}
 
-	user, err := h.userService.UpdateUser(uint(targetID), req.Username, req.Password, entity.Role(req.Role))
+	user, err := h.userService.UpdateUser(r.Context(), uint(targetID), req.Username, req.Password, entity.Role(req.Role), req.Timezone)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -210,24 +208,24 @@ func (h *userHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	// 只有管理员可以删除用户
 	userRole, ok := r.Context().Value("userRole").(entity.Role)
 	if !ok || userRole != entity.RoleAdmin {
-		http.Error(w, "权限不足", http.StatusForbidden)
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
 		return
 	}
 
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "无效的用户ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID")
 		return
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		http.Error(w, "无效的用户ID格式", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID格式")
 		return
 	}
 
-	if err := h.userService.DeleteUser(uint(id)); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := h.userService.DeleteUser(r.Context(), uint(id)); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -239,27 +237,23 @@ func (h *userHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	// 用户只能修改自己的密码
 	userID, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w, "未授权访问", http.StatusUnauthorized)
+		respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
 		return
 	}
 
-	var req struct {
-		OldPassword string `json:"oldPassword"`
-		NewPassword string `json:"newPassword"`
-	}
-
+	var req dto.PasswordChangeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "无效的请求体", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
 		return
 	}
 
-	if req.OldPassword == "" || req.NewPassword == "" {
-		http.Error(w, "旧密码和新密码不能为空", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
-	if err := h.userService.ChangePassword(userID, req.OldPassword, req.NewPassword); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := h.userService.ChangePassword(r.Context(), userID, req.OldPassword, req.NewPassword); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -271,24 +265,24 @@ func (h *userHandler) PromoteToAdmin(w http.ResponseWriter, r *http.Request) {
 	// 只有管理员可以提升用户权限
 	userRole, ok := r.Context().Value("userRole").(entity.Role)
 	if !ok || userRole != entity.RoleAdmin {
-		http.Error(w, "权限不足", http.StatusForbidden)
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
 		return
 	}
 
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "无效的用户ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID")
 		return
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		http.Error(w, "无效的用户ID格式", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID格式")
 		return
 	}
 
-	if err := h.userService.PromoteToAdmin(uint(id)); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := h.userService.PromoteToAdmin(r.Context(), uint(id)); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
@@ -300,32 +294,196 @@ func (h *userHandler) DemoteToUser(w http.ResponseWriter, r *http.Request) {
 	// 只有管理员可以降级用户权限
 	userRole, ok := r.Context().Value("userRole").(entity.Role)
 	if !ok || userRole != entity.RoleAdmin {
-		http.Error(w, "权限不足", http.StatusForbidden)
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
 		return
 	}
 
 	idStr := r.PathValue("id")
 	if idStr == "" {
-		http.Error(w, "无效的用户ID", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID")
 		return
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		http.Error(w, "无效的用户ID格式", http.StatusBadRequest)
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID格式")
+		return
+	}
+
+	if err := h.userService.DemoteToUser(r.Context(), uint(id)); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlockUser 解除用户的登录锁定
+func (h *userHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	// 只有管理员可以解除锁定
+	userRole, ok := r.Context().Value("userRole").(entity.Role)
+	if !ok || userRole != entity.RoleAdmin {
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
+		return
+	}
+
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID格式")
+		return
+	}
+
+	if err := h.userService.UnlockUser(r.Context(), uint(id)); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangeExpiredPassword 被要求修改密码的用户在登录前修改密码，成功后直接登录
+func (h *userHandler) ChangeExpiredPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.ExpiredPasswordChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	user, err := h.userService.ChangeExpiredPassword(r.Context(), req.Username, req.OldPassword, req.NewPassword, clientIP(r))
+	if err != nil {
+		if writeThrottled(w, r, err) {
+			return
+		}
+		respond.Error(w, r, err)
+		return
+	}
+
+	h.respondLogin(w, r, user, http.StatusOK)
+}
+
+// ResetPassword 使用一次性重置令牌修改密码
+func (h *userHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的请求体")
 		return
 	}
 
-	if err := h.userService.DemoteToUser(uint(id)); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	if err := h.userService.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// IssuePasswordResetToken 为用户签发密码重置令牌
+func (h *userHandler) IssuePasswordResetToken(w http.ResponseWriter, r *http.Request) {
+	// 只有管理员可以签发重置令牌
+	userRole, ok := r.Context().Value("userRole").(entity.Role)
+	if !ok || userRole != entity.RoleAdmin {
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
+		return
+	}
+	adminID, _ := r.Context().Value("userID").(uint)
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID格式")
+		return
+	}
+
+	token, expiresAt, err := h.userService.IssuePasswordResetToken(r.Context(), uint(id), adminID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	response := dto.PasswordResetTokenResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ForcePasswordChange 要求用户下次登录时修改密码
+func (h *userHandler) ForcePasswordChange(w http.ResponseWriter, r *http.Request) {
+	// 只有管理员可以要求用户修改密码
+	userRole, ok := r.Context().Value("userRole").(entity.Role)
+	if !ok || userRole != entity.RoleAdmin {
+		respond.Fail(w, r, http.StatusForbidden, "权限不足")
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		respond.Fail(w, r, http.StatusBadRequest, "无效的用户ID格式")
+		return
+	}
+
+	if err := h.userService.ForcePasswordChange(r.Context(), uint(id)); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeThrottled 登录被限制时返回429及Retry-After，返回是否已处理
+func writeThrottled(w http.ResponseWriter, r *http.Request, err error) bool {
+	var throttled *entity.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	retryAfter := int(time.Until(throttled.Until).Seconds()) + 1
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	respond.Fail(w, r, http.StatusTooManyRequests, throttled.Error())
+	return true
+}
+
+// userLocation 获取当前用户的时区，未设置时使用UTC
+func userLocation(r *http.Request) *time.Location {
+	if loc, ok := r.Context().Value("userLocation").(*time.Location); ok {
+		return loc
+	}
+	return time.UTC
+}
+
+// clientIP 获取请求的客户端IP（不信任X-Forwarded-For，避免伪造绕过限制）
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // generateJWT 生成JWT token
 func (h *userHandler) generateJWT(user *entity.User) (string, error) {
+	return signUserToken(h.jwtSecret, user)
+}
+
+// signUserToken 为用户签发访问令牌
+func signUserToken(jwtSecret string, user *entity.User) (string, error) {
 	claims := jwt.MapClaims{
 		"userID": user.ID,
 		"role":   string(user.Role),
@@ -333,7 +491,7 @@ func (h *userHandler) generateJWT(user *entity.User) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(h.jwtSecret))
+	return token.SignedString([]byte(jwtSecret))
 }
 
 // RegisterRoutes 注册用户相关路由
@@ -342,18 +500,34 @@ func (h *userHandler) RegisterRoutes(r router.Router) {
 	public := r.Group("/api/auth")
 	public.POST("/register", h.Register)
 	public.POST("/login", h.Login)
+	public.POST("/change-password", h.ChangeExpiredPassword)
+	public.POST("/reset-password", h.ResetPassword)
+	public.POST("/2fa/verify", h.VerifyTwoFactor)
+	public.POST("/2fa/enroll", h.ChallengeEnrollTOTP)
+	public.POST("/2fa/enroll/confirm", h.ChallengeConfirmTOTP)
 
 	// 受保护的路由（需要认证）
 	protected := r.Group("/api/users")
-	
+	protected.Use(middleware.RequireAuth(h.jwtSecret))
+
 	// 个人操作路由
 	protected.GET("/me", h.GetCurrentUser)
 	protected.PUT("/password", h.ChangePassword)
-	protected.PUT("/:id", h.UpdateUser)
+	protected.PUT("/{id}", h.UpdateUser)
+	protected.POST("/me/2fa/enroll", h.EnrollTOTP)
+	protected.POST("/me/2fa/confirm", h.ConfirmTOTP)
+	protected.POST("/me/2fa/disable", h.DisableTOTP)
+	protected.POST("/me/2fa/recovery-codes", h.RegenerateRecoveryCodes)
 
 	// 管理员操作路由
 	protected.GET("", h.GetAllUsers)
-	protected.DELETE("/:id", h.DeleteUser)
-	protected.POST("/:id/promote", h.PromoteToAdmin)
-	protected.POST("/:id/demote", h.DemoteToUser)
+	protected.DELETE("/{id}", h.DeleteUser)
+	protected.POST("/{id}/promote", h.PromoteToAdmin)
+	protected.POST("/{id}/demote", h.DemoteToUser)
+	protected.POST("/{id}/unlock", h.UnlockUser)
+	protected.POST("/{id}/reset-token", h.IssuePasswordResetToken)
+	protected.POST("/{id}/force-password-change", h.ForcePasswordChange)
+	protected.POST("/{id}/2fa/reset", h.ResetTOTP)
+	protected.GET("/2fa-policy", h.GetTwoFactorPolicy)
+	protected.PUT("/2fa-policy", h.SetTwoFactorPolicy)
 }
\ No newline at end of file
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"brb/internal/respond"
+	"brb/internal/router"
+	"brb/internal/service"
+)
+
+func TestLoginThrottled(t *testing.T) {
+	// 退避时长远长于测试耗时，避免bcrypt较慢时（如-race）退避已经结束
+	policy := service.DefaultLoginPolicy()
+	policy.BackoffBase = time.Hour
+	_, userService := newTestUserServiceWithPolicy(t, policy)
+	if _, err := userService.Register(t.Context(), "alice", "secret-pass-1"); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewUserHandler(userService, testJWTSecret).RegisterRoutes(router.NewStandardRouter(mux))
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body := `{"username":"alice","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return serve(mux, req)
+	}
+
+	// 连续失败BackoffThreshold次后进入退避
+	for i := 0; i < policy.BackoffThreshold; i++ {
+		if rec := login("wrong-pass"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i+1, rec.Code)
+		}
+	}
+
+	// 退避期间即使密码正确也返回429
+	rec := login("secret-pass-1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429: %s", rec.Code, rec.Body)
+	}
+	if retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retryAfter <= 0 {
+		t.Fatalf("Retry-After = %q", rec.Header().Get("Retry-After"))
+	}
+	var body respond.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != "too_many_requests" {
+		t.Fatalf("error code = %q, want too_many_requests", body.Error.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"brb/internal/entity"
+	"brb/internal/repo"
+	"brb/internal/service"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testJWTSecret = "test-secret"
+
+// testUserService 测试中需要直接调用的用户服务方法
+type testUserService interface {
+	UserService
+	SetTwoFactorRequired(ctx context.Context, role entity.Role, required bool) error
+}
+
+// newTestUserService 在临时数据库上创建使用默认登录策略的用户服务
+func newTestUserService(t *testing.T) (*sql.DB, testUserService) {
+	t.Helper()
+	return newTestUserServiceWithPolicy(t, service.DefaultLoginPolicy())
+}
+
+// newTestUserServiceWithPolicy 在临时数据库上创建使用指定登录策略的用户服务
+func newTestUserServiceWithPolicy(t *testing.T, loginPolicy service.LoginPolicy) (*sql.DB, testUserService) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	userRepo, err1 := repo.NewUserRepo(db)
+	attemptRepo, err2 := repo.NewLoginAttemptRepo(db)
+	resetRepo, err3 := repo.NewPasswordResetRepo(db)
+	recoveryRepo, err4 := repo.NewRecoveryCodeRepo(db)
+	settingRepo, err5 := repo.NewSettingRepo(db)
+	for _, err := range []error{err1, err2, err3, err4, err5} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, service.NewUserService(userRepo, attemptRepo, resetRepo, recoveryRepo, settingRepo, loginPolicy, service.DefaultPasswordPolicy())
+}
+
+// serve 用mux处理请求并返回响应
+func serve(mux http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"brb/pkg/metrics"
+)
+
+// HTTPMetrics 记录HTTP请求数和延迟
+type HTTPMetrics struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+// NewHTTPMetrics 创建HTTP指标并注册到reg
+func NewHTTPMetrics(reg *metrics.Registry) *HTTPMetrics {
+	m := &HTTPMetrics{
+		requests: metrics.NewCounterVec("brb_http_requests_total", "HTTP请求总数", "method", "route", "status"),
+		duration: metrics.NewHistogramVec("brb_http_request_duration_seconds", "HTTP请求处理耗时（秒）", metrics.DefBuckets, "method", "route"),
+	}
+	reg.Register(m.requests)
+	reg.Register(m.duration)
+	return m
+}
+
+// Middleware 记录指标的中间件，route取ServeMux匹配到的路由模式（如/v1/api/todos/{id}），避免标签基数随路径参数增长
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		m.requests.Inc(r.Method, route, strconv.Itoa(rec.Status()))
+		m.duration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
@@ -3,11 +3,14 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
 	"strings"
 	"time"
 
 	"brb/internal/entity"
+	"brb/internal/respond"
 	"brb/pkg/logger"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -16,11 +19,41 @@ import (
 type Middleware func(http.Handler) http.Handler
 
 // LoggingMiddleware 记录请求日志的中间件
+// 为每个请求分配ID（沿用合法的X-Request-ID请求头），写入响应头和该请求的所有日志
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		logger.Info.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		info := &requestInfo{}
+		ctx := logger.WithRequestID(r.Context(), requestID)
+		ctx = context.WithValue(ctx, requestInfoKey{}, info)
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.Status()),
+			slog.Int64("size", rec.size),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote", r.RemoteAddr),
+		}
+		if info.userID != 0 {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(info.userID)))
+		}
+
+		level := slog.LevelInfo
+		if rec.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "request", attrs...)
 	})
 }
 
@@ -31,14 +64,14 @@ func AuthMiddleware(jwtSecret string) Middleware {
 			// 获取Authorization头
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "缺少认证令牌", http.StatusUnauthorized)
+				respond.Fail(w, r, http.StatusUnauthorized, "缺少认证令牌")
 				return
 			}
 
 			// 检查Bearer token格式
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "认证令牌格式错误", http.StatusUnauthorized)
+				respond.Fail(w, r, http.StatusUnauthorized, "认证令牌格式错误")
 				return
 			}
 
@@ -54,32 +87,39 @@ func AuthMiddleware(jwtSecret string) Middleware {
 			})
 
 			if err != nil {
-				http.Error(w, "无效的认证令牌", http.StatusUnauthorized)
+				respond.Fail(w, r, http.StatusUnauthorized, "无效的认证令牌")
 				return
 			}
 
 			if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+				// 带purpose声明的是两步验证等流程中的临时令牌，不能用于访问接口
+				if _, ok := claims["purpose"]; ok {
+					respond.Fail(w, r, http.StatusUnauthorized, "无效的认证令牌")
+					return
+				}
+
 				// 提取用户信息
 				userID, ok := claims["userID"].(float64)
 				if !ok {
-					http.Error(w, "令牌中缺少用户ID", http.StatusUnauthorized)
+					respond.Fail(w, r, http.StatusUnauthorized, "令牌中缺少用户ID")
 					return
 				}
 
 				role, ok := claims["role"].(string)
 				if !ok {
-					http.Error(w, "令牌中缺少用户角色", http.StatusUnauthorized)
+					respond.Fail(w, r, http.StatusUnauthorized, "令牌中缺少用户角色")
 					return
 				}
 
 				// 将用户信息添加到请求上下文
+				setRequestUser(r.Context(), uint(userID))
 				ctx := context.WithValue(r.Context(), "userID", uint(userID))
 				ctx = context.WithValue(ctx, "userRole", entity.Role(role))
 				r = r.WithContext(ctx)
 
 				next.ServeHTTP(w, r)
 			} else {
-				http.Error(w, "无效的认证令牌", http.StatusUnauthorized)
+				respond.Fail(w, r, http.StatusUnauthorized, "无效的认证令牌")
 			}
 		})
 	}
@@ -97,13 +137,13 @@ func RequireRole(requiredRole entity.Role) Middleware {
 			// 从上下文中获取用户角色
 			userRole, ok := r.Context().Value("userRole").(entity.Role)
 			if !ok {
-				http.Error(w, "未授权访问", http.StatusUnauthorized)
+				respond.Fail(w, r, http.StatusUnauthorized, "未授权访问")
 				return
 			}
 
 			// 检查用户角色是否满足要求
 			if userRole != requiredRole {
-				http.Error(w, "权限不足", http.StatusForbidden)
+				respond.Fail(w, r, http.StatusForbidden, "权限不足")
 				return
 			}
 
@@ -136,8 +176,9 @@ func OptionalAuth(jwtSecret string) Middleware {
 					})
 
 					if err == nil && token.Valid {
-						if claims, ok := token.Claims.(jwt.MapClaims); ok {
+						if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["purpose"] == nil {
 							if userID, ok := claims["userID"].(float64); ok {
+								setRequestUser(r.Context(), uint(userID))
 								ctx := context.WithValue(r.Context(), "userID", uint(userID))
 								if role, ok := claims["role"].(string); ok {
 									ctx = context.WithValue(ctx, "userRole", entity.Role(role))
@@ -154,13 +195,31 @@ func OptionalAuth(jwtSecret string) Middleware {
 	}
 }
 
+// UserLocation 将认证用户的时区放入请求上下文（userLocation），须放在认证中间件之后
+// 查询失败时不设置，处理器回退到UTC
+func UserLocation(lookup func(userID uint) (*time.Location, error)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if userID, ok := r.Context().Value("userID").(uint); ok {
+				loc, err := lookup(userID)
+				if err != nil {
+					slog.WarnContext(r.Context(), "failed to load user timezone", "error", err)
+				} else {
+					r = r.WithContext(context.WithValue(r.Context(), "userLocation", loc))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // RecoveryMiddleware 恢复panic的中间件
 func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				logger.Error.Printf("Panic: %v\n", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				slog.ErrorContext(r.Context(), "panic", "error", err, "stack", string(debug.Stack()))
+				respond.Fail(w, r, http.StatusInternalServerError, "服务器内部错误")
 			}
 		}()
 		next.ServeHTTP(w, r)
@@ -0,0 +1,148 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+type assignmentRepo struct {
+	base *BaseRepo[entity.Assignment]
+}
+
+// NewAssignmentRepo 创建新的指派记录Repository
+func NewAssignmentRepo(db *sql.DB) (*assignmentRepo, error) {
+	// 初始化数据库表
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS assignments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			target_type TEXT NOT NULL,
+			target_id INTEGER NOT NULL,
+			from_user_id INTEGER,
+			to_user_id INTEGER,
+			assigned_by_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create assignments table: %w", err)
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_assignments_target ON assignments (target_type, target_id)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create assignments index: %w", err)
+	}
+
+	baseRepo := NewBaseRepo[entity.Assignment](db, "assignments")
+	return &assignmentRepo{base: baseRepo}, nil
+}
+
+// assignmentTables 指派目标对应的表
+var assignmentTables = map[entity.AssignmentTarget]string{
+	entity.AssignmentTargetTask: "tasks",
+	entity.AssignmentTargetTodo: "todos",
+}
+
+// Assign 在一个事务中记录指派变更并更新task或todo的负责人，返回是否发生变更。
+// 原负责人在写事务中读取并写入assignment.FromUserID，负责人未变化时不做修改
+func (r *assignmentRepo) Assign(assignment *entity.Assignment) (bool, error) {
+	table, ok := assignmentTables[assignment.TargetType]
+	if !ok {
+		return false, fmt.Errorf("unsupported assignment target: %s", assignment.TargetType)
+	}
+
+	tx, err := r.base.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 先写历史记录：INSERT ... SELECT在写锁下读取当前负责人，并发的指派不会记录过时的原负责人
+	var (
+		id         int64
+		fromUserID sql.NullInt64
+	)
+	err = tx.QueryRow(`INSERT INTO assignments (target_type, target_id, from_user_id, to_user_id, assigned_by_id, created_at)
+		SELECT ?, id, assignee_id, ?, ?, ? FROM `+table+` WHERE id = ? AND assignee_id IS NOT ?
+		RETURNING id, from_user_id`,
+		string(assignment.TargetType), assignment.ToUserID, assignment.AssignedByID, assignment.CreatedAt.UTC(),
+		assignment.TargetID, assignment.ToUserID,
+	).Scan(&id, &fromUserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = ?)", assignment.TargetID).Scan(&exists); err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, errs.NotFound("%s不存在", assignment.TargetType)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record assignment history: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE "+table+" SET assignee_id = ? WHERE id = ?", assignment.ToUserID, assignment.TargetID); err != nil {
+		return false, fmt.Errorf("failed to update %s assignee: %w", assignment.TargetType, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	assignment.ID = uint(id)
+	assignment.FromUserID = nil
+	if fromUserID.Valid {
+		from := uint(fromUserID.Int64)
+		assignment.FromUserID = &from
+	}
+	return true, nil
+}
+
+// GetByTarget 获取某个task或todo的指派历史，按写入的先后排序（与变更生效的顺序一致）
+func (r *assignmentRepo) GetByTarget(targetType entity.AssignmentTarget, targetID uint) ([]*entity.Assignment, error) {
+	query := `SELECT id, target_type, target_id, from_user_id, to_user_id, assigned_by_id, created_at
+		FROM assignments WHERE target_type = ? AND target_id = ? ORDER BY id`
+	rows, err := r.base.db.Query(query, string(targetType), targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []*entity.Assignment
+	for rows.Next() {
+		var (
+			assignment entity.Assignment
+			targetType string
+			fromUserID sql.NullInt64
+			toUserID   sql.NullInt64
+			createdAt  sql.NullTime
+		)
+		err := rows.Scan(&assignment.ID, &targetType, &assignment.TargetID, &fromUserID, &toUserID, &assignment.AssignedByID, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+
+		assignment.TargetType = entity.AssignmentTarget(targetType)
+		if fromUserID.Valid {
+			from := uint(fromUserID.Int64)
+			assignment.FromUserID = &from
+		}
+		if toUserID.Valid {
+			to := uint(toUserID.Int64)
+			assignment.ToUserID = &to
+		}
+		if createdAt.Valid {
+			assignment.CreatedAt = createdAt.Time
+		}
+		assignments = append(assignments, &assignment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return assignments, nil
+}
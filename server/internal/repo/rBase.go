@@ -2,9 +2,15 @@ package repo
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
+
+	"brb/internal/errs"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 // BaseRepo 提供基础的ORM风格CRUD操作，使用泛型提高类型安全性
@@ -23,6 +29,16 @@ func NewBaseRepo[T any](db *sql.DB, tableName string) *BaseRepo[T] {
 
 // Create 插入新记录
 func (r *BaseRepo[T]) Create(fields map[string]interface{}) (sql.Result, error) {
+	return insertRow(r.db, r.tableName, fields)
+}
+
+// execer *sql.DB或*sql.Tx，使插入语句可在事务中复用
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertRow 按字段映射插入一行
+func insertRow(db execer, tableName string, fields map[string]any) (sql.Result, error) {
 	if len(fields) == 0 {
 		return nil, fmt.Errorf("no fields to insert")
 	}
@@ -34,17 +50,17 @@ func (r *BaseRepo[T]) Create(fields map[string]interface{}) (sql.Result, error)
 	for column, value := range fields {
 		columns = append(columns, column)
 		placeholders = append(placeholders, "?")
-		values = append(values, value)
+		values = append(values, dbValue(value))
 	}
 
 	query := fmt.Sprintf(
 		"INSERT INTO %s (%s) VALUES (%s)",
-		r.tableName,
+		tableName,
 		strings.Join(columns, ", "),
 		strings.Join(placeholders, ", "),
 	)
 
-	return r.db.Exec(query, values...)
+	return db.Exec(query, values...)
 }
 
 // Update 更新记录
@@ -58,7 +74,7 @@ func (r *BaseRepo[T]) Update(id any, fields map[string]interface{}) error {
 
 	for column, value := range fields {
 		setClauses = append(setClauses, fmt.Sprintf("%s = ?", column))
-		values = append(values, value)
+		values = append(values, dbValue(value))
 	}
 	values = append(values, id)
 
@@ -68,15 +84,47 @@ func (r *BaseRepo[T]) Update(id any, fields map[string]interface{}) error {
 		strings.Join(setClauses, ", "),
 	)
 
-	_, err := r.db.Exec(query, values...)
-	return err
+	result, err := r.db.Exec(query, values...)
+	if err != nil {
+		return err
+	}
+	return r.checkAffected(result, id)
 }
 
 // Delete 删除记录
 func (r *BaseRepo[T]) Delete(id interface{}) error {
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?", r.tableName)
-	_, err := r.db.Exec(query, id)
-	return err
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	return r.checkAffected(result, id)
+}
+
+// dbValue 时间统一转换为UTC再写入，避免同一列中混入不同时区偏移
+func dbValue(value any) any {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC()
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return v.UTC()
+	}
+	return value
+}
+
+// checkAffected 按ID更新或删除时没有命中任何记录则返回NotFound
+func (r *BaseRepo[T]) checkAffected(result sql.Result, id any) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errs.NotFound("%s中不存在id为%v的记录", r.tableName, id)
+	}
+	return nil
 }
 
 // FindByID 根据ID查询记录
@@ -88,7 +136,7 @@ func (r *BaseRepo[T]) FindByID(id interface{}) (*T, error) {
 	err := scanRow(row, &entity)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("record not found")
+			return nil, errs.NotFound("记录不存在")
 		}
 		return nil, err
 	}
@@ -182,6 +230,50 @@ func (r *BaseRepo[T]) FindWhereWithFields(conditions map[string]any, fields []st
 	return entities, nil
 }
 
+// ensureColumn 为已存在的表补充新增的列（CREATE TABLE IF NOT EXISTS 不会修改旧表结构）
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read %s schema: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+			return fmt.Errorf("failed to scan %s schema: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// isUniqueViolation 是否为违反唯一约束的错误，column形如"表.列"
+func isUniqueViolation(err error, column string) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique &&
+		strings.Contains(sqliteErr.Error(), column)
+}
+
 // scanRow 辅助函数，用于扫描单行数据到结构体
 func scanRow(row *sql.Row, dest any) error {
 	return row.Scan(getFieldPointers(dest)...)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"brb/internal/entity"
+	"brb/internal/errs"
 )
 
 type eventRepo struct {
@@ -21,7 +22,7 @@ func NewEventRepo(db *sql.DB) (*eventRepo, error) {
 			description TEXT,
 			location TEXT,
 			priority INTEGER,
-			category TEXT
+			category TEXT -- 已弃用，由NewTagRepo转换为标签
 		)
 	`)
 	if err != nil {
@@ -32,55 +33,134 @@ func NewEventRepo(db *sql.DB) (*eventRepo, error) {
 	return &eventRepo{base: baseRepo}, nil
 }
 
-// Create 创建新的event记录
+// eventColumns 查询event时使用的列顺序，与queryEvents保持一致
+const eventColumns = "id, isTemplate, title, COALESCE(description, ''), COALESCE(location, ''), COALESCE(priority, 0)"
+
+// Create 创建新的event记录及其标签，Tags更新为标签的已有写法
+// Category不再存储，作为同名标签写入
 func (r *eventRepo) Create(event *entity.Event) error {
-	fields := map[string]any{
-		"isTemplate":  event.IsTemplate,
-		"title":       event.Title,
-		"description": event.Description,
-		"location":    event.Location,
-		"priority":    event.Priority,
-		"category":    event.Category,
+	tx, err := r.base.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// ID为0时由数据库自动生成
+	result, err := tx.Exec("INSERT INTO events (id, isTemplate, title, description, location, priority) VALUES (?, ?, ?, ?, ?, ?)",
+		nullableID(event.ID), event.IsTemplate, event.Title, event.Description, event.Location, event.Priority)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	tags, err := setEventTags(tx, uint(id), withCategory(event.Tags, event.Category))
+	if err != nil {
+		return err
 	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	event.ID = uint(id)
+	event.Category = ""
+	event.Tags = tags
+	return nil
+}
 
-	// If ID is set (for updates), include it, otherwise it will be auto-generated
-	if event.ID != 0 {
-		fields["id"] = event.ID
+// queryEvents 执行只查询eventColumns的语句，并加载结果的标签
+func (r *eventRepo) queryEvents(query string, args ...any) ([]*entity.Event, error) {
+	rows, err := r.base.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*entity.Event{}
+	for rows.Next() {
+		var event entity.Event
+		err := rows.Scan(&event.ID, &event.IsTemplate, &event.Title, &event.Description, &event.Location, &event.Priority)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
+	rows.Close()
 
-	_, err := r.base.Create(fields)
-	return err
+	if err := loadEventTags(r.base.db, events); err != nil {
+		return nil, err
+	}
+	return events, nil
 }
 
 // GetAll 获取所有event
 func (r *eventRepo) GetAll() ([]*entity.Event, error) {
-	return r.base.FindAll()
+	return r.queryEvents("SELECT " + eventColumns + " FROM events")
+}
+
+// GetBySignID 获取关联了指定sign的event
+func (r *eventRepo) GetBySignID(signID int64) ([]*entity.Event, error) {
+	return r.queryEvents("SELECT "+eventColumns+" FROM events WHERE id IN ("+signLinkedIDs+")", string(entity.SignTargetEvent), signID)
+}
+
+// GetByTags 获取带有指定标签的event
+func (r *eventRepo) GetByTags(f entity.TagFilter) ([]*entity.Event, error) {
+	subquery, args := tagFilterIDs(f)
+	return r.queryEvents("SELECT "+eventColumns+" FROM events WHERE id IN ("+subquery+")", args...)
 }
 
 // GetByID 根据ID获取event
 func (r *eventRepo) GetByID(id uint) (*entity.Event, error) {
-	event, err := r.base.FindByID(id)
+	events, err := r.queryEvents("SELECT "+eventColumns+" FROM events WHERE id = ?", id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("event not found")
-		}
 		return nil, err
 	}
-	return event, nil
+	if len(events) == 0 {
+		return nil, errs.NotFound("event不存在")
+	}
+	return events[0], nil
 }
 
-// Update 更新event记录
+// Update 更新event记录，Tags不为nil时替换全部标签，Tags更新为当前标签
+// Category不为空时追加同名标签
 func (r *eventRepo) Update(event *entity.Event) error {
-	fields := map[string]any{
-		"isTemplate":  event.IsTemplate,
-		"title":       event.Title,
-		"description": event.Description,
-		"location":    event.Location,
-		"priority":    event.Priority,
-		"category":    event.Category,
+	tx, err := r.base.db.Begin()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	return r.base.Update(event.ID, fields)
+	result, err := tx.Exec("UPDATE events SET isTemplate = ?, title = ?, description = ?, location = ?, priority = ? WHERE id = ?",
+		event.IsTemplate, event.Title, event.Description, event.Location, event.Priority, event.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.base.checkAffected(result, event.ID); err != nil {
+		return err
+	}
+	switch {
+	case event.Tags != nil:
+		tags, err := setEventTags(tx, event.ID, withCategory(event.Tags, event.Category))
+		if err != nil {
+			return err
+		}
+		event.Tags = tags
+	case event.Category != "":
+		if err := addEventTag(tx, event.ID, event.Category); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	event.Category = ""
+	if event.Tags == nil {
+		return loadEventTags(r.base.db, []*entity.Event{event})
+	}
+	return nil
 }
 
 // Delete 删除event记录
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+type identityRepo struct {
+	base *BaseRepo[entity.ExternalIdentity]
+}
+
+// NewIdentityRepo 创建新的外部身份关联Repository
+func NewIdentityRepo(db *sql.DB) (*identityRepo, error) {
+	// 初始化数据库表
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_identities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			issuer TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (issuer, subject)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user_identities table: %w", err)
+	}
+
+	baseRepo := NewBaseRepo[entity.ExternalIdentity](db, "user_identities")
+	return &identityRepo{base: baseRepo}, nil
+}
+
+// Create 创建外部身份关联
+func (r *identityRepo) Create(identity *entity.ExternalIdentity) error {
+	fields := map[string]any{
+		"user_id":    identity.UserID,
+		"issuer":     identity.Issuer,
+		"subject":    identity.Subject,
+		"created_at": identity.CreatedAt,
+	}
+
+	result, err := r.base.Create(fields)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	identity.ID = uint(id)
+	return nil
+}
+
+// GetBySubject 根据issuer和sub获取外部身份关联
+func (r *identityRepo) GetBySubject(issuer, subject string) (*entity.ExternalIdentity, error) {
+	query := "SELECT id, user_id, issuer, subject, created_at FROM user_identities WHERE issuer = ? AND subject = ?"
+
+	var (
+		identity  entity.ExternalIdentity
+		createdAt sql.NullTime
+	)
+	err := r.base.db.QueryRow(query, issuer, subject).Scan(&identity.ID, &identity.UserID, &identity.Issuer, &identity.Subject, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NotFound("外部身份不存在")
+		}
+		return nil, fmt.Errorf("failed to scan identity: %w", err)
+	}
+
+	if createdAt.Valid {
+		identity.CreatedAt = createdAt.Time
+	}
+	return &identity, nil
+}
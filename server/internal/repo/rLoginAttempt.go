@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"brb/internal/entity"
+)
+
+type loginAttemptRepo struct {
+	base *BaseRepo[entity.LoginAttempt]
+}
+
+// NewLoginAttemptRepo 创建新的登录失败记录Repository
+func NewLoginAttemptRepo(db *sql.DB) (*loginAttemptRepo, error) {
+	// 初始化数据库表，持久化以便重启后锁定状态仍然有效
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS login_attempts (
+			scope TEXT NOT NULL,
+			key TEXT NOT NULL,
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_failure_at DATETIME,
+			locked_until DATETIME,
+			last_ip TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (scope, key)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create login_attempts table: %w", err)
+	}
+	if err := ensureColumn(db, "login_attempts", "last_ip", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, err
+	}
+
+	baseRepo := NewBaseRepo[entity.LoginAttempt](db, "login_attempts")
+	return &loginAttemptRepo{base: baseRepo}, nil
+}
+
+// Get 获取登录失败记录，不存在时返回失败次数为0的空记录
+func (r *loginAttemptRepo) Get(scope entity.LoginAttemptScope, key string) (*entity.LoginAttempt, error) {
+	query := "SELECT failures, last_failure_at, locked_until, last_ip FROM login_attempts WHERE scope = ? AND key = ?"
+
+	var (
+		failures      int
+		lastFailureAt sql.NullTime
+		lockedUntil   sql.NullTime
+	)
+	attempt := &entity.LoginAttempt{Scope: scope, Key: key}
+
+	err := r.base.db.QueryRow(query, string(scope), key).Scan(&failures, &lastFailureAt, &lockedUntil, &attempt.LastIP)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return attempt, nil
+		}
+		return nil, fmt.Errorf("failed to scan login attempt: %w", err)
+	}
+
+	attempt.Failures = failures
+	if lastFailureAt.Valid {
+		attempt.LastFailureAt = &lastFailureAt.Time
+	}
+	if lockedUntil.Valid {
+		attempt.LockedUntil = &lockedUntil.Time
+	}
+	return attempt, nil
+}
+
+// RecordFailure 原子地累加一次登录失败并返回累加后的记录，并发的失败不会相互覆盖；
+// 未处于锁定期且最近一次失败早于resetBefore时从1重新计数。lastIP为空时保留原值
+func (r *loginAttemptRepo) RecordFailure(scope entity.LoginAttemptScope, key, lastIP string, now, resetBefore time.Time) (*entity.LoginAttempt, error) {
+	query := `INSERT INTO login_attempts (scope, key, failures, last_failure_at, last_ip)
+		VALUES (?, ?, 1, ?, ?)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN (locked_until IS NULL OR julianday(locked_until) <= julianday(excluded.last_failure_at))
+					AND julianday(last_failure_at) < julianday(?) THEN 1
+				ELSE failures + 1
+			END,
+			last_failure_at = excluded.last_failure_at,
+			last_ip = CASE WHEN excluded.last_ip = '' THEN last_ip ELSE excluded.last_ip END
+		RETURNING failures, last_failure_at, locked_until, last_ip`
+
+	var (
+		lastFailureAt sql.NullTime
+		lockedUntil   sql.NullTime
+	)
+	attempt := &entity.LoginAttempt{Scope: scope, Key: key}
+	err := r.base.db.QueryRow(query, string(scope), key, now.UTC(), lastIP, resetBefore.UTC()).
+		Scan(&attempt.Failures, &lastFailureAt, &lockedUntil, &attempt.LastIP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	if lastFailureAt.Valid {
+		attempt.LastFailureAt = &lastFailureAt.Time
+	}
+	if lockedUntil.Valid {
+		attempt.LockedUntil = &lockedUntil.Time
+	}
+	return attempt, nil
+}
+
+// Lock 将记录锁定到指定时间
+func (r *loginAttemptRepo) Lock(scope entity.LoginAttemptScope, key string, until time.Time) error {
+	query := "UPDATE login_attempts SET locked_until = ? WHERE scope = ? AND key = ?"
+	_, err := r.base.db.Exec(query, until.UTC(), string(scope), key)
+	return err
+}
+
+// Delete 清除登录失败记录
+func (r *loginAttemptRepo) Delete(scope entity.LoginAttemptScope, key string) error {
+	query := "DELETE FROM login_attempts WHERE scope = ? AND key = ?"
+	_, err := r.base.db.Exec(query, string(scope), key)
+	return err
+}
@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+type ontonRepo struct {
+	base *BaseRepo[entity.Onton]
+}
+
+// NewOntonRepo 创建onton的Repository，须在signs表创建之后调用
+// 删除onton或sign时由触发器清理两者的关联
+func NewOntonRepo(db *sql.DB) (*ontonRepo, error) {
+	// 初始化数据库表
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ontons (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			adic INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ontons table: %w", err)
+	}
+
+	// onton与指称它的sign之间的多对多关联
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS onton_signs (
+			onton_id INTEGER NOT NULL,
+			sign_id INTEGER NOT NULL,
+			PRIMARY KEY (onton_id, sign_id)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create onton_signs table: %w", err)
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_onton_signs_sign ON onton_signs (sign_id)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create onton_signs index: %w", err)
+	}
+
+	for _, trigger := range []string{
+		`CREATE TRIGGER IF NOT EXISTS onton_signs_on_onton_delete AFTER DELETE ON ontons
+		BEGIN
+			DELETE FROM onton_signs WHERE onton_id = OLD.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS onton_signs_on_sign_delete AFTER DELETE ON signs
+		BEGIN
+			DELETE FROM onton_signs WHERE sign_id = OLD.id;
+		END`,
+	} {
+		if _, err := db.Exec(trigger); err != nil {
+			return nil, fmt.Errorf("failed to create onton_signs trigger: %w", err)
+		}
+	}
+
+	baseRepo := NewBaseRepo[entity.Onton](db, "ontons")
+	return &ontonRepo{base: baseRepo}, nil
+}
+
+// Create 创建新的onton记录
+func (r *ontonRepo) Create(onton *entity.Onton) error {
+	result, err := r.base.Create(map[string]any{"adic": onton.Adic})
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	onton.ID = id
+	return nil
+}
+
+// GetAll 获取所有onton
+func (r *ontonRepo) GetAll() ([]*entity.Onton, error) {
+	return r.base.FindAll()
+}
+
+// GetByID 根据ID获取onton
+func (r *ontonRepo) GetByID(id int64) (*entity.Onton, error) {
+	onton, err := r.base.FindByID(id)
+	if err != nil {
+		if errs.IsNotFound(err) {
+			return nil, errs.NotFound("onton不存在")
+		}
+		return nil, err
+	}
+	return onton, nil
+}
+
+// Update 更新onton记录
+func (r *ontonRepo) Update(onton *entity.Onton) error {
+	return r.base.Update(onton.ID, map[string]any{"adic": onton.Adic})
+}
+
+// Delete 删除onton记录
+func (r *ontonRepo) Delete(id int64) error {
+	return r.base.Delete(id)
+}
+
+// LinkSign 记录sign指称onton，已关联时不做任何事
+func (r *ontonRepo) LinkSign(ontonID, signID int64) error {
+	_, err := r.base.db.Exec("INSERT OR IGNORE INTO onton_signs (onton_id, sign_id) VALUES (?, ?)", ontonID, signID)
+	if err != nil {
+		return fmt.Errorf("failed to link onton sign: %w", err)
+	}
+	return nil
+}
+
+// UnlinkSign 取消sign与onton的关联，未关联时不做任何事
+func (r *ontonRepo) UnlinkSign(ontonID, signID int64) error {
+	_, err := r.base.db.Exec("DELETE FROM onton_signs WHERE onton_id = ? AND sign_id = ?", ontonID, signID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink onton sign: %w", err)
+	}
+	return nil
+}
+
+// GetSigns 获取指称onton的sign，按能指排序
+func (r *ontonRepo) GetSigns(ontonID int64) ([]*entity.Sign, error) {
+	query := `SELECT ` + signColumns + ` FROM signs s
+		JOIN onton_signs o ON o.sign_id = s.id
+		WHERE o.onton_id = ?
+		ORDER BY s.signifier, s.id`
+	signs, err := querySigns(r.base.db, query, ontonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query onton signs: %w", err)
+	}
+	return signs, nil
+}
+
+// GetBySignID 获取sign指称的onton
+func (r *ontonRepo) GetBySignID(signID int64) ([]*entity.Onton, error) {
+	rows, err := r.base.db.Query("SELECT id, adic FROM ontons WHERE id IN (SELECT onton_id FROM onton_signs WHERE sign_id = ?) ORDER BY id", signID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ontons: %w", err)
+	}
+	defer rows.Close()
+
+	ontons := []*entity.Onton{}
+	for rows.Next() {
+		var onton entity.Onton
+		if err := rows.Scan(&onton.ID, &onton.Adic); err != nil {
+			return nil, fmt.Errorf("failed to scan onton: %w", err)
+		}
+		ontons = append(ontons, &onton)
+	}
+	return ontons, rows.Err()
+}
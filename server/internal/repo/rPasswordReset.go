@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+type passwordResetRepo struct {
+	base *BaseRepo[entity.PasswordResetToken]
+}
+
+// NewPasswordResetRepo 创建新的密码重置令牌Repository
+func NewPasswordResetRepo(db *sql.DB) (*passwordResetRepo, error) {
+	// 初始化数据库表
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token_hash TEXT UNIQUE NOT NULL,
+			expires_at DATETIME NOT NULL,
+			used_at DATETIME,
+			created_by_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create password_reset_tokens table: %w", err)
+	}
+
+	baseRepo := NewBaseRepo[entity.PasswordResetToken](db, "password_reset_tokens")
+	return &passwordResetRepo{base: baseRepo}, nil
+}
+
+// Create 保存新的重置令牌
+func (r *passwordResetRepo) Create(token *entity.PasswordResetToken) error {
+	fields := map[string]any{
+		"user_id":       token.UserID,
+		"token_hash":    token.TokenHash,
+		"expires_at":    token.ExpiresAt,
+		"created_by_id": token.CreatedByID,
+		"created_at":    token.CreatedAt,
+	}
+
+	result, err := r.base.Create(fields)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = uint(id)
+	return nil
+}
+
+// GetByHash 根据令牌摘要获取重置令牌
+func (r *passwordResetRepo) GetByHash(tokenHash string) (*entity.PasswordResetToken, error) {
+	query := "SELECT id, user_id, token_hash, expires_at, used_at, created_by_id, created_at FROM password_reset_tokens WHERE token_hash = ?"
+
+	var (
+		token     entity.PasswordResetToken
+		usedAt    sql.NullTime
+		createdAt sql.NullTime
+	)
+	err := r.base.db.QueryRow(query, tokenHash).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &usedAt, &token.CreatedByID, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NotFound("重置令牌不存在")
+		}
+		return nil, fmt.Errorf("failed to scan reset token: %w", err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if createdAt.Valid {
+		token.CreatedAt = createdAt.Time
+	}
+	return &token, nil
+}
+
+// Redeem 在一个事务中将令牌标记为已使用并更新用户密码（同时清除强制改密标记）
+// 令牌已被使用时返回Validation错误，并发请求中只有一个能成功
+func (r *passwordResetRepo) Redeem(token *entity.PasswordResetToken, passwordHash string, usedAt time.Time) error {
+	tx, err := r.base.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE password_reset_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", usedAt.UTC(), token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark reset token used: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errs.Validation("重置令牌已失效")
+	}
+
+	result, err = tx.Exec("UPDATE users SET password = ?, must_change_password = 0, updated_at = ? WHERE id = ?", passwordHash, usedAt.UTC(), token.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	affected, err = result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errs.NotFound("用户不存在")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	token.UsedAt = &usedAt
+	return nil
+}
+
+// DeleteByUserID 删除用户所有的重置令牌（签发新令牌时作废旧令牌）
+func (r *passwordResetRepo) DeleteByUserID(userID uint) error {
+	query := "DELETE FROM password_reset_tokens WHERE user_id = ?"
+	_, err := r.base.db.Exec(query, userID)
+	return err
+}
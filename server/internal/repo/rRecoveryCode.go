@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"brb/internal/entity"
+)
+
+type recoveryCodeRepo struct {
+	base *BaseRepo[entity.RecoveryCode]
+}
+
+// NewRecoveryCodeRepo 创建新的两步验证恢复码Repository
+func NewRecoveryCodeRepo(db *sql.DB) (*recoveryCodeRepo, error) {
+	// 初始化数据库表
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			code_hash TEXT NOT NULL,
+			used_at DATETIME
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recovery_codes table: %w", err)
+	}
+
+	baseRepo := NewBaseRepo[entity.RecoveryCode](db, "recovery_codes")
+	return &recoveryCodeRepo{base: baseRepo}, nil
+}
+
+// ReplaceForUser 用新的恢复码替换用户现有的全部恢复码
+func (r *recoveryCodeRepo) ReplaceForUser(userID uint, codeHashes []string) error {
+	tx, err := r.base.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Consume 使用一个未使用过的恢复码，返回是否成功
+func (r *recoveryCodeRepo) Consume(userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	result, err := r.base.db.Exec(
+		"UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		usedAt, userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// DeleteByUserID 删除用户的全部恢复码
+func (r *recoveryCodeRepo) DeleteByUserID(userID uint) error {
+	_, err := r.base.db.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID)
+	return err
+}
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SchemaVersion 当前代码对应的数据库结构版本，新增表或列时加一
+// 版本号保存在SQLite的user_version中，各repo初始化（建表、补列）完成后写入
+const SchemaVersion = 10
+
+// MarkSchemaVersion 在所有表初始化完成后记录结构版本，不会降低已有的版本号
+func MarkSchemaVersion(db *sql.DB) error {
+	current, err := schemaVersion(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	if current >= SchemaVersion {
+		return nil
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+	return nil
+}
+
+// CheckSchemaVersion 检查数据库结构版本与代码一致
+// 版本更高说明数据库已被更新的程序迁移，当前实例不应继续提供服务
+func CheckSchemaVersion(ctx context.Context, db *sql.DB) error {
+	current, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current != SchemaVersion {
+		return fmt.Errorf("schema version %d, expected %d", current, SchemaVersion)
+	}
+	return nil
+}
+
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,399 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"brb/internal/entity"
+)
+
+// 匹配部分在转义前的占位标记，HTML转义后替换为<mark></mark>
+const (
+	markOpen  = "\x02"
+	markClose = "\x03"
+)
+
+// markReplacer 将占位标记替换为HTML标签
+var markReplacer = strings.NewReplacer(markOpen, "<mark>", markClose, "</mark>")
+
+// minTrigramTerm trigram分词下能用MATCH查询的最短词长（字符数），更短的词改用LIKE
+const minTrigramTerm = 3
+
+// searchRepo 基于SQLite FTS5的全文索引，覆盖event、task和sign的文本字段，todo按所属task的描述搜索
+// 索引表由触发器随源表的写入同步；SQLite未编译FTS5（go-sqlite3需以-tags sqlite_fts5构建）时
+// 改为在源表上按LIKE逐词匹配，结果不按相关度排序
+type searchRepo struct {
+	db      *sql.DB
+	enabled bool
+}
+
+// searchTables 索引表，使用trigram分词，支持中文等不以空格分词的文本按子串匹配
+// signs_fts的labels列为sign全部多语言能指以空格连接
+var searchTables = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS events_fts USING fts5(title, description, location, tokenize='trigram')`,
+	`CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(description, tokenize='trigram')`,
+	`CREATE VIRTUAL TABLE IF NOT EXISTS signs_fts USING fts5(signifier, signified, labels, tokenize='trigram')`,
+}
+
+// searchTriggers 随源表写入同步索引的触发器，名称均以_fts_on_分隔
+var searchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS events_fts_on_insert AFTER INSERT ON events
+	BEGIN
+		INSERT INTO events_fts (rowid, title, description, location)
+		VALUES (NEW.id, NEW.title, COALESCE(NEW.description, ''), COALESCE(NEW.location, ''));
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS events_fts_on_update AFTER UPDATE OF title, description, location ON events
+	BEGIN
+		UPDATE events_fts SET title = NEW.title, description = COALESCE(NEW.description, ''),
+			location = COALESCE(NEW.location, '')
+		WHERE rowid = NEW.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS events_fts_on_delete AFTER DELETE ON events
+	BEGIN
+		DELETE FROM events_fts WHERE rowid = OLD.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_on_insert AFTER INSERT ON tasks
+	BEGIN
+		INSERT INTO tasks_fts (rowid, description) VALUES (NEW.id, COALESCE(NEW.description, ''));
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_on_update AFTER UPDATE OF description ON tasks
+	BEGIN
+		UPDATE tasks_fts SET description = COALESCE(NEW.description, '') WHERE rowid = NEW.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_on_delete AFTER DELETE ON tasks
+	BEGIN
+		DELETE FROM tasks_fts WHERE rowid = OLD.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS signs_fts_on_insert AFTER INSERT ON signs
+	BEGIN
+		INSERT INTO signs_fts (rowid, signifier, signified, labels) VALUES (NEW.id, NEW.signifier, NEW.signified, '');
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS signs_fts_on_update AFTER UPDATE OF signifier, signified ON signs
+	BEGIN
+		UPDATE signs_fts SET signifier = NEW.signifier, signified = NEW.signified WHERE rowid = NEW.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS signs_fts_on_delete AFTER DELETE ON signs
+	BEGIN
+		DELETE FROM signs_fts WHERE rowid = OLD.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS signs_fts_on_label_insert AFTER INSERT ON sign_labels
+	BEGIN
+		UPDATE signs_fts SET labels = (SELECT group_concat(value, ' ') FROM sign_labels WHERE sign_id = NEW.sign_id)
+		WHERE rowid = NEW.sign_id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS signs_fts_on_label_delete AFTER DELETE ON sign_labels
+	BEGIN
+		UPDATE signs_fts SET labels = COALESCE((SELECT group_concat(value, ' ') FROM sign_labels WHERE sign_id = OLD.sign_id), '')
+		WHERE rowid = OLD.sign_id;
+	END`,
+}
+
+// searchRebuild 由源表重建全部索引
+var searchRebuild = []string{
+	`DELETE FROM events_fts`,
+	`INSERT INTO events_fts (rowid, title, description, location)
+	SELECT id, title, COALESCE(description, ''), COALESCE(location, '') FROM events`,
+	`DELETE FROM tasks_fts`,
+	`INSERT INTO tasks_fts (rowid, description) SELECT id, COALESCE(description, '') FROM tasks`,
+	`DELETE FROM signs_fts`,
+	`INSERT INTO signs_fts (rowid, signifier, signified, labels)
+	SELECT s.id, s.signifier, s.signified, COALESCE((SELECT group_concat(value, ' ') FROM sign_labels WHERE sign_id = s.id), '')
+	FROM signs s`,
+}
+
+// NewSearchRepo 创建索引表和同步触发器，须在events、tasks、signs、sign_labels表之后调用
+// 触发器不完整时（首次创建，或之前以不支持FTS5的程序打开过）重建全部索引；
+// 旧版本的events_fts还索引了已弃用的category列，先删除后重建
+// 不支持FTS5时删除已有的同步触发器，否则源表的写入会失败
+func NewSearchRepo(db *sql.DB) (*searchRepo, error) {
+	existing, err := searchTriggerNames(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var fts5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return nil, fmt.Errorf("failed to detect fts5: %w", err)
+	}
+	if !fts5 {
+		for _, name := range existing {
+			if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+				return nil, fmt.Errorf("failed to drop search trigger %s: %w", name, err)
+			}
+		}
+		return &searchRepo{db: db}, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var legacy bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM pragma_table_info('events_fts') WHERE name = 'category')`).Scan(&legacy); err != nil {
+		return nil, fmt.Errorf("failed to inspect events_fts: %w", err)
+	}
+	var statements []string
+	if legacy {
+		statements = append(statements, "DROP TABLE events_fts",
+			"DROP TRIGGER IF EXISTS events_fts_on_insert", "DROP TRIGGER IF EXISTS events_fts_on_update", "DROP TRIGGER IF EXISTS events_fts_on_delete")
+	}
+	statements = append(append(statements, searchTables...), searchTriggers...)
+	if legacy || len(existing) < len(searchTriggers) {
+		statements = append(statements, searchRebuild...)
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("failed to create search index: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &searchRepo{db: db, enabled: true}, nil
+}
+
+// searchTriggerNames 数据库中已有的索引同步触发器
+func searchTriggerNames(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'trigger' AND name LIKE '%\_fts\_on\_%' ESCAPE '\'`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search triggers: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Enabled 全文索引是否可用，不可用时搜索使用LIKE
+func (r *searchRepo) Enabled() bool {
+	return r.enabled
+}
+
+// searchColumn 搜索结果中的一个文本字段
+type searchColumn struct {
+	name   string // 结果中的字段名
+	index  int    // 在索引表中的列序号
+	source string // 在源表中的表达式，用于LIKE匹配
+}
+
+// searchTarget 一种搜索对象，源表别名为s
+type searchTarget struct {
+	fts     string // 索引表，其rowid为s.id
+	from    string // 源表及连接的表
+	id      string // 结果的ID
+	columns []searchColumn
+	weights string // bm25的列权重，与索引表的列一一对应
+	mine    string // 只搜索调用者的对象时的条件，参数均为用户ID
+}
+
+var (
+	eventTarget = searchTarget{
+		fts:  "events_fts",
+		from: "events s",
+		id:   "s.id",
+		columns: []searchColumn{
+			{"title", 0, "s.title"},
+			{"description", 1, "COALESCE(s.description, '')"},
+			{"location", 2, "COALESCE(s.location, '')"},
+		},
+		weights: "10.0, 2.0, 1.0",
+		mine:    "s.id IN (SELECT event_id FROM tasks WHERE assignee_id = ? UNION SELECT event_id FROM todos WHERE assignee_id = ?)",
+	}
+	taskTarget = searchTarget{
+		fts:     "tasks_fts",
+		from:    "tasks s",
+		id:      "s.id",
+		columns: []searchColumn{{"description", 0, "COALESCE(s.description, '')"}},
+		weights: "1.0",
+		mine:    "s.assignee_id = ?",
+	}
+	// todo没有自己的文本，按所属task的描述匹配
+	todoTarget = searchTarget{
+		fts:     "tasks_fts",
+		from:    "tasks s JOIN todos t ON t.task_id = s.id",
+		id:      "t.id",
+		columns: []searchColumn{{"task", 0, "COALESCE(s.description, '')"}},
+		weights: "1.0",
+		mine:    "t.assignee_id = ?",
+	}
+	// sign是公开的词表，不按调用者限定
+	signTarget = searchTarget{
+		fts:  "signs_fts",
+		from: "signs s",
+		id:   "s.id",
+		columns: []searchColumn{
+			{"signifier", 0, "s.signifier"},
+			{"signified", 1, "s.signified"},
+			{"labels", 2, "COALESCE((SELECT group_concat(value, ' ') FROM sign_labels WHERE sign_id = s.id), '')"},
+		},
+		weights: "10.0, 2.0, 5.0",
+	}
+)
+
+// Search 按类型分别查询，有MATCH条件时按bm25排序，否则按ID倒序
+func (r *searchRepo) Search(q entity.SearchQuery) (*entity.SearchResults, error) {
+	types := q.Types
+	if len(types) == 0 {
+		types = entity.SearchTypes
+	}
+	results := &entity.SearchResults{FullText: r.enabled}
+	for _, t := range types {
+		var err error
+		switch t {
+		case entity.SearchEvent:
+			results.Events, err = r.search(t, eventTarget, q)
+		case entity.SearchTask:
+			results.Tasks, err = r.search(t, taskTarget, q)
+		case entity.SearchTodo:
+			results.Todos, err = r.search(t, todoTarget, q)
+		case entity.SearchSign:
+			results.Signs, err = r.search(t, signTarget, q)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %ss: %w", t, err)
+		}
+	}
+	return results, nil
+}
+
+func (r *searchRepo) search(searchType entity.SearchType, target searchTarget, q entity.SearchQuery) ([]*entity.SearchHit, error) {
+	// 过短的词不能用trigram索引，不支持FTS5时全部的词都在源表上按子串匹配
+	var phrases, likeTerms []string
+	for _, term := range q.Terms {
+		if r.enabled && utf8.RuneCountInString(term) >= minTrigramTerm {
+			phrases = append(phrases, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+		} else {
+			likeTerms = append(likeTerms, term)
+		}
+	}
+
+	selects := []string{target.id}
+	from := target.from
+	var where []string
+	var args []any
+	order := target.id + " DESC"
+	if len(phrases) > 0 {
+		for _, column := range target.columns {
+			selects = append(selects, fmt.Sprintf("highlight(%s, %d, '%s', '%s')", target.fts, column.index, markOpen, markClose))
+		}
+		selects = append(selects, fmt.Sprintf("-bm25(%s, %s)", target.fts, target.weights))
+		from = target.fts + " JOIN " + from
+		where = append(where, target.fts+".rowid = s.id", target.fts+" MATCH ?")
+		args = append(args, strings.Join(phrases, " AND "))
+		order = fmt.Sprintf("%d DESC, %s DESC", len(selects), target.id)
+	} else {
+		for _, column := range target.columns {
+			selects = append(selects, column.source)
+		}
+		selects = append(selects, "0")
+	}
+	for _, term := range likeTerms {
+		var ors []string
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+		for _, column := range target.columns {
+			ors = append(ors, column.source+` LIKE ? ESCAPE '\'`)
+			args = append(args, pattern)
+		}
+		where = append(where, "("+strings.Join(ors, " OR ")+")")
+	}
+	if q.UserID != nil && target.mine != "" {
+		where = append(where, target.mine)
+		for range strings.Count(target.mine, "?") {
+			args = append(args, *q.UserID)
+		}
+	}
+
+	query := "SELECT " + strings.Join(selects, ", ") + " FROM " + from +
+		" WHERE " + strings.Join(where, " AND ") + " ORDER BY " + order + " LIMIT ?"
+	rows, err := r.db.Query(query, append(args, q.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []*entity.SearchHit{}
+	for rows.Next() {
+		hit := &entity.SearchHit{Type: searchType, Fields: make(map[string]string, len(target.columns))}
+		values := make([]string, len(target.columns))
+		dest := []any{&hit.ID}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &hit.Score)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, column := range target.columns {
+			hit.Fields[column.name] = markReplacer.Replace(html.EscapeString(markTerms(values[i], likeTerms)))
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// markTerms 标记highlight未覆盖的词（不区分大小写），已标记的部分原样保留
+func markTerms(text string, terms []string) string {
+	if len(terms) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	for text != "" {
+		if strings.HasPrefix(text, markOpen) {
+			end := strings.Index(text, markClose)
+			if end < 0 {
+				sb.WriteString(text)
+				break
+			}
+			end += len(markClose)
+			sb.WriteString(text[:end])
+			text = text[end:]
+			continue
+		}
+		if n := matchTerms(text, terms); n > 0 {
+			sb.WriteString(markOpen + text[:n] + markClose)
+			text = text[n:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text)
+		sb.WriteString(text[:size])
+		text = text[size:]
+	}
+	return sb.String()
+}
+
+// matchTerms 返回text开头与某个词匹配的字节数，都不匹配时为0
+func matchTerms(text string, terms []string) int {
+	for _, term := range terms {
+		i := 0
+		for _, tr := range term {
+			if i >= len(text) {
+				i = -1
+				break
+			}
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if !strings.EqualFold(string(r), string(tr)) {
+				i = -1
+				break
+			}
+			i += size
+		}
+		if i > 0 {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// settingRepo 保存运行时可修改的键值配置
+type settingRepo struct {
+	db *sql.DB
+}
+
+// NewSettingRepo 创建新的设置Repository
+func NewSettingRepo(db *sql.DB) (*settingRepo, error) {
+	// 初始化数据库表
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create settings table: %w", err)
+	}
+
+	return &settingRepo{db: db}, nil
+}
+
+// Get 获取设置值，不存在时返回空字符串和false
+func (r *settingRepo) Get(key string) (string, bool, error) {
+	var value string
+	err := r.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to get setting %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// Set 写入设置值
+func (r *settingRepo) Set(key, value string) error {
+	_, err := r.db.Exec(
+		"INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value",
+		key, value,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set setting %s: %w", key, err)
+	}
+	return nil
+}
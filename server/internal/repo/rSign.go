@@ -3,8 +3,11 @@ package repo
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"brb/internal/entity"
+	"brb/internal/errs"
 )
 
 type signRepo struct {
@@ -24,50 +27,402 @@ func NewSignRepo(db *sql.DB) (*signRepo, error) {
 		return nil, fmt.Errorf("failed to create signs table: %w", err)
 	}
 
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_signs_signifier ON signs (signifier)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signs index: %w", err)
+	}
+
+	// 多语言能指，每个sign的每种语言至多一个首选能指
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS sign_labels (
+			sign_id INTEGER NOT NULL,
+			lang TEXT NOT NULL,
+			value TEXT NOT NULL,
+			preferred INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (sign_id, lang, value)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign_labels table: %w", err)
+	}
+
+	for _, stmt := range []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_sign_labels_preferred ON sign_labels (sign_id, lang) WHERE preferred = 1",
+		"CREATE INDEX IF NOT EXISTS idx_sign_labels_value ON sign_labels (value)",
+		`CREATE TRIGGER IF NOT EXISTS sign_labels_on_sign_delete AFTER DELETE ON signs
+		BEGIN
+			DELETE FROM sign_labels WHERE sign_id = OLD.id;
+		END`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("failed to create sign_labels index: %w", err)
+		}
+	}
+
+	// 记录创建者和最后修改者，旧数据为NULL
+	if err := ensureColumn(db, "signs", "created_by", "INTEGER"); err != nil {
+		return nil, err
+	}
+	if err := ensureColumn(db, "signs", "updated_by", "INTEGER"); err != nil {
+		return nil, err
+	}
+
 	baseRepo := NewBaseRepo[entity.Sign](db, "signs")
 	return &signRepo{base: baseRepo}, nil
 }
 
-// Create 创建新的sign记录
-func (r *signRepo) Create(sign *entity.Sign) error {
-	fields := map[string]interface{}{
-		"signifier": sign.Signifier,
-		"signified": sign.Signified,
+// EnforceUniqueSignifiers 启用时在signifier上建立唯一索引，作为服务层检查之外的最后保障；关闭时删除该索引
+// 已有重复能指时无法启用，返回列出重复能指的错误
+func (r *signRepo) EnforceUniqueSignifiers(enabled bool) error {
+	if !enabled {
+		_, err := r.base.db.Exec("DROP INDEX IF EXISTS idx_signs_signifier_unique")
+		return err
 	}
 
-	result, err := r.base.Create(fields)
+	_, err := r.base.db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_signs_signifier_unique ON signs (signifier)")
+	if !isUniqueViolation(err, "signs.signifier") {
+		return err
+	}
+	rows, err := r.base.db.Query("SELECT signifier FROM signs GROUP BY signifier HAVING COUNT(*) > 1 ORDER BY signifier LIMIT 10")
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+	var duplicates []string
+	for rows.Next() {
+		var signifier string
+		if err := rows.Scan(&signifier); err != nil {
+			return err
+		}
+		duplicates = append(duplicates, strconv.Quote(signifier))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("以下能指被多个sign使用，无法启用唯一能指: %s", strings.Join(duplicates, ", "))
+}
+
+// signifierConflict 违反能指唯一索引时返回Conflict，其他错误原样返回
+func signifierConflict(err error, signifier string) error {
+	if isUniqueViolation(err, "signs.signifier") {
+		return errs.Conflict("能指%q已存在", signifier)
+	}
+	return err
+}
 
+// Create 创建新的sign记录及其多语言能指
+func (r *signRepo) Create(sign *entity.Sign) error {
+	tx, err := r.base.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO signs (signifier, signified, created_by, updated_by) VALUES (?, ?, ?, ?)",
+		sign.Signifier, sign.Signified, nullableID(sign.CreatedBy), nullableID(sign.UpdatedBy))
+	if err != nil {
+		return signifierConflict(err, sign.Signifier)
+	}
 	id, err := result.LastInsertId()
 	if err != nil {
 		return err
 	}
+	if err := insertSignLabels(tx, id, sign.Labels); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 	sign.ID = id
 	return nil
 }
 
 // GetByID 根据ID获取sign
 func (r *signRepo) GetByID(id int64) (*entity.Sign, error) {
-	sign, err := r.base.FindByID(id)
+	signs, err := querySigns(r.base.db, "SELECT "+signColumns+" FROM signs s WHERE s.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(signs) == 0 {
+		return nil, errs.NotFound("sign不存在")
+	}
+	return signs[0], nil
+}
+
+// signColumns 查询sign的列，表须以s为别名
+const signColumns = "s.id, s.signifier, s.signified, COALESCE(s.created_by, 0), COALESCE(s.updated_by, 0)"
+
+// signLabelMatch 多语言能指的匹配条件，与signifier的条件以OR连接
+const signLabelMatch = "id IN (SELECT sign_id FROM sign_labels WHERE value "
+
+// querySigns 执行只查询signColumns的语句，并加载结果的多语言能指
+func querySigns(db *sql.DB, query string, args ...any) ([]*entity.Sign, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signs := []*entity.Sign{}
+	for rows.Next() {
+		var sign entity.Sign
+		if err := rows.Scan(&sign.ID, &sign.Signifier, &sign.Signified, &sign.CreatedBy, &sign.UpdatedBy); err != nil {
+			return nil, err
+		}
+		signs = append(signs, &sign)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := loadSignLabels(db, signs); err != nil {
+		return nil, err
+	}
+	return signs, nil
+}
+
+// loadSignLabels 批量加载sign的多语言能指，按语言排序，同一语言中首选能指在前
+func loadSignLabels(db *sql.DB, signs []*entity.Sign) error {
+	if len(signs) == 0 {
+		return nil
+	}
+	index := make(map[int64][]*entity.Sign, len(signs))
+	args := make([]any, 0, len(signs))
+	for _, sign := range signs {
+		sign.Labels = []entity.SignLabel{}
+		if _, ok := index[sign.ID]; !ok {
+			args = append(args, sign.ID)
+		}
+		index[sign.ID] = append(index[sign.ID], sign)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+	rows, err := db.Query(`SELECT sign_id, lang, value, preferred FROM sign_labels
+		WHERE sign_id IN (`+placeholders+`)
+		ORDER BY sign_id, lang, preferred DESC, value`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query sign labels: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var signID int64
+		var label entity.SignLabel
+		if err := rows.Scan(&signID, &label.Lang, &label.Value, &label.Preferred); err != nil {
+			return fmt.Errorf("failed to scan sign label: %w", err)
+		}
+		for _, sign := range index[signID] {
+			sign.Labels = append(sign.Labels, label)
+		}
+	}
+	return rows.Err()
+}
+
+// nullableID 用户ID为0（未知）时写入NULL
+func nullableID(id uint) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// insertSignLabels 写入sign的多语言能指
+func insertSignLabels(tx *sql.Tx, signID int64, labels []entity.SignLabel) error {
+	for _, label := range labels {
+		_, err := tx.Exec("INSERT INTO sign_labels (sign_id, lang, value, preferred) VALUES (?, ?, ?, ?)",
+			signID, label.Lang, label.Value, label.Preferred)
+		if err != nil {
+			return fmt.Errorf("failed to insert sign label %s %q: %w", label.Lang, label.Value, err)
+		}
+	}
+	return nil
+}
+
+// replaceSignLabels 以labels替换sign的全部多语言能指
+func replaceSignLabels(tx *sql.Tx, signID int64, labels []entity.SignLabel) error {
+	if _, err := tx.Exec("DELETE FROM sign_labels WHERE sign_id = ?", signID); err != nil {
+		return fmt.Errorf("failed to delete sign labels: %w", err)
+	}
+	return insertSignLabels(tx, signID, labels)
+}
+
+// signLabelsEqual sign已有的多语言能指是否与labels相同，不考虑顺序
+func signLabelsEqual(tx *sql.Tx, signID int64, labels []entity.SignLabel) (bool, error) {
+	rows, err := tx.Query("SELECT lang, value, preferred FROM sign_labels WHERE sign_id = ?", signID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("sign not found")
+		return false, fmt.Errorf("failed to query sign labels: %w", err)
+	}
+	defer rows.Close()
+
+	existing := map[entity.SignLabel]bool{}
+	for rows.Next() {
+		var label entity.SignLabel
+		if err := rows.Scan(&label.Lang, &label.Value, &label.Preferred); err != nil {
+			return false, fmt.Errorf("failed to scan sign label: %w", err)
+		}
+		existing[label] = true
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	if len(existing) != len(labels) {
+		return false, nil
+	}
+	for _, label := range labels {
+		if !existing[label] {
+			return false, nil
 		}
+	}
+	return true, nil
+}
+
+// likeEscaper 转义LIKE模式中的通配符，配合ESCAPE '\'使用
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// List 按条件分页查询sign，按能指排序，同时返回符合条件的总数
+// 前缀和子串匹配使用LIKE，对ASCII字母不区分大小写
+func (r *signRepo) List(q entity.SignQuery) ([]*entity.Sign, int, error) {
+	var where []string
+	var args []any
+	if q.Signifier != "" {
+		where = append(where, "(signifier = ? OR "+signLabelMatch+"= ?))")
+		args = append(args, q.Signifier, q.Signifier)
+	}
+	if q.Prefix != "" {
+		prefix := likeEscaper.Replace(q.Prefix) + "%"
+		where = append(where, `(signifier LIKE ? ESCAPE '\' OR `+signLabelMatch+`LIKE ? ESCAPE '\'))`)
+		args = append(args, prefix, prefix)
+	}
+	if q.Search != "" {
+		where = append(where, `signified LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(q.Search)+"%")
+	}
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.base.db.QueryRow("SELECT COUNT(*) FROM signs s"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + signColumns + " FROM signs s" + whereClause + " ORDER BY s.signifier, s.id LIMIT ? OFFSET ?"
+	signs, err := querySigns(r.base.db, query, append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return signs, total, nil
+}
+
+// GetAll 获取所有sign，按ID排序
+func (r *signRepo) GetAll() ([]*entity.Sign, error) {
+	return querySigns(r.base.db, "SELECT "+signColumns+" FROM signs s ORDER BY s.id")
+}
+
+// Import 在一个事务中按能指新建或更新sign，能指对应多个sign时更新ID最小的一个
+// Labels不为nil时替换已有sign的全部多语言能指，所指和多语言能指都相同时不做修改
+// userID记为新建sign的创建者和被修改sign的修改者；dryRun为true时回滚事务，只返回将会进行的变更
+func (r *signRepo) Import(signs []*entity.Sign, userID uint, dryRun bool) ([]*entity.SignImportResult, error) {
+	tx, err := r.base.db.Begin()
+	if err != nil {
 		return nil, err
 	}
-	return sign, nil
+	defer tx.Rollback()
+
+	results := make([]*entity.SignImportResult, 0, len(signs))
+	for _, sign := range signs {
+		result := &entity.SignImportResult{Sign: &entity.Sign{Signifier: sign.Signifier, Signified: sign.Signified, Labels: sign.Labels}}
+
+		var signified string
+		err := tx.QueryRow("SELECT id, signified FROM signs WHERE signifier = ? ORDER BY id LIMIT 1", sign.Signifier).
+			Scan(&result.Sign.ID, &signified)
+		switch {
+		case err == sql.ErrNoRows:
+			res, err := tx.Exec("INSERT INTO signs (signifier, signified, created_by, updated_by) VALUES (?, ?, ?, ?)",
+				sign.Signifier, sign.Signified, nullableID(userID), nullableID(userID))
+			if err != nil {
+				return nil, fmt.Errorf("failed to insert sign %q: %w", sign.Signifier, signifierConflict(err, sign.Signifier))
+			}
+			if result.Sign.ID, err = res.LastInsertId(); err != nil {
+				return nil, err
+			}
+			if err := insertSignLabels(tx, result.Sign.ID, sign.Labels); err != nil {
+				return nil, err
+			}
+			result.Action = entity.SignImportCreated
+		case err != nil:
+			return nil, fmt.Errorf("failed to query sign %q: %w", sign.Signifier, err)
+		default:
+			labelsChanged := false
+			if sign.Labels != nil {
+				same, err := signLabelsEqual(tx, result.Sign.ID, sign.Labels)
+				if err != nil {
+					return nil, err
+				}
+				labelsChanged = !same
+			}
+			if signified == sign.Signified && !labelsChanged {
+				result.Action = entity.SignImportUnchanged
+				break
+			}
+			if _, err := tx.Exec("UPDATE signs SET signified = ?, updated_by = ? WHERE id = ?", sign.Signified, nullableID(userID), result.Sign.ID); err != nil {
+				return nil, fmt.Errorf("failed to update sign %q: %w", sign.Signifier, err)
+			}
+			if labelsChanged {
+				if err := replaceSignLabels(tx, result.Sign.ID, sign.Labels); err != nil {
+					return nil, err
+				}
+			}
+			result.Action = entity.SignImportUpdated
+		}
+		results = append(results, result)
+	}
+
+	if dryRun {
+		return results, nil
+	}
+	return results, tx.Commit()
+}
+
+// GetByIDs 批量获取sign，不存在的ID被忽略，结果按ID排序
+func (r *signRepo) GetByIDs(ids []int64) ([]*entity.Sign, error) {
+	signs := []*entity.Sign{}
+	if len(ids) == 0 {
+		return signs, nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	return querySigns(r.base.db, "SELECT "+signColumns+" FROM signs s WHERE s.id IN ("+placeholders+") ORDER BY s.id", args...)
 }
 
-// Update 更新sign记录
+// Update 更新sign记录，Labels不为nil时替换全部多语言能指
 func (r *signRepo) Update(sign *entity.Sign) error {
-	fields := map[string]interface{}{
-		"signifier": sign.Signifier,
-		"signified": sign.Signified,
+	tx, err := r.base.db.Begin()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	return r.base.Update(sign.ID, fields)
+	result, err := tx.Exec("UPDATE signs SET signifier = ?, signified = ?, updated_by = ? WHERE id = ?",
+		sign.Signifier, sign.Signified, nullableID(sign.UpdatedBy), sign.ID)
+	if err != nil {
+		return signifierConflict(err, sign.Signifier)
+	}
+	if err := r.base.checkAffected(result, sign.ID); err != nil {
+		return err
+	}
+	if sign.Labels != nil {
+		if err := replaceSignLabels(tx, sign.ID, sign.Labels); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // Delete 删除sign记录
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"brb/internal/entity"
+)
+
+// signLinkTables 可关联sign的对象类型及其所在的表
+var signLinkTables = map[entity.SignTarget]string{
+	entity.SignTargetEvent: "events",
+	entity.SignTargetTask:  "tasks",
+	entity.SignTargetTodo:  "todos",
+}
+
+// signLinkedIDs 关联了某个sign的对象ID子查询，参数依次为target_type和sign_id
+const signLinkedIDs = "SELECT target_id FROM sign_links WHERE target_type = ? AND sign_id = ?"
+
+type signLinkRepo struct {
+	db *sql.DB
+}
+
+// NewSignLinkRepo 创建sign与event/task/todo的关联Repository，须在这些表创建之后调用
+// 删除sign或被关联的对象时由触发器清理关联
+func NewSignLinkRepo(db *sql.DB) (*signLinkRepo, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS sign_links (
+			sign_id INTEGER NOT NULL,
+			target_type TEXT NOT NULL,
+			target_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (sign_id, target_type, target_id)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign_links table: %w", err)
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_sign_links_target ON sign_links (target_type, target_id)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign_links index: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TRIGGER IF NOT EXISTS sign_links_on_sign_delete AFTER DELETE ON signs
+		BEGIN
+			DELETE FROM sign_links WHERE sign_id = OLD.id;
+		END
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign_links trigger: %w", err)
+	}
+	for target, table := range signLinkTables {
+		_, err = db.Exec(fmt.Sprintf(`
+			CREATE TRIGGER IF NOT EXISTS sign_links_on_%[1]s_delete AFTER DELETE ON %[2]s
+			BEGIN
+				DELETE FROM sign_links WHERE target_type = '%[1]s' AND target_id = OLD.id;
+			END
+		`, target, table))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sign_links trigger on %s: %w", table, err)
+		}
+	}
+
+	return &signLinkRepo{db: db}, nil
+}
+
+// Link 为对象关联sign，已关联时不做任何事
+func (r *signLinkRepo) Link(signID int64, targetType entity.SignTarget, targetID uint) error {
+	_, err := r.db.Exec("INSERT OR IGNORE INTO sign_links (sign_id, target_type, target_id) VALUES (?, ?, ?)",
+		signID, string(targetType), targetID)
+	if err != nil {
+		return fmt.Errorf("failed to link sign: %w", err)
+	}
+	return nil
+}
+
+// Unlink 取消对象与sign的关联，未关联时不做任何事
+func (r *signLinkRepo) Unlink(signID int64, targetType entity.SignTarget, targetID uint) error {
+	_, err := r.db.Exec("DELETE FROM sign_links WHERE sign_id = ? AND target_type = ? AND target_id = ?",
+		signID, string(targetType), targetID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink sign: %w", err)
+	}
+	return nil
+}
+
+// GetSigns 获取对象关联的sign，按能指排序
+func (r *signLinkRepo) GetSigns(targetType entity.SignTarget, targetID uint) ([]*entity.Sign, error) {
+	query := `SELECT ` + signColumns + ` FROM signs s
+		JOIN sign_links l ON l.sign_id = s.id
+		WHERE l.target_type = ? AND l.target_id = ?
+		ORDER BY s.signifier, s.id`
+	signs, err := querySigns(r.db, query, string(targetType), targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query linked signs: %w", err)
+	}
+	return signs, nil
+}
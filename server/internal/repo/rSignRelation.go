@@ -0,0 +1,187 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+type signRelationRepo struct {
+	db *sql.DB
+}
+
+// NewSignRelationRepo 创建sign关系的Repository，须在signs表创建之后调用
+// 删除sign时由触发器删除与之相连的关系
+func NewSignRelationRepo(db *sql.DB) (*signRelationRepo, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS sign_relations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			from_id INTEGER NOT NULL,
+			to_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (from_id, to_id, type)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign_relations table: %w", err)
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_sign_relations_to ON sign_relations (to_id)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign_relations index: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TRIGGER IF NOT EXISTS sign_relations_on_sign_delete AFTER DELETE ON signs
+		BEGIN
+			DELETE FROM sign_relations WHERE from_id = OLD.id OR to_id = OLD.id;
+		END
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign_relations trigger: %w", err)
+	}
+
+	return &signRelationRepo{db: db}, nil
+}
+
+const signRelationColumns = "id, from_id, to_id, type"
+
+// Create 创建关系记录
+func (r *signRelationRepo) Create(relation *entity.SignRelation) error {
+	result, err := r.db.Exec("INSERT INTO sign_relations (from_id, to_id, type) VALUES (?, ?, ?)",
+		relation.FromID, relation.ToID, string(relation.Type))
+	if err != nil {
+		return fmt.Errorf("failed to create sign relation: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	relation.ID = id
+	return nil
+}
+
+// GetByID 根据ID获取关系
+func (r *signRelationRepo) GetByID(id int64) (*entity.SignRelation, error) {
+	row := r.db.QueryRow("SELECT "+signRelationColumns+" FROM sign_relations WHERE id = ?", id)
+	relation, err := scanSignRelation(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NotFound("sign关系不存在")
+		}
+		return nil, err
+	}
+	return relation, nil
+}
+
+// Exists 检查两个sign之间是否已有指定类型的关系
+func (r *signRelationRepo) Exists(fromID, toID int64, relationType entity.SignRelationType) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM sign_relations WHERE from_id = ? AND to_id = ? AND type = ?)",
+		fromID, toID, string(relationType)).Scan(&exists)
+	return exists, err
+}
+
+// Reachable 检查沿指定类型的关系能否从fromID到达toID
+func (r *signRelationRepo) Reachable(fromID, toID int64, relationType entity.SignRelationType) (bool, error) {
+	query := `WITH RECURSIVE reach(id) AS (
+			SELECT ?
+			UNION
+			SELECT rel.to_id FROM sign_relations rel JOIN reach ON rel.from_id = reach.id WHERE rel.type = ?
+		)
+		SELECT EXISTS(SELECT 1 FROM reach WHERE id = ?)`
+	var reachable bool
+	err := r.db.QueryRow(query, fromID, string(relationType), toID).Scan(&reachable)
+	return reachable, err
+}
+
+// GetAll 获取所有关系，按ID排序
+func (r *signRelationRepo) GetAll() ([]*entity.SignRelation, error) {
+	rows, err := r.db.Query("SELECT " + signRelationColumns + " FROM sign_relations ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sign relations: %w", err)
+	}
+	defer rows.Close()
+
+	relations := []*entity.SignRelation{}
+	for rows.Next() {
+		relation, err := scanSignRelation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sign relation: %w", err)
+		}
+		relations = append(relations, relation)
+	}
+	return relations, rows.Err()
+}
+
+// GetBySignIDs 获取与任一指定sign相连的关系，types为空时不按类型筛选
+func (r *signRelationRepo) GetBySignIDs(signIDs []int64, types []entity.SignRelationType) ([]*entity.SignRelation, error) {
+	if len(signIDs) == 0 {
+		return []*entity.SignRelation{}, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(signIDs)), ", ")
+	query := "SELECT " + signRelationColumns + " FROM sign_relations WHERE (from_id IN (" + placeholders + ") OR to_id IN (" + placeholders + "))"
+	args := make([]any, 0, 2*len(signIDs)+len(types))
+	for range 2 {
+		for _, id := range signIDs {
+			args = append(args, id)
+		}
+	}
+	if len(types) > 0 {
+		query += " AND type IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(types)), ", ") + ")"
+		for _, t := range types {
+			args = append(args, string(t))
+		}
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sign relations: %w", err)
+	}
+	defer rows.Close()
+
+	relations := []*entity.SignRelation{}
+	for rows.Next() {
+		relation, err := scanSignRelation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sign relation: %w", err)
+		}
+		relations = append(relations, relation)
+	}
+	return relations, rows.Err()
+}
+
+// Delete 删除关系记录
+func (r *signRelationRepo) Delete(id int64) error {
+	result, err := r.db.Exec("DELETE FROM sign_relations WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete sign relation: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errs.NotFound("sign关系不存在")
+	}
+	return nil
+}
+
+func scanSignRelation(row interface{ Scan(dest ...any) error }) (*entity.SignRelation, error) {
+	var (
+		relation     entity.SignRelation
+		relationType string
+	)
+	if err := row.Scan(&relation.ID, &relation.FromID, &relation.ToID, &relationType); err != nil {
+		return nil, err
+	}
+	relation.Type = entity.SignRelationType(relationType)
+	return &relation, nil
+}
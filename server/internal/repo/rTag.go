@@ -0,0 +1,275 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+type tagRepo struct {
+	base *BaseRepo[entity.Tag]
+}
+
+// NewTagRepo 创建tag的Repository，须在events表创建之后调用
+// 删除event或tag时由触发器清理两者的关联；已有event的category转换为同名标签
+func NewTagRepo(db *sql.DB) (*tagRepo, error) {
+	// 名称使用NOCASE排序规则，唯一性和按名称匹配对ASCII字母不区分大小写
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL COLLATE NOCASE UNIQUE
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tags table: %w", err)
+	}
+
+	// event与tag之间的多对多关联
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_tags (
+			event_id INTEGER NOT NULL,
+			tag_id INTEGER NOT NULL,
+			PRIMARY KEY (event_id, tag_id)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event_tags table: %w", err)
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_event_tags_tag ON event_tags (tag_id)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event_tags index: %w", err)
+	}
+
+	for _, trigger := range []string{
+		`CREATE TRIGGER IF NOT EXISTS event_tags_on_event_delete AFTER DELETE ON events
+		BEGIN
+			DELETE FROM event_tags WHERE event_id = OLD.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS event_tags_on_tag_delete AFTER DELETE ON tags
+		BEGIN
+			DELETE FROM event_tags WHERE tag_id = OLD.id;
+		END`,
+	} {
+		if _, err := db.Exec(trigger); err != nil {
+			return nil, fmt.Errorf("failed to create event_tags trigger: %w", err)
+		}
+	}
+
+	if err := tagsFromCategories(db); err != nil {
+		return nil, err
+	}
+
+	baseRepo := NewBaseRepo[entity.Tag](db, "tags")
+	return &tagRepo{base: baseRepo}, nil
+}
+
+// tagsFromCategories 将已有event的category转换为同名标签并清空该列，重复执行没有影响
+func tagsFromCategories(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		`INSERT OR IGNORE INTO tags (name)
+		SELECT trim(category) FROM events WHERE trim(COALESCE(category, '')) <> '' ORDER BY id`,
+		`INSERT OR IGNORE INTO event_tags (event_id, tag_id)
+		SELECT e.id, t.id FROM events e JOIN tags t ON t.name = trim(e.category)`,
+		`UPDATE events SET category = NULL WHERE category IS NOT NULL`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to convert event categories to tags: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// tagSelect 查询tag及其使用次数，条件之后须接tagGroup
+const tagSelect = "SELECT t.id, t.name, COUNT(et.event_id) FROM tags t LEFT JOIN event_tags et ON et.tag_id = t.id"
+
+const tagGroup = " GROUP BY t.id ORDER BY t.name, t.id"
+
+// GetAll 获取所有tag及其使用次数，按名称排序
+func (r *tagRepo) GetAll() ([]*entity.Tag, error) {
+	return r.query(tagSelect + tagGroup)
+}
+
+// GetByID 根据ID获取tag
+func (r *tagRepo) GetByID(id int64) (*entity.Tag, error) {
+	tags, err := r.query(tagSelect+" WHERE t.id = ?"+tagGroup, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, errs.NotFound("tag不存在")
+	}
+	return tags[0], nil
+}
+
+// GetByName 根据名称获取tag，ASCII字母不区分大小写
+func (r *tagRepo) GetByName(name string) (*entity.Tag, error) {
+	tags, err := r.query(tagSelect+" WHERE t.name = ?"+tagGroup, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, errs.NotFound("tag不存在")
+	}
+	return tags[0], nil
+}
+
+func (r *tagRepo) query(query string, args ...any) ([]*entity.Tag, error) {
+	rows, err := r.base.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*entity.Tag{}
+	for rows.Next() {
+		var tag entity.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Usage); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, &tag)
+	}
+	return tags, rows.Err()
+}
+
+// Rename 修改tag名称
+func (r *tagRepo) Rename(id int64, name string) error {
+	return r.base.Update(id, map[string]any{"name": name})
+}
+
+// Merge 将source的event关联转移到target后删除source
+func (r *tagRepo) Merge(sourceID, targetID int64) error {
+	tx, err := r.base.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT OR IGNORE INTO event_tags (event_id, tag_id) SELECT event_id, ? FROM event_tags WHERE tag_id = ?", targetID, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to move event tags: %w", err)
+	}
+	result, err := tx.Exec("DELETE FROM tags WHERE id = ?", sourceID)
+	if err != nil {
+		return err
+	}
+	if err := r.base.checkAffected(result, sourceID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete 删除tag记录
+func (r *tagRepo) Delete(id int64) error {
+	return r.base.Delete(id)
+}
+
+// tagFilterIDs 带有筛选标签的event ID子查询，未知的标签名不匹配任何event
+func tagFilterIDs(f entity.TagFilter) (string, []any) {
+	args := make([]any, 0, len(f.Tags)+1)
+	for _, name := range f.Tags {
+		args = append(args, name)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(f.Tags)), ", ")
+	query := "SELECT et.event_id FROM event_tags et JOIN tags t ON t.id = et.tag_id WHERE t.name IN (" + placeholders + ")"
+	if f.Match == entity.TagMatchAll {
+		query += " GROUP BY et.event_id HAVING COUNT(DISTINCT et.tag_id) = ?"
+		args = append(args, len(f.Tags))
+	}
+	return query, args
+}
+
+// withCategory 将已弃用的category追加到标签列表，names为nil且没有category时保持为nil
+func withCategory(names []string, category string) []string {
+	if category == "" {
+		return names
+	}
+	return append(slices.Clone(names), category)
+}
+
+// addEventTag 为event追加一个标签，不存在的标签自动创建
+func addEventTag(tx *sql.Tx, eventID uint, name string) error {
+	if _, err := tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", name); err != nil {
+		return fmt.Errorf("failed to create tag %q: %w", name, err)
+	}
+	_, err := tx.Exec("INSERT OR IGNORE INTO event_tags (event_id, tag_id) SELECT ?, id FROM tags WHERE name = ?", eventID, name)
+	if err != nil {
+		return fmt.Errorf("failed to link tag %q: %w", name, err)
+	}
+	return nil
+}
+
+// setEventTags 替换event的全部标签，不存在的标签自动创建，返回按名称排序的已有写法
+func setEventTags(tx *sql.Tx, eventID uint, names []string) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM event_tags WHERE event_id = ?", eventID); err != nil {
+		return nil, fmt.Errorf("failed to delete event tags: %w", err)
+	}
+	for _, name := range names {
+		if err := addEventTag(tx, eventID, name); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query("SELECT t.name FROM event_tags et JOIN tags t ON t.id = et.tag_id WHERE et.event_id = ? ORDER BY t.name", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, name)
+	}
+	return tags, rows.Err()
+}
+
+// loadEventTags 批量加载event的标签，按名称排序
+func loadEventTags(db *sql.DB, events []*entity.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	index := make(map[uint][]*entity.Event, len(events))
+	args := make([]any, 0, len(events))
+	for _, event := range events {
+		event.Tags = []string{}
+		if _, ok := index[event.ID]; !ok {
+			args = append(args, event.ID)
+		}
+		index[event.ID] = append(index[event.ID], event)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+	rows, err := db.Query(`SELECT et.event_id, t.name FROM event_tags et JOIN tags t ON t.id = et.tag_id
+		WHERE et.event_id IN (`+placeholders+`)
+		ORDER BY et.event_id, t.name`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query event tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventID uint
+		var name string
+		if err := rows.Scan(&eventID, &name); err != nil {
+			return fmt.Errorf("failed to scan event tag: %w", err)
+		}
+		for _, event := range index[eventID] {
+			event.Tags = append(event.Tags, name)
+		}
+	}
+	return rows.Err()
+}
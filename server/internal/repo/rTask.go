@@ -3,10 +3,15 @@ package repo
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"brb/internal/entity"
+	"brb/internal/errs"
 )
 
+// taskColumns 查询task时使用的列顺序，与scanTask保持一致
+const taskColumns = "id, event_id, parent_task_id, description, allowed_start, allowed_end, planned_start, planned_end, status, created_at, pre_task_ids, assignee_id, allowed_all_day, planned_all_day"
+
 type taskRepo struct {
 	base *BaseRepo[entity.Task]
 }
@@ -27,12 +32,24 @@ func NewTaskRepo(db *sql.DB) (*taskRepo, error) {
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			
 			-- 存储pre_task_ids作为JSON数组
-			pre_task_ids TEXT
+			pre_task_ids TEXT,
+			assignee_id INTEGER,
+			allowed_all_day BOOLEAN NOT NULL DEFAULT 0,
+			planned_all_day BOOLEAN NOT NULL DEFAULT 0
 		)
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tasks table: %w", err)
 	}
+	for column, definition := range map[string]string{
+		"assignee_id":     "INTEGER",
+		"allowed_all_day": "BOOLEAN NOT NULL DEFAULT 0",
+		"planned_all_day": "BOOLEAN NOT NULL DEFAULT 0",
+	} {
+		if err := ensureColumn(db, "tasks", column, definition); err != nil {
+			return nil, err
+		}
+	}
 
 	baseRepo := NewBaseRepo[entity.Task](db, "tasks")
 	return &taskRepo{base: baseRepo}, nil
@@ -40,12 +57,21 @@ func NewTaskRepo(db *sql.DB) (*taskRepo, error) {
 
 // Create 创建新的task记录
 func (r *taskRepo) Create(task *entity.Task) error {
+	_, err := r.base.Create(taskFields(task))
+	return err
+}
+
+// taskFields 插入task时写入的字段
+func taskFields(task *entity.Task) map[string]any {
 	fields := map[string]interface{}{
-		"event_id":       task.EventID,
-		"parent_task_id": task.ParentTaskID,
-		"description":    task.Description,
-		"status":         string(task.Status),
-		"created_at":     task.CreatedAt,
+		"event_id":        task.EventID,
+		"parent_task_id":  task.ParentTaskID,
+		"description":     task.Description,
+		"status":          string(task.Status),
+		"created_at":      task.CreatedAt,
+		"assignee_id":     task.AssigneeID,
+		"allowed_all_day": task.AllowedTime.AllDay,
+		"planned_all_day": task.PlannedDuration.AllDay,
 	}
 
 	// 处理时间字段
@@ -73,9 +99,7 @@ func (r *taskRepo) Create(task *entity.Task) error {
 	if task.ID != 0 {
 		fields["id"] = task.ID
 	}
-
-	_, err := r.base.Create(fields)
-	return err
+	return fields
 }
 
 // HaveID 检查是否存在指定ID的task
@@ -90,7 +114,7 @@ func (r *taskRepo) HaveID(id uint) bool {
 
 // GetAll 获取所有task
 func (r *taskRepo) GetAll() ([]*entity.Task, error) {
-	query := "SELECT * FROM tasks"
+	query := "SELECT " + taskColumns + " FROM tasks"
 	rows, err := r.base.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -112,18 +136,74 @@ func (r *taskRepo) GetAll() ([]*entity.Task, error) {
 	return tasks, nil
 }
 
+// GetBySignID 获取关联了指定sign的task
+func (r *taskRepo) GetBySignID(signID int64) ([]*entity.Task, error) {
+	query := "SELECT " + taskColumns + " FROM tasks WHERE id IN (" + signLinkedIDs + ")"
+	rows, err := r.base.db.Query(query, string(entity.SignTargetTask), signID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*entity.Task{}
+	for rows.Next() {
+		task, err := r.scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetByEventTags 获取所属event带有指定标签的task
+func (r *taskRepo) GetByEventTags(f entity.TagFilter) ([]*entity.Task, error) {
+	subquery, args := tagFilterIDs(f)
+	query := "SELECT " + taskColumns + " FROM tasks WHERE event_id IN (" + subquery + ")"
+	rows, err := r.base.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*entity.Task{}
+	for rows.Next() {
+		task, err := r.scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
 // GetByID 根据ID获取task
 func (r *taskRepo) GetByID(id uint) (*entity.Task, error) {
-	query := "SELECT * FROM tasks WHERE id = ?"
+	query := "SELECT " + taskColumns + " FROM tasks WHERE id = ?"
 	row := r.base.db.QueryRow(query, id)
-	return r.scanTask(row)
+	task, err := r.scanTask(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NotFound("task不存在")
+		}
+		return nil, err
+	}
+	return task, nil
 }
 
 // scanTask 从数据库行扫描Task实体
 func (r *taskRepo) scanTask(row interface{}) (*entity.Task, error) {
 	var task entity.Task
 	var allowedStart, allowedEnd, plannedStart, plannedEnd sql.NullTime
-	var parentTaskID sql.NullInt64
+	var parentTaskID, assigneeID sql.NullInt64
 	var preTaskIDs sql.NullString
 
 	var err error
@@ -141,6 +221,9 @@ func (r *taskRepo) scanTask(row interface{}) (*entity.Task, error) {
 			&task.Status,
 			&task.CreatedAt,
 			&preTaskIDs,
+			&assigneeID,
+			&task.AllowedTime.AllDay,
+			&task.PlannedDuration.AllDay,
 		)
 	case *sql.Rows:
 		err = row.Scan(
@@ -155,6 +238,9 @@ func (r *taskRepo) scanTask(row interface{}) (*entity.Task, error) {
 			&task.Status,
 			&task.CreatedAt,
 			&preTaskIDs,
+			&assigneeID,
+			&task.AllowedTime.AllDay,
+			&task.PlannedDuration.AllDay,
 		)
 	default:
 		return nil, fmt.Errorf("unsupported row type")
@@ -170,6 +256,12 @@ func (r *taskRepo) scanTask(row interface{}) (*entity.Task, error) {
 		task.ParentTaskID = &parentID
 	}
 
+	// 处理负责人ID
+	if assigneeID.Valid {
+		assignee := uint(assigneeID.Int64)
+		task.AssigneeID = &assignee
+	}
+
 	// 处理时间字段
 	if allowedStart.Valid {
 		task.AllowedTime.Start = &allowedStart.Time
@@ -192,27 +284,19 @@ func (r *taskRepo) scanTask(row interface{}) (*entity.Task, error) {
 	return &task, nil
 }
 
-// Update 更新task记录
+// Update 更新task记录，未设置的时间写为NULL
 func (r *taskRepo) Update(task *entity.Task) error {
 	fields := map[string]any{
-		"event_id":       task.EventID,
-		"parent_task_id": task.ParentTaskID,
-		"description":    task.Description,
-		"status":         string(task.Status),
-	}
-
-	// 处理时间字段
-	if task.AllowedTime.Start != nil {
-		fields["allowed_start"] = task.AllowedTime.Start
-	}
-	if task.AllowedTime.End != nil {
-		fields["allowed_end"] = task.AllowedTime.End
-	}
-	if task.PlannedDuration.Start != nil {
-		fields["planned_start"] = task.PlannedDuration.Start
-	}
-	if task.PlannedDuration.End != nil {
-		fields["planned_end"] = task.PlannedDuration.End
+		"event_id":        task.EventID,
+		"parent_task_id":  task.ParentTaskID,
+		"description":     task.Description,
+		"status":          string(task.Status),
+		"allowed_start":   task.AllowedTime.Start,
+		"allowed_end":     task.AllowedTime.End,
+		"planned_start":   task.PlannedDuration.Start,
+		"planned_end":     task.PlannedDuration.End,
+		"allowed_all_day": task.AllowedTime.AllDay,
+		"planned_all_day": task.PlannedDuration.AllDay,
 	}
 
 	// 处理pre_task_ids作为JSON数组
@@ -223,6 +307,30 @@ func (r *taskRepo) Update(task *entity.Task) error {
 	return r.base.Update(task.ID, fields)
 }
 
+// GetByAssigneeID 获取指派给指定用户的所有task
+func (r *taskRepo) GetByAssigneeID(assigneeID uint) ([]*entity.Task, error) {
+	query := "SELECT " + taskColumns + " FROM tasks WHERE assignee_id = ?"
+	rows, err := r.base.db.Query(query, assigneeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*entity.Task
+	for rows.Next() {
+		task, err := r.scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
 // Delete 删除task记录
 func (r *taskRepo) Delete(id uint) error {
 	return r.base.Delete(id)
@@ -234,3 +342,26 @@ func (r *taskRepo) DeleteByEventID(eventID uint) error {
 	_, err := r.base.db.Exec(query, eventID)
 	return err
 }
+
+// CountOverdue 统计截止时间早于now且尚未完成或取消的task数量
+func (r *taskRepo) CountOverdue(now time.Time) (int, error) {
+	query := "SELECT allowed_end FROM tasks WHERE allowed_end IS NOT NULL AND status NOT IN (?, ?)"
+	rows, err := r.base.db.Query(query, entity.StatusCompleted, entity.StatusCancelled)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	// 时间以带时区的文本存储，在Go中比较以避免按字符串比较不同时区的时间
+	count := 0
+	for rows.Next() {
+		var end sql.NullTime
+		if err := rows.Scan(&end); err != nil {
+			return 0, err
+		}
+		if end.Valid && end.Time.Before(now) {
+			count++
+		}
+	}
+	return count, rows.Err()
+}
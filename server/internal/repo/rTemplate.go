@@ -0,0 +1,191 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"brb/internal/entity"
+)
+
+// 模板清单项的类型
+const (
+	checklistKindTask = "task"
+	checklistKindTodo = "todo"
+)
+
+type templateRepo struct {
+	db *sql.DB
+}
+
+// NewTemplateRepo 创建模板清单的Repository，须在events表创建之后调用
+// 删除event时由触发器清理其清单
+func NewTemplateRepo(db *sql.DB) (*templateRepo, error) {
+	// 计划时间以相对时间窗口开始的分钟数保存
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_checklist_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			parent_id INTEGER,
+			position INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			offset_minutes INTEGER NOT NULL DEFAULT 0,
+			duration_minutes INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event_checklist_items table: %w", err)
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_event_checklist_items_event ON event_checklist_items (event_id, position)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event_checklist_items index: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE TRIGGER IF NOT EXISTS event_checklist_items_on_event_delete AFTER DELETE ON events
+		BEGIN
+			DELETE FROM event_checklist_items WHERE event_id = OLD.id;
+		END`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event_checklist_items trigger: %w", err)
+	}
+
+	return &templateRepo{db: db}, nil
+}
+
+// GetChecklist 获取event的清单，没有清单时返回空清单
+func (r *templateRepo) GetChecklist(eventID uint) (*entity.Checklist, error) {
+	rows, err := r.db.Query(`SELECT id, parent_id, kind, description, offset_minutes, duration_minutes
+		FROM event_checklist_items WHERE event_id = ? ORDER BY position`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checklist: %w", err)
+	}
+	defer rows.Close()
+
+	checklist := &entity.Checklist{Tasks: []entity.ChecklistTask{}, Todos: []entity.ChecklistTodo{}}
+	taskIndex := map[int64]int{} // 子task项ID -> 在Tasks中的下标
+	for rows.Next() {
+		var (
+			id, offset, duration int64
+			parentID             sql.NullInt64
+			kind, description    string
+		)
+		if err := rows.Scan(&id, &parentID, &kind, &description, &offset, &duration); err != nil {
+			return nil, fmt.Errorf("failed to scan checklist item: %w", err)
+		}
+		plan := entity.ChecklistPlan{
+			Offset:   time.Duration(offset) * time.Minute,
+			Duration: time.Duration(duration) * time.Minute,
+		}
+
+		switch {
+		case kind == checklistKindTask:
+			taskIndex[id] = len(checklist.Tasks)
+			checklist.Tasks = append(checklist.Tasks, entity.ChecklistTask{
+				Description: description,
+				Plan:        plan,
+				Todos:       []entity.ChecklistTodo{},
+			})
+		case parentID.Valid:
+			// 子task项的position总是小于其todo，此时已读取
+			i := taskIndex[parentID.Int64]
+			checklist.Tasks[i].Todos = append(checklist.Tasks[i].Todos, entity.ChecklistTodo{Plan: plan})
+		default:
+			checklist.Todos = append(checklist.Todos, entity.ChecklistTodo{Plan: plan})
+		}
+	}
+	return checklist, rows.Err()
+}
+
+// SetChecklist 替换event的全部清单项
+func (r *templateRepo) SetChecklist(eventID uint, checklist *entity.Checklist) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM event_checklist_items WHERE event_id = ?", eventID); err != nil {
+		return fmt.Errorf("failed to delete checklist: %w", err)
+	}
+
+	position := 0
+	insert := func(parentID any, kind, description string, plan entity.ChecklistPlan) (int64, error) {
+		position++
+		result, err := tx.Exec(`INSERT INTO event_checklist_items
+			(event_id, parent_id, position, kind, description, offset_minutes, duration_minutes)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			eventID, parentID, position, kind, description, int64(plan.Offset/time.Minute), int64(plan.Duration/time.Minute))
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert checklist item: %w", err)
+		}
+		return result.LastInsertId()
+	}
+
+	for _, todo := range checklist.Todos {
+		if _, err := insert(nil, checklistKindTodo, "", todo.Plan); err != nil {
+			return err
+		}
+	}
+	for _, task := range checklist.Tasks {
+		taskID, err := insert(nil, checklistKindTask, task.Description, task.Plan)
+		if err != nil {
+			return err
+		}
+		for _, todo := range task.Todos {
+			if _, err := insert(taskID, checklistKindTodo, "", todo.Plan); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Instantiate 在一个事务中创建task树，写入后设置各task和todo的ID及其关联ID
+func (r *templateRepo) Instantiate(tree *entity.TaskTree) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTaskTree(tx, tree); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertTaskTree(tx *sql.Tx, tree *entity.TaskTree) error {
+	result, err := insertRow(tx, "tasks", taskFields(tree.Task))
+	if err != nil {
+		return fmt.Errorf("failed to insert task: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	tree.Task.ID = uint(id)
+
+	for _, todo := range tree.Todos {
+		todo.TaskID = tree.Task.ID
+		result, err := insertRow(tx, "todos", todoFields(todo))
+		if err != nil {
+			return fmt.Errorf("failed to insert todo: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		todo.ID = uint(id)
+	}
+
+	for _, subtree := range tree.Subtasks {
+		parentID := tree.Task.ID
+		subtree.Task.ParentTaskID = &parentID
+		if err := insertTaskTree(tx, subtree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,11 +2,16 @@ package repo
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"brb/internal/entity"
+	"brb/internal/errs"
 )
 
+// todoColumns 查询todo时使用的列顺序，与scanTodo保持一致
+const todoColumns = "id, event_id, task_id, status, planned_start, planned_end, actual_start, actual_end, completed_time, assignee_id, planned_all_day, actual_all_day"
+
 type todoRepo struct {
 	base *BaseRepo[entity.Todo]
 }
@@ -23,12 +28,24 @@ func NewTodoRepo(db *sql.DB) (*todoRepo, error) {
 			planned_end DATETIME,
 			actual_start DATETIME,
 			actual_end DATETIME,
-			completed_time DATETIME
+			completed_time DATETIME,
+			assignee_id INTEGER,
+			planned_all_day BOOLEAN NOT NULL DEFAULT 0,
+			actual_all_day BOOLEAN NOT NULL DEFAULT 0
 		)
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create todos table: %w", err)
 	}
+	for column, definition := range map[string]string{
+		"assignee_id":     "INTEGER",
+		"planned_all_day": "BOOLEAN NOT NULL DEFAULT 0",
+		"actual_all_day":  "BOOLEAN NOT NULL DEFAULT 0",
+	} {
+		if err := ensureColumn(db, "todos", column, definition); err != nil {
+			return nil, err
+		}
+	}
 
 	baseRepo := NewBaseRepo[entity.Todo](db, "todos")
 	return &todoRepo{base: baseRepo}, nil
@@ -36,11 +53,20 @@ func NewTodoRepo(db *sql.DB) (*todoRepo, error) {
 
 // Create 创建新的todo记录
 func (r *todoRepo) Create(todo *entity.Todo) error {
+	_, err := r.base.Create(todoFields(todo))
+	return err
+}
+
+// todoFields 插入todo时写入的字段
+func todoFields(todo *entity.Todo) map[string]any {
 	fields := map[string]any{
-		"event_id":       todo.EventID,
-		"task_id":        todo.TaskID,
-		"status":         string(todo.Status),
-		"completed_time": todo.CompletedTime,
+		"event_id":        todo.EventID,
+		"task_id":         todo.TaskID,
+		"status":          string(todo.Status),
+		"completed_time":  todo.CompletedTime,
+		"assignee_id":     todo.AssigneeID,
+		"planned_all_day": todo.PlannedTime.AllDay,
+		"actual_all_day":  todo.ActualTime.AllDay,
 	}
 
 	// 处理计划时间
@@ -63,14 +89,12 @@ func (r *todoRepo) Create(todo *entity.Todo) error {
 	if todo.ID != 0 {
 		fields["id"] = todo.ID
 	}
-
-	_, err := r.base.Create(fields)
-	return err
+	return fields
 }
 
 // GetAll 获取所有todo记录
 func (r *todoRepo) GetAll() ([]*entity.Todo, error) {
-	query := "SELECT id, event_id, task_id, status, planned_start, planned_end, actual_start, actual_end, completed_time FROM todos"
+	query := "SELECT " + todoColumns + " FROM todos"
 	rows, err := r.base.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query todos: %w", err)
@@ -93,15 +117,40 @@ func (r *todoRepo) GetAll() ([]*entity.Todo, error) {
 	return todos, nil
 }
 
+// GetBySignID 获取关联了指定sign的todo
+func (r *todoRepo) GetBySignID(signID int64) ([]*entity.Todo, error) {
+	query := "SELECT " + todoColumns + " FROM todos WHERE id IN (" + signLinkedIDs + ")"
+	rows, err := r.base.db.Query(query, string(entity.SignTargetTodo), signID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+
+	todos := []*entity.Todo{}
+	for rows.Next() {
+		todo, err := r.scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return todos, nil
+}
+
 // GetByID 根据ID获取todo
 func (r *todoRepo) GetByID(id uint) (*entity.Todo, error) {
-	query := "SELECT id, event_id, task_id, status, planned_start, planned_end, actual_start, actual_end, completed_time FROM todos WHERE id = ?"
+	query := "SELECT " + todoColumns + " FROM todos WHERE id = ?"
 	row := r.base.db.QueryRow(query, id)
 
 	todo, err := r.scanTodo(row)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("todo not found")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NotFound("todo不存在")
 		}
 		return nil, fmt.Errorf("failed to scan todo: %w", err)
 	}
@@ -120,14 +169,17 @@ func (r *todoRepo) scanTodo(row any) (*entity.Todo, error) {
 		actualStart   sql.NullTime
 		actualEnd     sql.NullTime
 		completedTime sql.NullTime
+		assigneeID    sql.NullInt64
+		plannedAllDay bool
+		actualAllDay  bool
 	)
 
 	var err error
 	switch row := row.(type) {
 	case *sql.Row:
-		err = row.Scan(&id, &eventID, &taskID, &status, &plannedStart, &plannedEnd, &actualStart, &actualEnd, &completedTime)
+		err = row.Scan(&id, &eventID, &taskID, &status, &plannedStart, &plannedEnd, &actualStart, &actualEnd, &completedTime, &assigneeID, &plannedAllDay, &actualAllDay)
 	case *sql.Rows:
-		err = row.Scan(&id, &eventID, &taskID, &status, &plannedStart, &plannedEnd, &actualStart, &actualEnd, &completedTime)
+		err = row.Scan(&id, &eventID, &taskID, &status, &plannedStart, &plannedEnd, &actualStart, &actualEnd, &completedTime, &assigneeID, &plannedAllDay, &actualAllDay)
 	default:
 		return nil, fmt.Errorf("unsupported row type")
 	}
@@ -148,7 +200,14 @@ func (r *todoRepo) scanTodo(row any) (*entity.Todo, error) {
 		todo.EventID = &eventIDVal
 	}
 
+	// 处理可空的assignee_id
+	if assigneeID.Valid {
+		assigneeIDVal := uint(assigneeID.Int64)
+		todo.AssigneeID = &assigneeIDVal
+	}
+
 	// 处理计划时间
+	todo.PlannedTime.AllDay = plannedAllDay
 	if plannedStart.Valid {
 		todo.PlannedTime.Start = &plannedStart.Time
 	}
@@ -157,6 +216,7 @@ func (r *todoRepo) scanTodo(row any) (*entity.Todo, error) {
 	}
 
 	// 处理实际时间
+	todo.ActualTime.AllDay = actualAllDay
 	if actualStart.Valid {
 		todo.ActualTime.Start = &actualStart.Time
 	}
@@ -172,32 +232,47 @@ func (r *todoRepo) scanTodo(row any) (*entity.Todo, error) {
 	return todo, nil
 }
 
-// Update 更新todo记录
+// Update 更新todo记录，未设置的时间写为NULL
 func (r *todoRepo) Update(todo *entity.Todo) error {
 	fields := map[string]any{
-		"event_id":       todo.EventID,
-		"task_id":        todo.TaskID,
-		"status":         string(todo.Status),
-		"completed_time": todo.CompletedTime,
+		"event_id":        todo.EventID,
+		"task_id":         todo.TaskID,
+		"status":          string(todo.Status),
+		"completed_time":  todo.CompletedTime,
+		"planned_start":   todo.PlannedTime.Start,
+		"planned_end":     todo.PlannedTime.End,
+		"actual_start":    todo.ActualTime.Start,
+		"actual_end":      todo.ActualTime.End,
+		"planned_all_day": todo.PlannedTime.AllDay,
+		"actual_all_day":  todo.ActualTime.AllDay,
 	}
 
-	// 处理计划时间
-	if todo.PlannedTime.Start != nil {
-		fields["planned_start"] = todo.PlannedTime.Start
-	}
-	if todo.PlannedTime.End != nil {
-		fields["planned_end"] = todo.PlannedTime.End
+	return r.base.Update(todo.ID, fields)
+}
+
+// GetByAssigneeID 获取指派给指定用户的所有todo，按计划开始时间排序
+func (r *todoRepo) GetByAssigneeID(assigneeID uint) ([]*entity.Todo, error) {
+	query := "SELECT " + todoColumns + " FROM todos WHERE assignee_id = ? ORDER BY planned_start IS NULL, planned_start"
+	rows, err := r.base.db.Query(query, assigneeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todos: %w", err)
 	}
+	defer rows.Close()
 
-	// 处理实际时间
-	if todo.ActualTime.Start != nil {
-		fields["actual_start"] = todo.ActualTime.Start
+	var todos []*entity.Todo
+	for rows.Next() {
+		todo, err := r.scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
 	}
-	if todo.ActualTime.End != nil {
-		fields["actual_end"] = todo.ActualTime.End
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
-	return r.base.Update(todo.ID, fields)
+	return todos, nil
 }
 
 // Delete 删除todo记录
@@ -211,3 +286,23 @@ func (r *todoRepo) DeleteByTaskID(taskID uint) error {
 	_, err := r.base.db.Exec(query, taskID)
 	return err
 }
+
+// CountByStatus 按状态统计todo数量
+func (r *todoRepo) CountByStatus() (map[entity.Status]int, error) {
+	rows, err := r.base.db.Query("SELECT status, COUNT(*) FROM todos GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[entity.Status]int{}
+	for rows.Next() {
+		var status entity.Status
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
@@ -2,11 +2,17 @@ package repo
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"brb/internal/entity"
+	"brb/internal/errs"
 )
 
+// userColumns 查询用户时使用的列顺序，与scanUser保持一致
+const userColumns = "id, username, password, role, must_change_password, totp_secret, totp_enabled, totp_last_counter, timezone, created_at, updated_at"
+
 type userRepo struct {
 	base *BaseRepo[entity.User]
 }
@@ -20,6 +26,11 @@ func NewUserRepo(db *sql.DB) (*userRepo, error) {
 			username TEXT UNIQUE NOT NULL,
 			password TEXT NOT NULL,
 			role TEXT NOT NULL DEFAULT 'user',
+			must_change_password BOOLEAN NOT NULL DEFAULT 0,
+			totp_secret TEXT NOT NULL DEFAULT '',
+			totp_enabled BOOLEAN NOT NULL DEFAULT 0,
+			totp_last_counter INTEGER NOT NULL DEFAULT 0,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)
@@ -27,6 +38,17 @@ func NewUserRepo(db *sql.DB) (*userRepo, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create users table: %w", err)
 	}
+	for column, definition := range map[string]string{
+		"must_change_password": "BOOLEAN NOT NULL DEFAULT 0",
+		"totp_secret":          "TEXT NOT NULL DEFAULT ''",
+		"totp_enabled":         "BOOLEAN NOT NULL DEFAULT 0",
+		"totp_last_counter":    "INTEGER NOT NULL DEFAULT 0",
+		"timezone":             "TEXT NOT NULL DEFAULT 'UTC'",
+	} {
+		if err := ensureColumn(db, "users", column, definition); err != nil {
+			return nil, err
+		}
+	}
 
 	baseRepo := NewBaseRepo[entity.User](db, "users")
 	return &userRepo{base: baseRepo}, nil
@@ -35,11 +57,16 @@ func NewUserRepo(db *sql.DB) (*userRepo, error) {
 // Create 创建新用户
 func (r *userRepo) Create(user *entity.User) error {
 	fields := map[string]any{
-		"username":   user.Username,
-		"password":   user.Password,
-		"role":       string(user.Role),
-		"created_at": user.CreatedAt,
-		"updated_at": user.UpdatedAt,
+		"username":             user.Username,
+		"password":             user.Password,
+		"role":                 string(user.Role),
+		"must_change_password": user.MustChangePassword,
+		"totp_secret":          user.TOTPSecret,
+		"totp_enabled":         user.TOTPEnabled,
+		"totp_last_counter":    user.TOTPLastCounter,
+		"timezone":             userTimezone(user),
+		"created_at":           user.CreatedAt,
+		"updated_at":           user.UpdatedAt,
 	}
 
 	// 如果ID已设置（用于更新），包含它，否则将自动生成
@@ -47,19 +74,28 @@ func (r *userRepo) Create(user *entity.User) error {
 		fields["id"] = user.ID
 	}
 
-	_, err := r.base.Create(fields)
-	return err
+	result, err := r.base.Create(fields)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.ID = uint(id)
+	return nil
 }
 
 // GetByID 根据ID获取用户
 func (r *userRepo) GetByID(id uint) (*entity.User, error) {
-	query := "SELECT id, username, password, role, created_at, updated_at FROM users WHERE id = ?"
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
 	row := r.base.db.QueryRow(query, id)
 
 	user, err := r.scanUser(row)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NotFound("用户不存在")
 		}
 		return nil, fmt.Errorf("failed to scan user: %w", err)
 	}
@@ -68,13 +104,13 @@ func (r *userRepo) GetByID(id uint) (*entity.User, error) {
 
 // GetByUsername 根据用户名获取用户
 func (r *userRepo) GetByUsername(username string) (*entity.User, error) {
-	query := "SELECT id, username, password, role, created_at, updated_at FROM users WHERE username = ?"
+	query := "SELECT " + userColumns + " FROM users WHERE username = ?"
 	row := r.base.db.QueryRow(query, username)
 
 	user, err := r.scanUser(row)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NotFound("用户不存在")
 		}
 		return nil, fmt.Errorf("failed to scan user: %w", err)
 	}
@@ -83,7 +119,7 @@ func (r *userRepo) GetByUsername(username string) (*entity.User, error) {
 
 // GetAll 获取所有用户
 func (r *userRepo) GetAll() ([]*entity.User, error) {
-	query := "SELECT id, username, password, role, created_at, updated_at FROM users"
+	query := "SELECT " + userColumns + " FROM users"
 	rows, err := r.base.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users: %w", err)
@@ -109,15 +145,37 @@ func (r *userRepo) GetAll() ([]*entity.User, error) {
 // Update 更新用户信息
 func (r *userRepo) Update(user *entity.User) error {
 	fields := map[string]any{
-		"username":   user.Username,
-		"password":   user.Password,
-		"role":       string(user.Role),
-		"updated_at": user.UpdatedAt,
+		"username":             user.Username,
+		"password":             user.Password,
+		"role":                 string(user.Role),
+		"must_change_password": user.MustChangePassword,
+		"totp_secret":          user.TOTPSecret,
+		"totp_enabled":         user.TOTPEnabled,
+		"totp_last_counter":    user.TOTPLastCounter,
+		"timezone":             userTimezone(user),
+		"updated_at":           user.UpdatedAt,
 	}
 
 	return r.base.Update(user.ID, fields)
 }
 
+// AdvanceTOTPCounter 仅当counter大于最近一次使用的TOTP时间步时才记录，返回是否记录成功，
+// 同一验证码的并发请求只有一个能成功
+func (r *userRepo) AdvanceTOTPCounter(id uint, counter int64, updatedAt time.Time) (bool, error) {
+	result, err := r.base.db.Exec(
+		"UPDATE users SET totp_last_counter = ?, updated_at = ? WHERE id = ? AND totp_last_counter < ?",
+		counter, updatedAt.UTC(), id, counter,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
 // Delete 删除用户
 func (r *userRepo) Delete(id uint) error {
 	return r.base.Delete(id)
@@ -148,20 +206,25 @@ func (r *userRepo) HaveID(id uint) bool {
 // scanUser 从数据库行扫描User实体
 func (r *userRepo) scanUser(row any) (*entity.User, error) {
 	var (
-		id        uint
-		username  string
-		password  string
-		role      string
-		createdAt sql.NullTime
-		updatedAt sql.NullTime
+		id         uint
+		username   string
+		password   string
+		role       string
+		mustChange bool
+		secret     string
+		enabled    bool
+		counter    int64
+		timezone   string
+		createdAt  sql.NullTime
+		updatedAt  sql.NullTime
 	)
 
 	var err error
 	switch row := row.(type) {
 	case *sql.Row:
-		err = row.Scan(&id, &username, &password, &role, &createdAt, &updatedAt)
+		err = row.Scan(&id, &username, &password, &role, &mustChange, &secret, &enabled, &counter, &timezone, &createdAt, &updatedAt)
 	case *sql.Rows:
-		err = row.Scan(&id, &username, &password, &role, &createdAt, &updatedAt)
+		err = row.Scan(&id, &username, &password, &role, &mustChange, &secret, &enabled, &counter, &timezone, &createdAt, &updatedAt)
 	default:
 		return nil, fmt.Errorf("unsupported row type")
 	}
@@ -171,10 +234,15 @@ func (r *userRepo) scanUser(row any) (*entity.User, error) {
 	}
 
 	user := &entity.User{
-		ID:       id,
-		Username: username,
-		Password: password,
-		Role:     entity.Role(role),
+		ID:                 id,
+		Username:           username,
+		Password:           password,
+		Role:               entity.Role(role),
+		MustChangePassword: mustChange,
+		TOTPSecret:         secret,
+		TOTPEnabled:        enabled,
+		TOTPLastCounter:    counter,
+		Timezone:           timezone,
 	}
 
 	// 处理时间字段
@@ -187,3 +255,11 @@ func (r *userRepo) scanUser(row any) (*entity.User, error) {
 
 	return user, nil
 }
+
+// userTimezone 未设置时区时使用默认值
+func userTimezone(user *entity.User) string {
+	if user.Timezone == "" {
+		return entity.DefaultTimezone
+	}
+	return user.Timezone
+}
@@ -0,0 +1,96 @@
+// Package respond 统一的JSON响应和错误格式
+//
+// 错误响应格式：
+//
+//	{"error": {"code": "not_found", "message": "todo不存在", "fields": [...], "requestId": "..."}}
+package respond
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"brb/internal/errs"
+	"brb/pkg/logger"
+)
+
+// ErrorBody 错误详情
+type ErrorBody struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Fields    []errs.FieldError `json:"fields,omitempty"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+var kindStatus = map[errs.Kind]int{
+	errs.KindNotFound:     http.StatusNotFound,
+	errs.KindValidation:   http.StatusBadRequest,
+	errs.KindConflict:     http.StatusConflict,
+	errs.KindForbidden:    http.StatusForbidden,
+	errs.KindUnauthorized: http.StatusUnauthorized,
+	errs.KindUnavailable:  http.StatusServiceUnavailable,
+}
+
+var statusCode = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusTooManyRequests:       "too_many_requests",
+	http.StatusInternalServerError:   "internal",
+	http.StatusBadGateway:            "bad_gateway",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+// Status 返回错误对应的HTTP状态码
+func Status(err error) int {
+	if status, ok := kindStatus[errs.KindOf(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error 按错误类型写出错误响应；未分类的错误记录日志并返回通用提示，避免泄露内部细节
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := errs.As(err)
+	if !ok || e.Kind == errs.KindInternal {
+		slog.ErrorContext(r.Context(), "internal error", "error", err)
+		Fail(w, r, http.StatusInternalServerError, "服务器内部错误")
+		return
+	}
+
+	code := e.Code
+	if code == "" {
+		code = string(e.Kind)
+	}
+	write(w, r, Status(err), ErrorBody{Code: code, Message: e.Message, Fields: e.Fields})
+}
+
+// Fail 写出handler自身产生的错误（如请求体无法解析），错误码由状态码决定
+func Fail(w http.ResponseWriter, r *http.Request, status int, message string) {
+	code, ok := statusCode[status]
+	if !ok {
+		code = "error"
+	}
+	write(w, r, status, ErrorBody{Code: code, Message: message})
+}
+
+func write(w http.ResponseWriter, r *http.Request, status int, body ErrorBody) {
+	body.RequestID = logger.RequestID(r.Context())
+	JSON(w, status, ErrorResponse{Error: body})
+}
+
+// JSON 写出JSON响应
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -19,4 +19,13 @@ type Router interface {
 
 	// 中间件支持
 	Use(mws ...middleware.Middleware)
+
+	// Routes 返回已注册的全部路由（包括其他分组注册的），按注册顺序
+	Routes() []Route
+}
+
+// Route 已注册的路由
+type Route struct {
+	Method string
+	Path   string
 }
@@ -3,6 +3,7 @@ package router
 import (
 	"brb/internal/middleware"
 	"net/http"
+	"slices"
 	"strings"
 )
 
@@ -11,11 +12,13 @@ type standardRouter struct {
 	prefix string
 	mux    *http.ServeMux
 	mws    []middleware.Middleware
+	routes *[]Route // 所有分组共享
 }
 
 func NewStandardRouter(mux *http.ServeMux) Router {
 	return &standardRouter{
-		mux: mux,
+		mux:    mux,
+		routes: &[]Route{},
 	}
 }
 
@@ -42,7 +45,7 @@ func (r *standardRouter) PATCH(path string, handler http.HandlerFunc, mws ...mid
 func (r *standardRouter) handle(method, path string, handler http.HandlerFunc, mws ...middleware.Middleware) {
 	fullPath := r.prefix + path
 
-	mws = append(r.mws, mws...)
+	mws = append(slices.Clone(r.mws), mws...)
 	// 应用中间件
 	h := http.Handler(handler)
 	for i := len(mws) - 1; i >= 0; i-- {
@@ -50,17 +53,24 @@ func (r *standardRouter) handle(method, path string, handler http.HandlerFunc, m
 	}
 
 	// 注册路由
-	r.mux.Handle(strings.ToUpper(method)+" "+fullPath, h)
+	method = strings.ToUpper(method)
+	r.mux.Handle(method+" "+fullPath, h)
+	*r.routes = append(*r.routes, Route{Method: method, Path: fullPath})
 }
 
 func (r *standardRouter) Group(prefix string) Router {
 	return &standardRouter{
 		prefix: r.prefix + prefix,
 		mux:    r.mux,
-		mws:    r.mws,
+		mws:    slices.Clone(r.mws), // 复制一份，避免兄弟分组共享底层数组
+		routes: r.routes,
 	}
 }
 
 func (r *standardRouter) Use(mws ...middleware.Middleware) {
 	r.mws = append(r.mws, mws...)
 }
+
+func (r *standardRouter) Routes() []Route {
+	return slices.Clone(*r.routes)
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"brb/internal/repo"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestDB 在临时目录中打开一个SQLite数据库，测试结束时关闭
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// must 初始化repository失败时终止测试
+func must[T any](t *testing.T) func(T, error) T {
+	return func(v T, err error) T {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("init repository: %v", err)
+		}
+		return v
+	}
+}
+
+// newTestUserService 基于真实repository创建userService
+func newTestUserService(t *testing.T, db *sql.DB) *userService {
+	t.Helper()
+	return NewUserService(
+		must[userRepository](t)(repo.NewUserRepo(db)),
+		must[loginAttemptRepository](t)(repo.NewLoginAttemptRepo(db)),
+		must[passwordResetRepository](t)(repo.NewPasswordResetRepo(db)),
+		must[recoveryCodeRepository](t)(repo.NewRecoveryCodeRepo(db)),
+		must[settingRepository](t)(repo.NewSettingRepo(db)),
+		DefaultLoginPolicy(),
+		DefaultPasswordPolicy(),
+	)
+}
+
+// newTestEventRepos 按app中的顺序创建sign、event及其关联和标签表
+func newTestEventRepos(t *testing.T, db *sql.DB) (signRepository, eventRepository, signLinkRepository) {
+	t.Helper()
+	signRepo := must[signRepository](t)(repo.NewSignRepo(db))
+	must[todoRepository](t)(repo.NewTodoRepo(db))
+	must[taskRepository](t)(repo.NewTaskRepo(db))
+	eventRepo := must[eventRepository](t)(repo.NewEventRepo(db))
+	links := must[signLinkRepository](t)(repo.NewSignLinkRepo(db))
+	must[tagRepository](t)(repo.NewTagRepo(db))
+	return signRepo, eventRepo, links
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// assignmentService 负责task和todo的指派以及指派历史
+type assignmentService struct {
+	assignmentRepo assignmentRepository
+	taskRepo       taskRepository
+	todoRepo       todoRepository
+	userRepo       userRepository
+}
+
+type assignmentRepository interface {
+	Assign(assignment *entity.Assignment) (bool, error)
+	GetByTarget(targetType entity.AssignmentTarget, targetID uint) ([]*entity.Assignment, error)
+}
+
+// NewAssignmentService 创建新的AssignmentService实例
+func NewAssignmentService(assignmentRepo assignmentRepository, taskRepo taskRepository, todoRepo todoRepository, userRepo userRepository) *assignmentService {
+	return &assignmentService{
+		assignmentRepo: assignmentRepo,
+		taskRepo:       taskRepo,
+		todoRepo:       todoRepo,
+		userRepo:       userRepo,
+	}
+}
+
+// AssignTask 将task指派给指定用户，assigneeID为nil表示取消指派
+func (s *assignmentService) AssignTask(ctx context.Context, taskID uint, assigneeID *uint, operatorID uint) (*entity.Task, error) {
+	if err := s.validateAssignee(assigneeID); err != nil {
+		return nil, err
+	}
+
+	if err := s.assign(ctx, entity.AssignmentTargetTask, taskID, assigneeID, operatorID); err != nil {
+		return nil, err
+	}
+
+	return s.taskRepo.GetByID(taskID)
+}
+
+// AssignTodo 将todo指派给指定用户，assigneeID为nil表示取消指派
+func (s *assignmentService) AssignTodo(ctx context.Context, todoID uint, assigneeID *uint, operatorID uint) (*entity.Todo, error) {
+	if err := s.validateAssignee(assigneeID); err != nil {
+		return nil, err
+	}
+
+	if err := s.assign(ctx, entity.AssignmentTargetTodo, todoID, assigneeID, operatorID); err != nil {
+		return nil, err
+	}
+
+	return s.todoRepo.GetByID(todoID)
+}
+
+// GetAssignmentHistory 获取task或todo的指派历史
+func (s *assignmentService) GetAssignmentHistory(targetType entity.AssignmentTarget, targetID uint) ([]*entity.Assignment, error) {
+	switch targetType {
+	case entity.AssignmentTargetTask:
+		if !s.taskRepo.HaveID(targetID) {
+			return nil, errs.NotFound("task不存在")
+		}
+	case entity.AssignmentTargetTodo:
+		if _, err := s.todoRepo.GetByID(targetID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errs.Validation("unsupported assignment target: %s", targetType)
+	}
+
+	return s.assignmentRepo.GetByTarget(targetType, targetID)
+}
+
+// GetTodosByAssignee 获取指派给用户的todo，status为空时返回全部
+func (s *assignmentService) GetTodosByAssignee(userID uint, status entity.Status) ([]*entity.Todo, error) {
+	todos, err := s.todoRepo.GetByAssigneeID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return todos, nil
+	}
+
+	filtered := make([]*entity.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if todo.Status == status {
+			filtered = append(filtered, todo)
+		}
+	}
+	return filtered, nil
+}
+
+// GetTasksByAssignee 获取指派给用户的task
+func (s *assignmentService) GetTasksByAssignee(userID uint) ([]*entity.Task, error) {
+	return s.taskRepo.GetByAssigneeID(userID)
+}
+
+// validateAssignee 检查被指派的用户是否存在
+func (s *assignmentService) validateAssignee(assigneeID *uint) error {
+	if assigneeID == nil {
+		return nil
+	}
+	if !s.userRepo.HaveID(*assigneeID) {
+		return errs.Validation("assignee user %d does not exist", *assigneeID)
+	}
+	return nil
+}
+
+// assign 更新负责人并写入一条指派变更记录，负责人未变化时不做任何修改
+func (s *assignmentService) assign(ctx context.Context, targetType entity.AssignmentTarget, targetID uint, to *uint, operatorID uint) error {
+	assignment := &entity.Assignment{
+		TargetType:   targetType,
+		TargetID:     targetID,
+		ToUserID:     to,
+		AssignedByID: operatorID,
+		CreatedAt:    time.Now(),
+	}
+	changed, err := s.assignmentRepo.Assign(assignment)
+	if err != nil || !changed {
+		return err
+	}
+
+	slog.InfoContext(ctx, "指派变更", "target_type", targetType, "target_id", targetID, "from", formatAssignee(assignment.FromUserID), "to", formatAssignee(to), "operator_id", operatorID)
+	return nil
+}
+
+// formatAssignee 格式化负责人用于日志输出
+func formatAssignee(id *uint) string {
+	if id == nil {
+		return "无"
+	}
+	return fmt.Sprintf("%d", *id)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+// newTestAssignmentService 创建用户1到3，以及event 1下未指派的task 1和它的todo 1
+func newTestAssignmentService(t *testing.T) *assignmentService {
+	t.Helper()
+	db := newTestDB(t)
+	_, eventRepo, _ := newTestEventRepos(t, db)
+	taskRepo := must[taskRepository](t)(repo.NewTaskRepo(db))
+	todoRepo := must[todoRepository](t)(repo.NewTodoRepo(db))
+	userRepo := must[userRepository](t)(repo.NewUserRepo(db))
+	assignmentRepo := must[assignmentRepository](t)(repo.NewAssignmentRepo(db))
+
+	for _, username := range []string{"alice", "bob", "carol"} {
+		if err := userRepo.Create(&entity.User{Username: username, Password: "x", Role: entity.RoleUser}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := eventRepo.Create(&entity.Event{Title: "release"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := taskRepo.Create(&entity.Task{Description: "write notes", EventID: 1, PreTaskIDs: []uint{}, Status: entity.StatusPending, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := todoRepo.Create(&entity.Todo{TaskID: 1, Status: entity.StatusPending}); err != nil {
+		t.Fatal(err)
+	}
+	return NewAssignmentService(assignmentRepo, taskRepo, todoRepo, userRepo)
+}
+
+// assignTo 按目标类型调用AssignTask或AssignTodo，返回指派后的负责人
+func assignTo(t *testing.T, s *assignmentService, target entity.AssignmentTarget, id uint, assigneeID *uint, operatorID uint) (*uint, error) {
+	if target == entity.AssignmentTargetTask {
+		task, err := s.AssignTask(t.Context(), id, assigneeID, operatorID)
+		if err != nil {
+			return nil, err
+		}
+		return task.AssigneeID, nil
+	}
+	todo, err := s.AssignTodo(t.Context(), id, assigneeID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	return todo.AssigneeID, nil
+}
+
+// formatHistory 将指派历史格式化为"原负责人>新负责人"
+func formatHistory(assignments []*entity.Assignment) []string {
+	history := make([]string, len(assignments))
+	for i, a := range assignments {
+		history[i] = formatAssignee(a.FromUserID) + ">" + formatAssignee(a.ToUserID)
+	}
+	return history
+}
+
+func TestAssign(t *testing.T) {
+	bob, carol, unknown := uint(2), uint(3), uint(99)
+
+	tests := []struct {
+		name string
+		// 依次指派的负责人，nil表示取消指派
+		steps       []*uint
+		targetID    uint
+		wantErr     errs.Kind
+		wantHistory []string
+	}{
+		{name: "assign", steps: []*uint{&bob}, targetID: 1, wantHistory: []string{"无>2"}},
+		{name: "reassign", steps: []*uint{&bob, &carol}, targetID: 1, wantHistory: []string{"无>2", "2>3"}},
+		{name: "unassign", steps: []*uint{&bob, nil}, targetID: 1, wantHistory: []string{"无>2", "2>无"}},
+		{name: "same assignee is a no-op", steps: []*uint{&bob, &bob}, targetID: 1, wantHistory: []string{"无>2"}},
+		{name: "unassigning an unassigned target is a no-op", steps: []*uint{nil}, targetID: 1, wantHistory: []string{}},
+		{name: "unknown user", steps: []*uint{&unknown}, targetID: 1, wantErr: errs.KindValidation, wantHistory: []string{}},
+		{name: "missing target", steps: []*uint{&bob}, targetID: 42, wantErr: errs.KindNotFound},
+	}
+	for _, target := range []entity.AssignmentTarget{entity.AssignmentTargetTask, entity.AssignmentTargetTodo} {
+		for _, tt := range tests {
+			t.Run(string(target)+"/"+tt.name, func(t *testing.T) {
+				s := newTestAssignmentService(t)
+
+				var err error
+				for _, assigneeID := range tt.steps {
+					var got *uint
+					got, err = assignTo(t, s, target, tt.targetID, assigneeID, 1)
+					if err != nil {
+						break
+					}
+					if !sameUserID(got, assigneeID) {
+						t.Fatalf("assignee = %s, want %s", formatAssignee(got), formatAssignee(assigneeID))
+					}
+				}
+				if got := errKind(err); got != tt.wantErr {
+					t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+				}
+
+				history, err := s.GetAssignmentHistory(target, tt.targetID)
+				if tt.wantHistory == nil {
+					if errKind(err) != errs.KindNotFound {
+						t.Fatalf("history err = %v, want not found", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := formatHistory(history); !slices.Equal(got, tt.wantHistory) {
+					t.Fatalf("history = %v, want %v", got, tt.wantHistory)
+				}
+				for _, a := range history {
+					if a.AssignedByID != 1 || a.TargetType != target || a.TargetID != tt.targetID {
+						t.Fatalf("history entry = %+v", a)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestConcurrentReassignments(t *testing.T) {
+	s := newTestAssignmentService(t)
+	const n = 6
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assigneeID := uint(i%3 + 1)
+			if _, err := s.AssignTask(t.Context(), 1, &assigneeID, 1); err != nil {
+				t.Errorf("assign %d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 每条记录的原负责人必须是上一条记录的新负责人
+	history, err := s.GetAssignmentHistory(entity.AssignmentTargetTask, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var previous *uint
+	for i, a := range history {
+		if !sameUserID(a.FromUserID, previous) {
+			t.Fatalf("entry %d records %s as the previous assignee, want %s: %v", i, formatAssignee(a.FromUserID), formatAssignee(previous), formatHistory(history))
+		}
+		previous = a.ToUserID
+	}
+	task, err := s.taskRepo.GetByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameUserID(task.AssigneeID, previous) {
+		t.Fatalf("assignee = %s, want %s from the last entry", formatAssignee(task.AssigneeID), formatAssignee(previous))
+	}
+}
+
+// sameUserID 判断两个可空的用户ID是否相同
+func sameUserID(a, b *uint) bool {
+	return formatAssignee(a) == formatAssignee(b)
+}
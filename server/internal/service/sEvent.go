@@ -15,6 +15,8 @@ type eventRepository interface {
 	Create(event *entity.Event) error
 	GetAll() ([]*entity.Event, error)
 	GetByID(id uint) (*entity.Event, error)
+	GetBySignID(signID int64) ([]*entity.Event, error)
+	GetByTags(f entity.TagFilter) ([]*entity.Event, error)
 	Update(event *entity.Event) error
 	Delete(id uint) error
 }
@@ -36,6 +38,10 @@ func (s *eventService) GetAllEvents() ([]*entity.Event, error) {
 	return s.eventRepo.GetAll()
 }
 
+// GetEventsByTags 获取带有指定标签的event
+func (s *eventService) GetEventsByTags(f entity.TagFilter) ([]*entity.Event, error) {
+	return s.eventRepo.GetByTags(f)
+}
 
 // GetEventByID 根据ID获取event
 func (s *eventService) GetEventByID(id uint) (*entity.Event, error) {
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// LoginPolicy 登录失败限制策略
+type LoginPolicy struct {
+	BackoffThreshold   int           // 连续失败达到该次数后开始指数退避
+	BackoffBase        time.Duration // 退避基础时长，此后每次失败翻倍
+	LockoutThreshold   int           // 同一用户名连续失败达到该次数后锁定
+	IPLockoutThreshold int           // 同一IP连续失败达到该次数后锁定
+	LockoutDuration    time.Duration // 锁定时长，同时也是退避时长的上限
+	ResetAfter         time.Duration // 超过该时长没有新的失败则清零计数
+}
+
+// DefaultLoginPolicy 返回默认的登录失败限制策略
+func DefaultLoginPolicy() LoginPolicy {
+	return LoginPolicy{
+		BackoffThreshold:   3,
+		BackoffBase:        time.Second,
+		LockoutThreshold:   10,
+		IPLockoutThreshold: 50,
+		LockoutDuration:    15 * time.Minute,
+		ResetAfter:         time.Hour,
+	}
+}
+
+type loginAttemptRepository interface {
+	Get(scope entity.LoginAttemptScope, key string) (*entity.LoginAttempt, error)
+	RecordFailure(scope entity.LoginAttemptScope, key, lastIP string, now, resetBefore time.Time) (*entity.LoginAttempt, error)
+	Lock(scope entity.LoginAttemptScope, key string, until time.Time) error
+	Delete(scope entity.LoginAttemptScope, key string) error
+}
+
+// lockoutThreshold 返回指定维度的锁定阈值
+func (p LoginPolicy) lockoutThreshold(scope entity.LoginAttemptScope) int {
+	if scope == entity.LoginScopeIP {
+		return p.IPLockoutThreshold
+	}
+	return p.LockoutThreshold
+}
+
+// backoff 计算第failures次失败后需要等待的时长
+func (p LoginPolicy) backoff(failures int) time.Duration {
+	if failures < p.BackoffThreshold {
+		return 0
+	}
+	delay := p.BackoffBase
+	for i := p.BackoffThreshold; i < failures && delay < p.LockoutDuration; i++ {
+		delay *= 2
+	}
+	return min(delay, p.LockoutDuration)
+}
+
+// loginAttempts 一次登录在用户名和IP两个维度上的失败记录
+type loginAttempts struct {
+	user *entity.LoginAttempt
+	ip   *entity.LoginAttempt
+}
+
+// beginLogin 读取用户名和IP的失败记录，处于锁定或退避期时返回LoginThrottledError
+func (s *userService) beginLogin(username, ip string, now time.Time) (*loginAttempts, error) {
+	userAttempt, err := s.loadAttempt(entity.LoginScopeUsername, username, now)
+	if err != nil {
+		return nil, err
+	}
+	ipAttempt, err := s.loadAttempt(entity.LoginScopeIP, ip, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkThrottle(userAttempt, now); err != nil {
+		return nil, err
+	}
+	if err := s.checkThrottle(ipAttempt, now); err != nil {
+		return nil, err
+	}
+	return &loginAttempts{user: userAttempt, ip: ipAttempt}, nil
+}
+
+// failLogin 在两个维度上各记录一次失败，用户名维度同时记下来源IP，以便解除锁定时一并清除
+func (s *userService) failLogin(ctx context.Context, attempts *loginAttempts, now time.Time) error {
+	if err := s.recordFailure(ctx, entity.LoginScopeUsername, attempts.user.Key, attempts.ip.Key, now); err != nil {
+		return err
+	}
+	return s.recordFailure(ctx, entity.LoginScopeIP, attempts.ip.Key, "", now)
+}
+
+// succeedLogin 登录成功后清除用户名和IP两个维度的失败计数
+func (s *userService) succeedLogin(attempts *loginAttempts) error {
+	for _, attempt := range []*entity.LoginAttempt{attempts.user, attempts.ip} {
+		if attempt.Failures == 0 {
+			continue
+		}
+		if err := s.attemptRepo.Delete(attempt.Scope, attempt.Key); err != nil {
+			return fmt.Errorf("failed to reset login attempts: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadAttempt 读取失败记录，过期的计数会被视为清零
+func (s *userService) loadAttempt(scope entity.LoginAttemptScope, key string, now time.Time) (*entity.LoginAttempt, error) {
+	attempt, err := s.attemptRepo.Get(scope, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login attempts: %w", err)
+	}
+
+	locked := attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil)
+	if !locked && attempt.LastFailureAt != nil && now.Sub(*attempt.LastFailureAt) > s.loginPolicy.ResetAfter {
+		attempt.Failures = 0
+		attempt.LastFailureAt = nil
+		attempt.LockedUntil = nil
+	}
+	return attempt, nil
+}
+
+// checkThrottle 检查是否处于锁定或退避期
+func (s *userService) checkThrottle(attempt *entity.LoginAttempt, now time.Time) error {
+	if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+		return &entity.LoginThrottledError{Until: *attempt.LockedUntil, Locked: true}
+	}
+
+	// 并发请求读取时钟后，其他请求可能已记下更晚的失败时间，未达退避阈值时不应因此被拒绝
+	if delay := s.loginPolicy.backoff(attempt.Failures); attempt.LastFailureAt != nil && delay > 0 {
+		next := attempt.LastFailureAt.Add(delay)
+		if now.Before(next) {
+			return &entity.LoginThrottledError{Until: next}
+		}
+	}
+	return nil
+}
+
+// recordFailure 在数据库中累加一次登录失败，按累加后的次数决定是否锁定
+func (s *userService) recordFailure(ctx context.Context, scope entity.LoginAttemptScope, key, lastIP string, now time.Time) error {
+	attempt, err := s.attemptRepo.RecordFailure(scope, key, lastIP, now, now.Add(-s.loginPolicy.ResetAfter))
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures >= s.loginPolicy.lockoutThreshold(scope) {
+		until := now.Add(s.loginPolicy.LockoutDuration)
+		if err := s.attemptRepo.Lock(scope, key, until); err != nil {
+			return fmt.Errorf("failed to lock login: %w", err)
+		}
+		slog.WarnContext(ctx, "登录锁定", "scope", scope, "key", key, "failures", attempt.Failures, "until", until)
+	}
+	return nil
+}
+
+// UnlockUser 解除用户的登录锁定（仅管理员可操作）
+func (s *userService) UnlockUser(ctx context.Context, id uint) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.clearLockout(user.Username); err != nil {
+		return fmt.Errorf("解除锁定失败: %w", err)
+	}
+
+	slog.InfoContext(ctx, "登录锁定已解除", "username", user.Username, "id", user.ID)
+	return nil
+}
+
+// clearLockout 清除用户名的失败记录，以及最近一次对该用户名登录失败的IP的记录
+func (s *userService) clearLockout(username string) error {
+	attempt, err := s.attemptRepo.Get(entity.LoginScopeUsername, username)
+	if err != nil {
+		return err
+	}
+	if err := s.attemptRepo.Delete(entity.LoginScopeUsername, username); err != nil {
+		return err
+	}
+	if attempt.LastIP == "" {
+		return nil
+	}
+	return s.attemptRepo.Delete(entity.LoginScopeIP, attempt.LastIP)
+}
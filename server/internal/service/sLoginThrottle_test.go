@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+func TestConcurrentLoginFailures(t *testing.T) {
+	const n = 8
+	s := newTestUserService(t, newTestDB(t))
+	// 不退避，第n次失败时锁定，使并发的n次请求都能通过检查
+	s.loginPolicy.BackoffThreshold = 100
+	s.loginPolicy.LockoutThreshold = n
+	s.loginPolicy.IPLockoutThreshold = n
+	if _, err := s.Register(t.Context(), "alice", "secret-pass-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i] = s.Login(t.Context(), "alice", "wrong-pass", "10.0.0.1")
+		}()
+	}
+	wg.Wait()
+	for i, err := range results {
+		if errs.KindOf(err) != errs.KindUnauthorized {
+			t.Fatalf("request %d: got %v, want unauthorized", i, err)
+		}
+	}
+
+	// 每次失败都必须计入，否则并发请求可以绕过锁定
+	for _, scope := range []struct {
+		scope entity.LoginAttemptScope
+		key   string
+	}{{entity.LoginScopeUsername, "alice"}, {entity.LoginScopeIP, "10.0.0.1"}} {
+		attempt, err := s.attemptRepo.Get(scope.scope, scope.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempt.Failures != n || attempt.LockedUntil == nil {
+			t.Fatalf("%s attempt = %d failures, locked until %v, want %d failures and locked", scope.scope, attempt.Failures, attempt.LockedUntil, n)
+		}
+	}
+
+	var throttled *entity.LoginThrottledError
+	if _, err := s.Login(t.Context(), "alice", "secret-pass-1", "10.0.0.2"); !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("login after the burst: got %v, want locked", err)
+	}
+}
+
+func TestLoginFailureCountResets(t *testing.T) {
+	s := newTestUserService(t, newTestDB(t))
+	now := time.Now()
+	for _, at := range []time.Time{now.Add(-3 * time.Hour), now.Add(-150 * time.Minute), now} {
+		if err := s.recordFailure(t.Context(), entity.LoginScopeUsername, "alice", "", at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 相隔超过ResetAfter的失败从1重新计数
+	attempt, err := s.attemptRepo.Get(entity.LoginScopeUsername, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.Failures != 1 {
+		t.Fatalf("failures = %d, want 1", attempt.Failures)
+	}
+}
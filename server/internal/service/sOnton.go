@@ -0,0 +1,131 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"fmt"
+)
+
+// ontonService 实现handler.ontonService接口
+type ontonService struct {
+	ontonRepo ontonRepository
+	signRepo  signRepository
+	policy    SignPolicy
+}
+
+type ontonRepository interface {
+	Create(onton *entity.Onton) error
+	GetAll() ([]*entity.Onton, error)
+	GetByID(id int64) (*entity.Onton, error)
+	Update(onton *entity.Onton) error
+	Delete(id int64) error
+	LinkSign(ontonID, signID int64) error
+	UnlinkSign(ontonID, signID int64) error
+	GetSigns(ontonID int64) ([]*entity.Sign, error)
+	GetBySignID(signID int64) ([]*entity.Onton, error)
+}
+
+// NewOntonService 创建新的OntonService实例，onton没有创建者，只有policy中的角色可以修改
+func NewOntonService(ontonRepo ontonRepository, signRepo signRepository, policy SignPolicy) *ontonService {
+	return &ontonService{
+		ontonRepo: ontonRepo,
+		signRepo:  signRepo,
+		policy:    policy,
+	}
+}
+
+// CreateOnton 创建新的onton
+func (s *ontonService) CreateOnton(onton *entity.Onton, role entity.Role) error {
+	if !s.policy.editsShared(role) {
+		return errs.Forbidden("无权创建onton")
+	}
+	if err := validateAdic(onton.Adic); err != nil {
+		return err
+	}
+	return s.ontonRepo.Create(onton)
+}
+
+// GetAllOntons 获取所有onton
+func (s *ontonService) GetAllOntons() ([]*entity.Onton, error) {
+	return s.ontonRepo.GetAll()
+}
+
+// GetOntonByID 根据ID获取onton
+func (s *ontonService) GetOntonByID(id int64) (*entity.Onton, error) {
+	return s.ontonRepo.GetByID(id)
+}
+
+// UpdateOnton 更新onton
+func (s *ontonService) UpdateOnton(onton *entity.Onton, role entity.Role) error {
+	if !s.policy.editsShared(role) {
+		return errs.Forbidden("无权修改onton")
+	}
+	if err := validateAdic(onton.Adic); err != nil {
+		return err
+	}
+	return s.ontonRepo.Update(onton)
+}
+
+// DeleteOnton 删除onton，与sign的关联一并删除
+func (s *ontonService) DeleteOnton(id int64, role entity.Role) error {
+	if !s.policy.editsShared(role) {
+		return errs.Forbidden("无权删除onton")
+	}
+	return s.ontonRepo.Delete(id)
+}
+
+// LinkSign 记录sign指称onton，用户须能修改该sign
+func (s *ontonService) LinkSign(ontonID, signID int64, userID uint, role entity.Role) error {
+	if err := s.checkSign(ontonID, signID, userID, role); err != nil {
+		return err
+	}
+	return s.ontonRepo.LinkSign(ontonID, signID)
+}
+
+// UnlinkSign 取消sign与onton的关联，用户须能修改该sign
+func (s *ontonService) UnlinkSign(ontonID, signID int64, userID uint, role entity.Role) error {
+	if err := s.checkSign(ontonID, signID, userID, role); err != nil {
+		return err
+	}
+	return s.ontonRepo.UnlinkSign(ontonID, signID)
+}
+
+// checkSign 检查onton和sign存在，且用户可以修改该sign的指称
+func (s *ontonService) checkSign(ontonID, signID int64, userID uint, role entity.Role) error {
+	if _, err := s.ontonRepo.GetByID(ontonID); err != nil {
+		return err
+	}
+	sign, err := s.signRepo.GetByID(signID)
+	if err != nil {
+		return err
+	}
+	if !s.policy.canEdit(sign, userID, role) {
+		return errs.Forbidden("无权修改他人创建的sign的指称")
+	}
+	return nil
+}
+
+// GetOntonSigns 获取指称onton的sign
+func (s *ontonService) GetOntonSigns(ontonID int64) ([]*entity.Sign, error) {
+	if _, err := s.ontonRepo.GetByID(ontonID); err != nil {
+		return nil, err
+	}
+	return s.ontonRepo.GetSigns(ontonID)
+}
+
+// GetSignOntons 获取sign指称的onton
+func (s *ontonService) GetSignOntons(signID int64) ([]*entity.Onton, error) {
+	if _, err := s.signRepo.GetByID(signID); err != nil {
+		return nil, err
+	}
+	return s.ontonRepo.GetBySignID(signID)
+}
+
+// validateAdic 元数须在entity.MinAdic到entity.MaxAdic之间
+func validateAdic(adic int8) error {
+	if adic < entity.MinAdic || adic > entity.MaxAdic {
+		message := fmt.Sprintf("必须在%d到%d之间", entity.MinAdic, entity.MaxAdic)
+		return errs.Validation("onton元数无效").WithFields(errs.FieldError{Field: "adic", Message: message})
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+// newTestOntonService 创建sign 1（cat）、sign 2（kitten）、二元onton 1和onton 2，策略允许管理员编辑
+func newTestOntonService(t *testing.T) (*sql.DB, *ontonService, *signService) {
+	t.Helper()
+	db := newTestDB(t)
+	signRepo, _, _ := newTestEventRepos(t, db)
+	policy := SignPolicy{EditorRoles: []entity.Role{entity.RoleAdmin}}
+	ontons := NewOntonService(must[ontonRepository](t)(repo.NewOntonRepo(db)), signRepo, policy)
+
+	for _, sign := range []*entity.Sign{{Signifier: "cat", CreatedBy: 1}, {Signifier: "kitten", CreatedBy: 1}} {
+		if err := signRepo.Create(sign); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 2 {
+		if err := ontons.CreateOnton(&entity.Onton{Adic: 2}, entity.RoleAdmin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, ontons, NewSignService(signRepo, policy)
+}
+
+func TestOntonAdic(t *testing.T) {
+	tests := []struct {
+		adic    int8
+		wantErr errs.Kind
+	}{
+		{-1, errs.KindValidation},
+		{0, errs.KindValidation},
+		{entity.MinAdic, ""},
+		{entity.MaxAdic, ""},
+		{entity.MaxAdic + 1, errs.KindValidation},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(int(tt.adic)), func(t *testing.T) {
+			_, ontons, _ := newTestOntonService(t)
+
+			created := &entity.Onton{Adic: tt.adic}
+			createErr := ontons.CreateOnton(created, entity.RoleAdmin)
+			updateErr := ontons.UpdateOnton(&entity.Onton{ID: 1, Adic: tt.adic}, entity.RoleAdmin)
+			for _, err := range []error{createErr, updateErr} {
+				if got := errKind(err); got != tt.wantErr {
+					t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+				}
+				var e *errs.Error
+				if err != nil && (!errors.As(err, &e) || len(e.Fields) != 1 || e.Fields[0].Field != "adic") {
+					t.Fatalf("err = %v, want a field error on adic", err)
+				}
+			}
+
+			all, err := ontons.GetAllOntons()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantCount, wantAdic := 2, int8(2)
+			if tt.wantErr == "" {
+				wantCount, wantAdic = 3, tt.adic
+			}
+			if len(all) != wantCount || all[0].Adic != wantAdic {
+				t.Fatalf("ontons = %d, onton 1 adic = %d, want %d, %d", len(all), all[0].Adic, wantCount, wantAdic)
+			}
+		})
+	}
+}
+
+func TestOntonSigns(t *testing.T) {
+	const cat, kitten = 1, 2
+
+	tests := []struct {
+		name    string
+		write   func(ontons *ontonService, signs *signService) error
+		wantErr errs.Kind
+		// 操作后onton 1的sign和sign 2（kitten）的onton
+		wantSigns  []string
+		wantOntons []int64
+	}{
+		{
+			name: "link",
+			write: func(ontons *ontonService, _ *signService) error {
+				return ontons.LinkSign(1, kitten, 1, entity.RoleUser)
+			},
+			wantSigns: []string{"cat", "kitten"}, wantOntons: []int64{1},
+		},
+		{
+			name: "link twice",
+			write: func(ontons *ontonService, _ *signService) error {
+				return ontons.LinkSign(1, cat, 1, entity.RoleUser)
+			},
+			wantSigns: []string{"cat"}, wantOntons: []int64{},
+		},
+		{
+			name: "unlink",
+			write: func(ontons *ontonService, _ *signService) error {
+				return ontons.UnlinkSign(1, cat, 1, entity.RoleUser)
+			},
+			wantSigns: []string{}, wantOntons: []int64{},
+		},
+		{
+			name: "unlink a sign that is not linked",
+			write: func(ontons *ontonService, _ *signService) error {
+				return ontons.UnlinkSign(1, kitten, 1, entity.RoleUser)
+			},
+			wantSigns: []string{"cat"}, wantOntons: []int64{},
+		},
+		{
+			name: "link to another onton",
+			write: func(ontons *ontonService, _ *signService) error {
+				return ontons.LinkSign(2, kitten, 1, entity.RoleUser)
+			},
+			wantSigns: []string{"cat"}, wantOntons: []int64{2},
+		},
+		{
+			name: "missing onton",
+			write: func(ontons *ontonService, _ *signService) error {
+				return ontons.LinkSign(99, kitten, 1, entity.RoleUser)
+			},
+			wantErr:   errs.KindNotFound,
+			wantSigns: []string{"cat"}, wantOntons: []int64{},
+		},
+		{
+			name: "missing sign",
+			write: func(ontons *ontonService, _ *signService) error {
+				return ontons.LinkSign(1, 99, 1, entity.RoleUser)
+			},
+			wantErr:   errs.KindNotFound,
+			wantSigns: []string{"cat"}, wantOntons: []int64{},
+		},
+		{
+			name: "deleting a linked sign removes the link",
+			write: func(_ *ontonService, signs *signService) error {
+				return signs.DeleteSign(cat, 1, entity.RoleUser)
+			},
+			wantSigns: []string{}, wantOntons: []int64{},
+		},
+		{
+			name: "deleting an onton removes its links",
+			write: func(ontons *ontonService, _ *signService) error {
+				if err := ontons.LinkSign(1, kitten, 1, entity.RoleUser); err != nil {
+					return err
+				}
+				return ontons.DeleteOnton(1, entity.RoleAdmin)
+			},
+			wantSigns: nil, wantOntons: []int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ontons, signs := newTestOntonService(t)
+			if err := ontons.LinkSign(1, cat, 1, entity.RoleUser); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := errKind(tt.write(ontons, signs)); got != tt.wantErr {
+				t.Fatalf("err kind = %q, want %q", got, tt.wantErr)
+			}
+
+			linked, err := ontons.GetOntonSigns(1)
+			if tt.wantSigns == nil {
+				if errKind(err) != errs.KindNotFound {
+					t.Fatalf("onton signs err = %v, want not found", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				signifiers := make([]string, len(linked))
+				for i, sign := range linked {
+					signifiers[i] = sign.Signifier
+				}
+				if !slices.Equal(signifiers, tt.wantSigns) {
+					t.Fatalf("onton 1 signs = %v, want %v", signifiers, tt.wantSigns)
+				}
+			}
+
+			denoted, err := ontons.GetSignOntons(kitten)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int64, len(denoted))
+			for i, onton := range denoted {
+				ids[i] = onton.ID
+			}
+			if !slices.Equal(ids, tt.wantOntons) {
+				t.Fatalf("kitten ontons = %v, want %v", ids, tt.wantOntons)
+			}
+		})
+	}
+}
+
+func TestDeletedSignOntons(t *testing.T) {
+	db, ontons, signs := newTestOntonService(t)
+	if err := ontons.LinkSign(1, 1, 1, entity.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if err := signs.DeleteSign(1, 1, entity.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ontons.GetSignOntons(1); errKind(err) != errs.KindNotFound {
+		t.Fatalf("err = %v, want not found for a deleted sign", err)
+	}
+	var links int
+	if err := db.QueryRow("SELECT COUNT(*) FROM onton_signs").Scan(&links); err != nil {
+		t.Fatal(err)
+	}
+	if links != 0 {
+		t.Fatalf("%d links left after deleting the sign", links)
+	}
+	// 重新关联已删除的sign失败，不留下悬空的关联
+	if err := ontons.LinkSign(1, 1, 1, entity.RoleUser); errKind(err) != errs.KindNotFound {
+		t.Fatalf("err = %v, want not found", err)
+	}
+}
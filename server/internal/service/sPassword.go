@@ -0,0 +1,253 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordPolicy 密码策略
+type PasswordPolicy struct {
+	MinLength     int           // 最小长度
+	RequireUpper  bool          // 需要大写字母
+	RequireLower  bool          // 需要小写字母
+	RequireDigit  bool          // 需要数字
+	RequireSymbol bool          // 需要特殊字符
+	Blocklist     []string      // 禁止使用的常见密码（不区分大小写）
+	ResetTokenTTL time.Duration // 重置令牌有效期
+}
+
+// commonPasswords 常见弱密码列表
+var commonPasswords = []string{
+	"123456", "123456789", "12345678", "1234567890", "111111", "000000",
+	"password", "password1", "password123", "passw0rd", "qwerty", "qwerty123",
+	"qwertyuiop", "abc123", "abcd1234", "1q2w3e4r", "1qaz2wsx", "iloveyou",
+	"admin", "admin123", "administrator", "welcome", "welcome1", "letmein",
+	"monkey", "dragon", "sunshine", "princess", "football", "baseball",
+	"superman", "trustno1", "changeme", "secret", "master", "woaini1314",
+	"a123456", "aa123456", "5201314", "654321", "666666", "888888",
+}
+
+// DefaultPasswordPolicy 返回默认的密码策略
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:     8,
+		RequireLower:  true,
+		RequireDigit:  true,
+		Blocklist:     commonPasswords,
+		ResetTokenTTL: 24 * time.Hour,
+	}
+}
+
+// ReadPasswordBlocklist 读取禁止使用的密码列表，每行一个，忽略空行和以#开头的行
+func ReadPasswordBlocklist(r io.Reader) ([]string, error) {
+	var blocklist []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist = append(blocklist, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read password blocklist: %w", err)
+	}
+	return blocklist, nil
+}
+
+// Validate 检查密码是否满足策略
+func (p PasswordPolicy) Validate(username, password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return errs.Validation("密码长度不能少于%d位", p.MinLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		return errs.Validation("密码必须包含大写字母")
+	}
+	if p.RequireLower && !hasLower {
+		return errs.Validation("密码必须包含小写字母")
+	}
+	if p.RequireDigit && !hasDigit {
+		return errs.Validation("密码必须包含数字")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return errs.Validation("密码必须包含特殊字符")
+	}
+
+	for _, common := range p.Blocklist {
+		if strings.EqualFold(password, common) {
+			return errs.Validation("密码过于常见，请更换")
+		}
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return errs.Validation("密码不能包含用户名")
+	}
+	return nil
+}
+
+type passwordResetRepository interface {
+	Create(token *entity.PasswordResetToken) error
+	GetByHash(tokenHash string) (*entity.PasswordResetToken, error)
+	Redeem(token *entity.PasswordResetToken, passwordHash string, usedAt time.Time) error
+	DeleteByUserID(userID uint) error
+}
+
+// hashPassword 校验密码策略并生成bcrypt哈希
+func (s *userService) hashPassword(username, password string) (string, error) {
+	if err := s.passwordPolicy.Validate(username, password); err != nil {
+		return "", err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("密码加密失败: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// IssuePasswordResetToken 为用户签发一次性密码重置令牌（仅管理员可操作），返回令牌明文
+func (s *userService) IssuePasswordResetToken(ctx context.Context, userID, adminID uint) (string, time.Time, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return "", time.Time{}, errs.NotFound("用户不存在")
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, fmt.Errorf("生成重置令牌失败: %w", err)
+	}
+	plain := base64.RawURLEncoding.EncodeToString(raw)
+
+	// 新令牌签发后旧令牌全部作废
+	if err := s.resetRepo.DeleteByUserID(user.ID); err != nil {
+		return "", time.Time{}, fmt.Errorf("作废旧令牌失败: %w", err)
+	}
+
+	now := time.Now()
+	token := &entity.PasswordResetToken{
+		UserID:      user.ID,
+		TokenHash:   hashResetToken(plain),
+		ExpiresAt:   now.Add(s.passwordPolicy.ResetTokenTTL),
+		CreatedByID: adminID,
+		CreatedAt:   now,
+	}
+	if err := s.resetRepo.Create(token); err != nil {
+		return "", time.Time{}, fmt.Errorf("保存重置令牌失败: %w", err)
+	}
+
+	slog.InfoContext(ctx, "已签发密码重置令牌", "username", user.Username, "id", user.ID, "issuer_id", adminID)
+	return plain, token.ExpiresAt, nil
+}
+
+// ResetPassword 使用一次性令牌重置密码
+func (s *userService) ResetPassword(ctx context.Context, plainToken, newPassword string) error {
+	token, err := s.resetRepo.GetByHash(hashResetToken(plainToken))
+	if err != nil {
+		return errs.Validation("重置令牌无效")
+	}
+
+	now := time.Now()
+	if token.UsedAt != nil || now.After(token.ExpiresAt) {
+		return errs.Validation("重置令牌已失效")
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := s.hashPassword(user.Username, newPassword)
+	if err != nil {
+		return err
+	}
+
+	// 令牌的消费和密码的更新在同一事务中完成，并发使用同一令牌时只有一个请求成功
+	if err := s.resetRepo.Redeem(token, hashed, now); err != nil {
+		return err
+	}
+
+	// 重置密码后解除该用户的登录锁定
+	if err := s.clearLockout(user.Username); err != nil {
+		return fmt.Errorf("解除锁定失败: %w", err)
+	}
+
+	slog.InfoContext(ctx, "用户已通过重置令牌修改密码", "username", user.Username, "id", user.ID)
+	return nil
+}
+
+// ForcePasswordChange 要求用户在下次登录时修改密码（仅管理员可操作）
+func (s *userService) ForcePasswordChange(ctx context.Context, id uint) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	user.MustChangePassword = true
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("更新用户失败: %w", err)
+	}
+
+	slog.InfoContext(ctx, "用户被要求在下次登录时修改密码", "username", user.Username, "id", user.ID)
+	return nil
+}
+
+// ChangeExpiredPassword 未登录状态下修改已被要求更换的密码，成功后返回用户
+func (s *userService) ChangeExpiredPassword(ctx context.Context, username, oldPassword, newPassword, ip string) (*entity.User, error) {
+	user, err := s.authenticate(ctx, username, oldPassword, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	if newPassword == oldPassword {
+		return nil, errs.Validation("新密码不能与旧密码相同")
+	}
+
+	hashed, err := s.hashPassword(user.Username, newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = hashed
+	user.MustChangePassword = false
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("密码更新失败: %w", err)
+	}
+
+	slog.InfoContext(ctx, "用户密码已修改", "username", user.Username, "id", user.ID)
+	return user, nil
+}
+
+// hashResetToken 计算重置令牌的摘要，数据库只保存摘要
+func hashResetToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
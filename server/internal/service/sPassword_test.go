@@ -0,0 +1,111 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"brb/internal/errs"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestResetPasswordSingleUse(t *testing.T) {
+	const newPassword = "n3w-secret-pass"
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		uses     int // 依次使用同一令牌的次数
+		wantKind []errs.Kind
+	}{
+		{name: "first use", ttl: time.Hour, uses: 1, wantKind: []errs.Kind{""}},
+		{name: "reuse", ttl: time.Hour, uses: 2, wantKind: []errs.Kind{"", errs.KindValidation}},
+		{name: "expired", ttl: -time.Minute, uses: 1, wantKind: []errs.Kind{errs.KindValidation}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestUserService(t, newTestDB(t))
+			s.passwordPolicy.ResetTokenTTL = tt.ttl
+			user, err := s.Register(t.Context(), "alice", "old-secret-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, _, err := s.IssuePasswordResetToken(t.Context(), user.ID, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range tt.uses {
+				err := s.ResetPassword(t.Context(), token, newPassword)
+				if got := errKind(err); got != tt.wantKind[i] {
+					t.Fatalf("use %d: got error %v, want kind %q", i+1, err, tt.wantKind[i])
+				}
+			}
+		})
+	}
+
+	t.Run("unknown token", func(t *testing.T) {
+		s := newTestUserService(t, newTestDB(t))
+		if err := s.ResetPassword(t.Context(), "no-such-token", newPassword); errs.KindOf(err) != errs.KindValidation {
+			t.Fatalf("got %v, want validation error", err)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		s := newTestUserService(t, newTestDB(t))
+		user, err := s.Register(t.Context(), "bob", "old-secret-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, _, err := s.IssuePasswordResetToken(t.Context(), user.ID, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const n = 5
+		var wg sync.WaitGroup
+		results := make([]error, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = s.ResetPassword(t.Context(), token, "concurrent-pass-"+string(rune('a'+i))+"1")
+			}()
+		}
+		wg.Wait()
+
+		succeeded := 0
+		winner := -1
+		for i, err := range results {
+			switch {
+			case err == nil:
+				succeeded++
+				winner = i
+			case errs.KindOf(err) != errs.KindValidation:
+				t.Errorf("request %d: unexpected error %v", i, err)
+			}
+		}
+		if succeeded != 1 {
+			t.Fatalf("%d requests consumed the token, want exactly 1", succeeded)
+		}
+
+		// 生效的必须是成功那次请求设置的密码
+		stored, err := s.userRepo.GetByID(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "concurrent-pass-" + string(rune('a'+winner)) + "1"
+		if bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte(want)) != nil {
+			t.Fatalf("stored password is not the one set by the successful request")
+		}
+	})
+}
+
+// errKind 返回错误类型，nil返回空字符串
+func errKind(err error) errs.Kind {
+	if err == nil {
+		return ""
+	}
+	return errs.KindOf(err)
+}
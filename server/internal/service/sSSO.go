@@ -0,0 +1,247 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// unusablePassword SSO开通的用户没有本地密码，该值不是合法的bcrypt哈希，密码登录永远失败
+const unusablePassword = "!"
+
+// SSOPolicy 外部身份登录的用户开通与角色映射策略
+type SSOPolicy struct {
+	RoleClaim    string   // 用于映射角色的声明名（如groups），为空时不同步角色
+	AdminValues  []string // 声明中包含其中任一值时映射为管理员，否则为普通用户
+	LinkExisting bool     // 首次登录时是否关联用户名与身份提供方已验证邮箱相同的本地用户
+}
+
+// ssoService 处理外部身份（OIDC）登录
+type ssoService struct {
+	userRepo     userRepository
+	identityRepo identityRepository
+	policy       SSOPolicy
+}
+
+type identityRepository interface {
+	Create(identity *entity.ExternalIdentity) error
+	GetBySubject(issuer, subject string) (*entity.ExternalIdentity, error)
+}
+
+// NewSSOService 创建新的SSOService实例
+func NewSSOService(userRepo userRepository, identityRepo identityRepository, policy SSOPolicy) *ssoService {
+	return &ssoService{
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		policy:       policy,
+	}
+}
+
+// ExternalLogin 外部身份登录：首次登录时开通或关联本地用户，之后每次按声明同步角色
+// 与密码登录一样，被要求修改密码的用户返回ErrPasswordChangeRequired，两步验证由handler处理
+func (s *ssoService) ExternalLogin(ctx context.Context, issuer, subject string, claims map[string]any) (*entity.User, error) {
+	var user *entity.User
+
+	identity, err := s.identityRepo.GetBySubject(issuer, subject)
+	switch {
+	case err == nil:
+		user, err = s.userRepo.GetByID(identity.UserID)
+		if errs.IsNotFound(err) {
+			return nil, errs.NotFound("外部身份关联的用户不存在")
+		}
+		if err != nil {
+			return nil, err
+		}
+	case errs.IsNotFound(err):
+		user, err = s.provision(ctx, issuer, subject, claims)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if role, ok := s.mapRole(claims); ok && role != user.Role {
+		slog.InfoContext(ctx, "SSO角色同步", "username", user.Username, "id", user.ID, "from", user.Role, "to", role)
+		user.Role = role
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("同步用户角色失败: %w", err)
+		}
+	}
+
+	if user.MustChangePassword {
+		return nil, entity.ErrPasswordChangeRequired
+	}
+
+	slog.InfoContext(ctx, "用户SSO登录成功", "username", user.Username, "id", user.ID)
+	return user, nil
+}
+
+// LinkIdentity 将外部身份关联到已登录的本地用户，该身份已关联其他用户时返回Conflict
+func (s *ssoService) LinkIdentity(ctx context.Context, userID uint, issuer, subject string) (*entity.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := s.identityRepo.GetBySubject(issuer, subject)
+	switch {
+	case err == nil && identity.UserID == user.ID:
+		return user, nil
+	case err == nil:
+		return nil, errs.Conflict("该外部身份已关联到其他用户")
+	case !errs.IsNotFound(err):
+		return nil, err
+	}
+
+	if err := s.link(user, issuer, subject); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "SSO身份已由用户关联", "username", user.Username, "id", user.ID, "issuer", issuer, "subject", subject)
+	return user, nil
+}
+
+// provision 为首次登录的外部身份关联已有用户或创建新用户
+// preferred_username和未验证的邮箱可由用户在身份提供方自行修改，不能作为关联依据：
+// 只关联用户名等于已验证邮箱的本地用户，用户名被其他本地用户占用时拒绝，由该用户登录后显式关联
+func (s *ssoService) provision(ctx context.Context, issuer, subject string, claims map[string]any) (*entity.User, error) {
+	username := usernameFromClaims(subject, claims)
+
+	if s.policy.LinkExisting {
+		if email, ok := verifiedEmail(claims); ok {
+			existing, err := s.userRepo.GetByUsername(email)
+			if err == nil {
+				if err := s.link(existing, issuer, subject); err != nil {
+					return nil, err
+				}
+				slog.InfoContext(ctx, "SSO身份已按已验证邮箱关联到现有用户", "username", existing.Username, "id", existing.ID, "issuer", issuer, "subject", subject)
+				return existing, nil
+			}
+			if !errs.IsNotFound(err) {
+				return nil, err
+			}
+		}
+
+		exists, err := s.userRepo.ExistsByUsername(username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check username existence: %w", err)
+		}
+		if exists {
+			return nil, errs.Conflict("用户名%q已被本地用户使用，请先以该用户登录后关联外部身份", username).WithCode("identity_link_required")
+		}
+	}
+
+	unique, err := s.uniqueUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &entity.User{
+		Username:  unique,
+		Password:  unusablePassword,
+		Role:      entity.RoleUser,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := s.link(user, issuer, subject); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "SSO用户已开通", "username", user.Username, "id", user.ID, "issuer", issuer, "subject", subject)
+	return user, nil
+}
+
+// link 记录外部身份与本地用户的关联
+func (s *ssoService) link(user *entity.User, issuer, subject string) error {
+	identity := &entity.ExternalIdentity{
+		UserID:    user.ID,
+		Issuer:    issuer,
+		Subject:   subject,
+		CreatedAt: time.Now(),
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
+
+// verifiedEmail 返回身份提供方声明已验证的邮箱，email_verified可能是布尔值或字符串
+func verifiedEmail(claims map[string]any) (string, bool) {
+	email, _ := claims["email"].(string)
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", false
+	}
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		return email, verified
+	case string:
+		return email, verified == "true"
+	}
+	return "", false
+}
+
+// uniqueUsername 用户名已被占用时追加数字后缀
+func (s *ssoService) uniqueUsername(base string) (string, error) {
+	candidate := base
+	for i := 2; ; i++ {
+		exists, err := s.userRepo.ExistsByUsername(candidate)
+		if err != nil {
+			return "", fmt.Errorf("failed to check username existence: %w", err)
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// mapRole 根据配置的声明映射角色，未配置或声明缺失时不修改
+func (s *ssoService) mapRole(claims map[string]any) (entity.Role, bool) {
+	if s.policy.RoleClaim == "" {
+		return "", false
+	}
+
+	raw, ok := claims[s.policy.RoleClaim]
+	if !ok {
+		return "", false
+	}
+
+	var values []string
+	switch v := raw.(type) {
+	case string:
+		values = strings.Fields(v)
+	case []any:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+
+	for _, value := range values {
+		if slices.Contains(s.policy.AdminValues, value) {
+			return entity.RoleAdmin, true
+		}
+	}
+	return entity.RoleUser, true
+}
+
+// usernameFromClaims 依次使用preferred_username、email、sub生成用户名
+func usernameFromClaims(subject string, claims map[string]any) string {
+	for _, key := range []string{"preferred_username", "email"} {
+		if value, ok := claims[key].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return "sso-" + subject
+}
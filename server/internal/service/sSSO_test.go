@@ -0,0 +1,242 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+const testIssuer = "https://idp.test"
+
+func newTestSSOService(t *testing.T, db *sql.DB, policy SSOPolicy) *ssoService {
+	t.Helper()
+	return NewSSOService(
+		must[userRepository](t)(repo.NewUserRepo(db)),
+		must[identityRepository](t)(repo.NewIdentityRepo(db)),
+		policy,
+	)
+}
+
+func TestExternalLoginProvisioning(t *testing.T) {
+	tests := []struct {
+		name         string
+		linkExisting bool
+		local        string // 已存在的本地用户名，为空时不创建
+		claims       map[string]any
+		wantUsername string
+		wantLinked   bool      // 是否关联到已存在的本地用户
+		wantErr      errs.Kind // 期望的错误类型
+	}{
+		{
+			name:         "new user from preferred_username",
+			claims:       map[string]any{"preferred_username": "alice"},
+			wantUsername: "alice",
+		},
+		{
+			name:         "new user from email",
+			claims:       map[string]any{"email": "alice@example.com"},
+			wantUsername: "alice@example.com",
+		},
+		{
+			name:         "new user from subject",
+			claims:       map[string]any{},
+			wantUsername: "sso-sub-1",
+		},
+		{
+			name:         "taken username gets suffix when linking is off",
+			local:        "alice",
+			claims:       map[string]any{"preferred_username": "alice", "email": "alice", "email_verified": true},
+			wantUsername: "alice-2",
+		},
+		{
+			name:         "link by verified email",
+			linkExisting: true,
+			local:        "alice@example.com",
+			claims:       map[string]any{"preferred_username": "al", "email": "alice@example.com", "email_verified": true},
+			wantUsername: "alice@example.com",
+			wantLinked:   true,
+		},
+		{
+			name:         "link by verified email sent as string",
+			linkExisting: true,
+			local:        "alice@example.com",
+			claims:       map[string]any{"email": "alice@example.com", "email_verified": "true"},
+			wantUsername: "alice@example.com",
+			wantLinked:   true,
+		},
+		{
+			name:         "unverified email is not linked",
+			linkExisting: true,
+			local:        "alice@example.com",
+			claims:       map[string]any{"email": "alice@example.com", "email_verified": false},
+			wantErr:      errs.KindConflict,
+		},
+		{
+			name:         "preferred_username is never linked",
+			linkExisting: true,
+			local:        "admin",
+			claims:       map[string]any{"preferred_username": "admin"},
+			wantErr:      errs.KindConflict,
+		},
+		{
+			name:         "verified email without local user provisions",
+			linkExisting: true,
+			local:        "someone-else",
+			claims:       map[string]any{"email": "bob@example.com", "email_verified": true},
+			wantUsername: "bob@example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			users := newTestUserService(t, db)
+			s := newTestSSOService(t, db, SSOPolicy{LinkExisting: tt.linkExisting})
+
+			var local *entity.User
+			if tt.local != "" {
+				var err error
+				if local, err = users.Register(t.Context(), tt.local, "local-pass-1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			user, err := s.ExternalLogin(t.Context(), testIssuer, "sub-1", tt.claims)
+			if tt.wantErr != "" {
+				if errs.KindOf(err) != tt.wantErr {
+					t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+				}
+				if _, err := s.identityRepo.GetBySubject(testIssuer, "sub-1"); !errs.IsNotFound(err) {
+					t.Fatalf("identity was linked despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Username != tt.wantUsername {
+				t.Fatalf("username = %q, want %q", user.Username, tt.wantUsername)
+			}
+			if linked := local != nil && user.ID == local.ID; linked != tt.wantLinked {
+				t.Fatalf("linked to local user = %v, want %v", linked, tt.wantLinked)
+			}
+
+			// 再次登录使用已关联的用户
+			again, err := s.ExternalLogin(t.Context(), testIssuer, "sub-1", tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.ID != user.ID {
+				t.Fatalf("second login returned user %d, want %d", again.ID, user.ID)
+			}
+		})
+	}
+}
+
+func TestExternalLoginRoleSync(t *testing.T) {
+	policy := SSOPolicy{RoleClaim: "groups", AdminValues: []string{"brb-admins"}}
+	tests := []struct {
+		name     string
+		policy   SSOPolicy
+		initial  entity.Role
+		claims   map[string]any
+		wantRole entity.Role
+	}{
+		{"promote from list claim", policy, entity.RoleUser, map[string]any{"groups": []any{"staff", "brb-admins"}}, entity.RoleAdmin},
+		{"promote from space separated claim", policy, entity.RoleUser, map[string]any{"groups": "staff brb-admins"}, entity.RoleAdmin},
+		{"demote when admin value removed", policy, entity.RoleAdmin, map[string]any{"groups": []any{"staff"}}, entity.RoleUser},
+		{"missing claim keeps role", policy, entity.RoleAdmin, map[string]any{}, entity.RoleAdmin},
+		{"sync disabled keeps role", SSOPolicy{}, entity.RoleAdmin, map[string]any{"groups": []any{"staff"}}, entity.RoleAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			s := newTestSSOService(t, db, tt.policy)
+
+			user, err := s.ExternalLogin(t.Context(), testIssuer, "sub-1", map[string]any{"preferred_username": "alice"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			user.Role = tt.initial
+			if err := s.userRepo.Update(user); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.claims["preferred_username"] = "alice"
+			user, err = s.ExternalLogin(t.Context(), testIssuer, "sub-1", tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored, err := s.userRepo.GetByID(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Role != tt.wantRole || stored.Role != tt.wantRole {
+				t.Fatalf("role = %s (stored %s), want %s", user.Role, stored.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestExternalLoginPasswordChangeRequired(t *testing.T) {
+	db := newTestDB(t)
+	users := newTestUserService(t, db)
+	s := newTestSSOService(t, db, SSOPolicy{})
+
+	user, err := s.ExternalLogin(t.Context(), testIssuer, "sub-1", map[string]any{"preferred_username": "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := users.ForcePasswordChange(t.Context(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ExternalLogin(t.Context(), testIssuer, "sub-1", map[string]any{}); err != entity.ErrPasswordChangeRequired {
+		t.Fatalf("err = %v, want ErrPasswordChangeRequired", err)
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	db := newTestDB(t)
+	users := newTestUserService(t, db)
+	s := newTestSSOService(t, db, SSOPolicy{})
+
+	alice, err := users.Register(t.Context(), "alice", "local-pass-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := users.Register(t.Context(), "bob", "local-pass-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  uint
+		subject string
+		wantErr errs.Kind
+	}{
+		{"link", alice.ID, "sub-1", ""},
+		{"link again is idempotent", alice.ID, "sub-1", ""},
+		{"identity of another user", bob.ID, "sub-1", errs.KindConflict},
+		{"unknown user", 999, "sub-2", errs.KindNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.LinkIdentity(t.Context(), tt.userID, testIssuer, tt.subject)
+			if got := errKind(err); got != tt.wantErr {
+				t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 关联后以外部身份登录得到本地用户
+	user, err := s.ExternalLogin(t.Context(), testIssuer, "sub-1", map[string]any{"preferred_username": "someone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != alice.ID {
+		t.Fatalf("login returned user %d, want %d", user.ID, alice.ID)
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+// searchService 实现handler.searchService接口
+type searchService struct {
+	searchRepo searchRepository
+}
+
+type searchRepository interface {
+	Search(q entity.SearchQuery) (*entity.SearchResults, error)
+}
+
+// NewSearchService 创建新的SearchService实例
+func NewSearchService(searchRepo searchRepository) *searchService {
+	return &searchService{searchRepo: searchRepo}
+}
+
+// Search 在event、task、todo和sign中全文搜索，结果按类型分组、各自按相关度排序
+// 默认只搜索调用者的event、task和todo，只有管理员可以将q.UserID设为nil以搜索全部用户的
+func (s *searchService) Search(q entity.SearchQuery, role entity.Role) (*entity.SearchResults, error) {
+	if q.UserID == nil && role != entity.RoleAdmin {
+		return nil, errs.Forbidden("只有管理员可以搜索其他用户的task和todo")
+	}
+	return s.searchRepo.Search(q)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+// newTestSearchService 创建两个用户各自的event、task和todo：
+// event 1、task 1、todo 1分配给用户1，event 2、task 2、todo 2分配给用户2，sign 1公开
+func newTestSearchService(t *testing.T) *searchService {
+	t.Helper()
+	db := newTestDB(t)
+	signRepo, eventRepo, _ := newTestEventRepos(t, db)
+	taskRepo := must[taskRepository](t)(repo.NewTaskRepo(db))
+	todoRepo := must[todoRepository](t)(repo.NewTodoRepo(db))
+	searchRepo := must[searchRepository](t)(repo.NewSearchRepo(db))
+
+	for i, owner := range []struct {
+		event, task string
+	}{
+		{"Quarterly review", "prepare <b>slides</b> & notes"},
+		{"Review of the Oslo trip", "book flights"},
+	} {
+		userID := uint(i + 1)
+		if err := eventRepo.Create(&entity.Event{Title: owner.event}); err != nil {
+			t.Fatal(err)
+		}
+		task := &entity.Task{Description: owner.task, EventID: userID, PreTaskIDs: []uint{}, AssigneeID: &userID, Status: entity.StatusPending, CreatedAt: time.Now()}
+		if err := taskRepo.Create(task); err != nil {
+			t.Fatal(err)
+		}
+		if err := todoRepo.Create(&entity.Todo{TaskID: userID, AssigneeID: &userID, Status: entity.StatusPending}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := signRepo.Create(&entity.Sign{Signifier: "review", Signified: "a formal assessment"}); err != nil {
+		t.Fatal(err)
+	}
+	return NewSearchService(searchRepo)
+}
+
+func TestSearch(t *testing.T) {
+	search := newTestSearchService(t)
+	alice := uint(1)
+
+	tests := []struct {
+		name       string
+		terms      []string
+		searchType entity.SearchType
+		all        bool // 不限定为调用者，仅管理员可用
+		role       entity.Role
+		wantErr    errs.Kind
+		wantIDs    []int64
+		// 第一条结果中的字段及其内容，为空时不检查
+		field, wantField string
+	}{
+		{"own events by default", []string{"review"}, entity.SearchEvent, false, "", "", []int64{1}, "title", "Quarterly <mark>review</mark>"},
+		{"all events", []string{"review"}, entity.SearchEvent, true, entity.RoleAdmin, "", []int64{1, 2}, "", ""},
+		{"only admins may search all", []string{"flights"}, entity.SearchTask, true, entity.RoleUser, errs.KindForbidden, nil, "", ""},
+		{"own tasks by default", []string{"flights"}, entity.SearchTask, false, "", "", []int64{}, "", ""},
+		{"all tasks", []string{"flights"}, entity.SearchTask, true, entity.RoleAdmin, "", []int64{2}, "description", "book <mark>flights</mark>"},
+		{"todos match their task", []string{"slides"}, entity.SearchTodo, false, "", "", []int64{1}, "task", "prepare &lt;b&gt;<mark>slides</mark>&lt;/b&gt; &amp; notes"},
+		{"other users' todos", []string{"flights"}, entity.SearchTodo, false, "", "", []int64{}, "", ""},
+		{"signs are not scoped", []string{"assessment"}, entity.SearchSign, false, "", "", []int64{1}, "signified", "a formal <mark>assessment</mark>"},
+		{"short term is escaped", []string{"&"}, entity.SearchTask, false, "", "", []int64{1}, "description", "prepare &lt;b&gt;slides&lt;/b&gt; <mark>&amp;</mark> notes"},
+		{"all terms must match", []string{"slides", "flights"}, entity.SearchTask, true, entity.RoleAdmin, "", []int64{}, "", ""},
+		{"markup in the query", []string{"<b>slides"}, entity.SearchTask, false, "", "", []int64{1}, "description", "prepare <mark>&lt;b&gt;slides</mark>&lt;/b&gt; &amp; notes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := entity.SearchQuery{Terms: tt.terms, Types: []entity.SearchType{tt.searchType}, UserID: &alice, Limit: 10}
+			if tt.all {
+				q.UserID = nil
+			}
+			role := tt.role
+			if role == "" {
+				role = entity.RoleUser
+			}
+			results, err := search.Search(q, role)
+			if got := errKind(err); got != tt.wantErr {
+				t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			hits := map[entity.SearchType][]*entity.SearchHit{
+				entity.SearchEvent: results.Events,
+				entity.SearchTask:  results.Tasks,
+				entity.SearchTodo:  results.Todos,
+				entity.SearchSign:  results.Signs,
+			}[tt.searchType]
+
+			ids := make([]int64, len(hits))
+			for i, hit := range hits {
+				ids[i] = hit.ID
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Fatalf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if tt.field != "" {
+				if got := hits[0].Fields[tt.field]; got != tt.wantField {
+					t.Fatalf("%s = %q, want %q", tt.field, got, tt.wantField)
+				}
+			}
+		})
+	}
+}
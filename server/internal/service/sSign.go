@@ -1,32 +1,61 @@
 package service
 
 import (
+	"slices"
+
 	"brb/internal/entity"
+	"brb/internal/errs"
 )
 
 // signService 实现handler.signService接口
 type signService struct {
 	signRepo signRepository
+	policy   SignPolicy
+}
+
+// SignPolicy sign的编辑策略，sign对所有人可读，创建者总能修改和删除自己的sign
+// sign的关系、关联和onton同属共享词表，写入时按同一策略判断
+type SignPolicy struct {
+	UniqueSignifiers bool          // 为true时一个能指（默认或多语言能指）只能属于一个sign
+	EditorRoles      []entity.Role // 可以修改、删除他人创建的（共享的）sign，管理onton和批量导入的角色
+}
+
+// editsShared 该角色能否修改他人创建的sign
+func (p SignPolicy) editsShared(role entity.Role) bool {
+	return slices.Contains(p.EditorRoles, role)
+}
+
+// canEdit 创建者可以修改自己的sign，他人创建或创建者未知的sign按策略中的角色判断
+func (p SignPolicy) canEdit(sign *entity.Sign, userID uint, role entity.Role) bool {
+	return (sign.CreatedBy != 0 && sign.CreatedBy == userID) || p.editsShared(role)
 }
 
 
 type signRepository interface {
 	Create(sign *entity.Sign) error
 	GetByID(id int64) (*entity.Sign, error)
+	GetByIDs(ids []int64) ([]*entity.Sign, error)
+	List(q entity.SignQuery) ([]*entity.Sign, int, error)
 	Update(sign *entity.Sign) error
 	Delete(id int64) error
 }
 
 // NewSignService 创建新的SignService实例
-func NewSignService(signRepo signRepository) *signService {
+func NewSignService(signRepo signRepository, policy SignPolicy) *signService {
 	return &signService{
 		signRepo: signRepo,
+		policy:   policy,
 	}
 }
 
-// CreateSign creates a new sign
-func (s *signService) CreateSign(sign *entity.Sign) error {
-
+// CreateSign creates a new sign, userID is recorded as its creator
+func (s *signService) CreateSign(sign *entity.Sign, userID uint) error {
+	sign.CreatedBy = userID
+	sign.UpdatedBy = userID
+	if err := s.checkUnique(sign); err != nil {
+		return err
+	}
+	normalizeLabels(sign.Labels)
 	return s.signRepo.Create(sign)
 }
 
@@ -35,13 +64,96 @@ func (s *signService) GetSignByID(id int64) (*entity.Sign, error) {
 	return s.signRepo.GetByID(id)
 }
 
+// ListSigns 按条件分页查询sign，返回当页结果和总数
+func (s *signService) ListSigns(q entity.SignQuery) ([]*entity.Sign, int, error) {
+	return s.signRepo.List(q)
+}
 
-func (s *signService) UpdateSign(sign *entity.Sign) error {
 
+// UpdateSign 修改sign，非创建者须具有可以修改共享sign的角色
+func (s *signService) UpdateSign(sign *entity.Sign, userID uint, role entity.Role) error {
+	existing, err := s.signRepo.GetByID(sign.ID)
+	if err != nil {
+		return err
+	}
+	if !s.policy.canEdit(existing, userID, role) {
+		return errs.Forbidden("无权修改他人创建的sign")
+	}
+	sign.CreatedBy = existing.CreatedBy
+	sign.UpdatedBy = userID
+	if err := s.checkUnique(sign); err != nil {
+		return err
+	}
+	normalizeLabels(sign.Labels)
 	return s.signRepo.Update(sign)
 }
 
 
-func (s *signService) DeleteSign(id int64) error {
+// DeleteSign 删除sign，权限同UpdateSign
+func (s *signService) DeleteSign(id int64, userID uint, role entity.Role) error {
+	existing, err := s.signRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if !s.policy.canEdit(existing, userID, role) {
+		return errs.Forbidden("无权删除他人创建的sign")
+	}
 	return s.signRepo.Delete(id)
-}
\ No newline at end of file
+}
+
+// checkUnique 启用唯一约束时，检查默认能指和多语言能指是否已被其他sign用作默认或多语言能指
+func (s *signService) checkUnique(sign *entity.Sign) error {
+	if !s.policy.UniqueSignifiers {
+		return nil
+	}
+	return checkSignifiers(s.signRepo, sign, func(other *entity.Sign) bool { return other.ID == sign.ID })
+}
+
+// signLister 按能指查找sign
+type signLister interface {
+	List(q entity.SignQuery) ([]*entity.Sign, int, error)
+}
+
+// signifiers 返回sign的默认能指和各多语言能指，去除重复
+func signifiers(sign *entity.Sign) []string {
+	names := []string{sign.Signifier}
+	for _, label := range sign.Labels {
+		if !slices.Contains(names, label.Value) {
+			names = append(names, label.Value)
+		}
+	}
+	return names
+}
+
+// checkSignifiers 检查sign的每个能指都没有被其他sign用作默认或多语言能指，self判断查到的sign是否就是要写入的sign
+func checkSignifiers(signs signLister, sign *entity.Sign, self func(*entity.Sign) bool) error {
+	for _, name := range signifiers(sign) {
+		// 要写入的sign自身也可能命中，多取一条
+		existing, _, err := signs.List(entity.SignQuery{Signifier: name, Limit: 2})
+		if err != nil {
+			return err
+		}
+		for _, other := range existing {
+			if !self(other) {
+				return errs.Conflict("能指%q已被sign %d使用", name, other.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeLabels 未指定首选能指的语言，以该语言的第一个能指为首选
+func normalizeLabels(labels []entity.SignLabel) {
+	preferred := map[string]bool{}
+	for _, label := range labels {
+		if label.Preferred {
+			preferred[label.Lang] = true
+		}
+	}
+	for i, label := range labels {
+		if !preferred[label.Lang] {
+			labels[i].Preferred = true
+			preferred[label.Lang] = true
+		}
+	}
+}
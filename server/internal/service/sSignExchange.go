@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+// signExchangeService 负责词汇表的批量导入和导出
+type signExchangeService struct {
+	signRepo         signExchangeRepository
+	signRelationRepo signRelationRepository
+	policy           SignPolicy
+}
+
+type signExchangeRepository interface {
+	GetAll() ([]*entity.Sign, error)
+	List(q entity.SignQuery) ([]*entity.Sign, int, error)
+	Import(signs []*entity.Sign, userID uint, dryRun bool) ([]*entity.SignImportResult, error)
+}
+
+// NewSignExchangeService 创建新的SignExchangeService实例
+func NewSignExchangeService(signRepo signExchangeRepository, signRelationRepo signRelationRepository, policy SignPolicy) *signExchangeService {
+	return &signExchangeService{
+		signRepo:         signRepo,
+		signRelationRepo: signRelationRepo,
+		policy:           policy,
+	}
+}
+
+// ImportSigns 按能指新建或更新sign，全部记录在一个事务中处理
+// 导入会修改他人创建的sign，须具有可以修改共享sign的角色；dryRun为true时不写入数据库，只返回将会进行的变更
+func (s *signExchangeService) ImportSigns(signs []*entity.Sign, userID uint, role entity.Role, dryRun bool) ([]*entity.SignImportResult, error) {
+	if !s.policy.editsShared(role) {
+		return nil, errs.Forbidden("无权批量导入sign")
+	}
+	if err := s.checkUnique(signs); err != nil {
+		return nil, err
+	}
+	results, err := s.signRepo.Import(signs, userID, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import signs: %w", err)
+	}
+	return results, nil
+}
+
+// checkUnique 启用唯一约束时，导入的默认能指和多语言能指不能属于其他sign，导入的记录之间也不能重复
+// 默认能指相同的sign会被更新而不是新建，不视为冲突
+func (s *signExchangeService) checkUnique(signs []*entity.Sign) error {
+	if !s.policy.UniqueSignifiers {
+		return nil
+	}
+	owners := map[string]string{}
+	for _, sign := range signs {
+		for _, name := range signifiers(sign) {
+			if owner, ok := owners[name]; ok && owner != sign.Signifier {
+				return errs.Conflict("能指%q在导入的sign %q和%q中重复", name, owner, sign.Signifier)
+			}
+			owners[name] = sign.Signifier
+		}
+		self := func(other *entity.Sign) bool { return other.Signifier == sign.Signifier }
+		if err := checkSignifiers(s.signRepo, sign, self); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportSigns 获取全部sign及其之间的关系
+func (s *signExchangeService) ExportSigns() ([]*entity.Sign, []*entity.SignRelation, error) {
+	signs, err := s.signRepo.GetAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get signs: %w", err)
+	}
+	relations, err := s.signRelationRepo.GetAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get sign relations: %w", err)
+	}
+	return signs, relations, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+func TestImportSigns(t *testing.T) {
+	batch := []*entity.Sign{
+		{Signifier: "cat", Signified: "a small feline"},
+		{Signifier: "dog", Signified: "a loyal canine"},
+		{Signifier: "fox", Signified: "a wild canine"},
+	}
+	wantActions := []entity.SignImportAction{entity.SignImportUnchanged, entity.SignImportUpdated, entity.SignImportCreated}
+
+	tests := []struct {
+		name    string
+		role    entity.Role
+		dryRun  bool
+		wantErr errs.Kind
+		// 导入后数据库中的能指和所指
+		want map[string]string
+	}{
+		{
+			name:   "dry run rolls back",
+			role:   entity.RoleAdmin,
+			dryRun: true,
+			want:   map[string]string{"cat": "a small feline", "dog": "a canine"},
+		},
+		{
+			name: "import commits",
+			role: entity.RoleAdmin,
+			want: map[string]string{"cat": "a small feline", "dog": "a loyal canine", "fox": "a wild canine"},
+		},
+		{
+			name:    "role cannot edit shared signs",
+			role:    entity.RoleUser,
+			dryRun:  true,
+			wantErr: errs.KindForbidden,
+			want:    map[string]string{"cat": "a small feline", "dog": "a canine"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			signRepo := newTestSignRepo(t, db, false)
+			exchange := NewSignExchangeService(signRepo, must[signRelationRepository](t)(repo.NewSignRelationRepo(db)),
+				SignPolicy{EditorRoles: []entity.Role{entity.RoleAdmin}})
+			for _, sign := range []*entity.Sign{{Signifier: "cat", Signified: "a small feline"}, {Signifier: "dog", Signified: "a canine"}} {
+				if err := signRepo.Create(sign); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			results, err := exchange.ImportSigns(batch, 1, tt.role, tt.dryRun)
+			if got := errKind(err); got != tt.wantErr {
+				t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+			}
+			if err == nil {
+				actions := make([]entity.SignImportAction, len(results))
+				for i, result := range results {
+					actions[i] = result.Action
+				}
+				if !slices.Equal(actions, wantActions) {
+					t.Fatalf("actions = %v, want %v", actions, wantActions)
+				}
+			}
+
+			signs, err := signRepo.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, sign := range signs {
+				got[sign.Signifier] = sign.Signified
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Fatalf("signs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
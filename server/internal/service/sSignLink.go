@@ -0,0 +1,113 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"fmt"
+)
+
+// signLinkService 负责sign与event/task/todo之间的关联，sign在此作为受控词表使用
+type signLinkService struct {
+	signLinkRepo signLinkRepository
+	signRepo     signRepository
+	eventRepo    eventRepository
+	taskRepo     taskRepository
+	todoRepo     todoRepository
+	policy       SignPolicy
+}
+
+type signLinkRepository interface {
+	Link(signID int64, targetType entity.SignTarget, targetID uint) error
+	Unlink(signID int64, targetType entity.SignTarget, targetID uint) error
+	GetSigns(targetType entity.SignTarget, targetID uint) ([]*entity.Sign, error)
+}
+
+// NewSignLinkService 创建新的SignLinkService实例
+func NewSignLinkService(signLinkRepo signLinkRepository, signRepo signRepository, eventRepo eventRepository, taskRepo taskRepository, todoRepo todoRepository, policy SignPolicy) *signLinkService {
+	return &signLinkService{
+		signLinkRepo: signLinkRepo,
+		signRepo:     signRepo,
+		eventRepo:    eventRepo,
+		taskRepo:     taskRepo,
+		todoRepo:     todoRepo,
+		policy:       policy,
+	}
+}
+
+// LinkSign 为event/task/todo关联sign，用户须能修改该sign
+func (s *signLinkService) LinkSign(signID int64, targetType entity.SignTarget, targetID, userID uint, role entity.Role) error {
+	if err := s.checkSign(signID, userID, role); err != nil {
+		return err
+	}
+	if err := s.checkTarget(targetType, targetID); err != nil {
+		return err
+	}
+	return s.signLinkRepo.Link(signID, targetType, targetID)
+}
+
+// UnlinkSign 取消event/task/todo与sign的关联，用户须能修改该sign
+func (s *signLinkService) UnlinkSign(signID int64, targetType entity.SignTarget, targetID, userID uint, role entity.Role) error {
+	if err := s.checkSign(signID, userID, role); err != nil {
+		return err
+	}
+	if err := s.checkTarget(targetType, targetID); err != nil {
+		return err
+	}
+	return s.signLinkRepo.Unlink(signID, targetType, targetID)
+}
+
+// checkSign 检查sign存在且用户可以修改其关联
+func (s *signLinkService) checkSign(signID int64, userID uint, role entity.Role) error {
+	sign, err := s.signRepo.GetByID(signID)
+	if err != nil {
+		return err
+	}
+	if !s.policy.canEdit(sign, userID, role) {
+		return errs.Forbidden("无权修改他人创建的sign的关联")
+	}
+	return nil
+}
+
+// GetSigns 获取event/task/todo关联的sign
+func (s *signLinkService) GetSigns(targetType entity.SignTarget, targetID uint) ([]*entity.Sign, error) {
+	if err := s.checkTarget(targetType, targetID); err != nil {
+		return nil, err
+	}
+	return s.signLinkRepo.GetSigns(targetType, targetID)
+}
+
+// GetSignItems 获取关联了sign的全部event、task和todo
+func (s *signLinkService) GetSignItems(signID int64) (*entity.SignItems, error) {
+	sign, err := s.signRepo.GetByID(signID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := &entity.SignItems{Sign: sign}
+	if items.Events, err = s.eventRepo.GetBySignID(signID); err != nil {
+		return nil, fmt.Errorf("failed to get events by sign: %w", err)
+	}
+	if items.Tasks, err = s.taskRepo.GetBySignID(signID); err != nil {
+		return nil, fmt.Errorf("failed to get tasks by sign: %w", err)
+	}
+	if items.Todos, err = s.todoRepo.GetBySignID(signID); err != nil {
+		return nil, fmt.Errorf("failed to get todos by sign: %w", err)
+	}
+	return items, nil
+}
+
+// checkTarget 检查被关联的对象是否存在
+func (s *signLinkService) checkTarget(targetType entity.SignTarget, targetID uint) error {
+	var err error
+	switch targetType {
+	case entity.SignTargetEvent:
+		_, err = s.eventRepo.GetByID(targetID)
+	case entity.SignTargetTask:
+		_, err = s.taskRepo.GetByID(targetID)
+	case entity.SignTargetTodo:
+		_, err = s.todoRepo.GetByID(targetID)
+	default:
+		return errs.Validation("unsupported sign target: %s", targetType)
+	}
+	return err
+}
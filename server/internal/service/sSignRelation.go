@@ -0,0 +1,285 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"fmt"
+	"slices"
+)
+
+// 子图遍历的限制
+const (
+	maxGraphDepth = 5
+	maxGraphNodes = 200
+)
+
+// signRelationService 负责sign之间的关系及其遍历
+type signRelationService struct {
+	signRelationRepo signRelationRepository
+	signRepo         signRepository
+	policy           SignPolicy
+}
+
+type signRelationRepository interface {
+	Create(relation *entity.SignRelation) error
+	GetByID(id int64) (*entity.SignRelation, error)
+	Exists(fromID, toID int64, relationType entity.SignRelationType) (bool, error)
+	Reachable(fromID, toID int64, relationType entity.SignRelationType) (bool, error)
+	GetBySignIDs(signIDs []int64, types []entity.SignRelationType) ([]*entity.SignRelation, error)
+	GetAll() ([]*entity.SignRelation, error)
+	Delete(id int64) error
+}
+
+// NewSignRelationService 创建新的SignRelationService实例
+func NewSignRelationService(signRelationRepo signRelationRepository, signRepo signRepository, policy SignPolicy) *signRelationService {
+	return &signRelationService{
+		signRelationRepo: signRelationRepo,
+		signRepo:         signRepo,
+		policy:           policy,
+	}
+}
+
+// AddRelation 添加从fromID到toID的关系
+// 反向类型（narrower、hasPart、referencedBy）转换为反向的基本类型保存，同义关系按ID从小到大保存；
+// 层级关系（broader、partOf）若会形成环则拒绝；用户须能修改fromID的sign
+func (s *signRelationService) AddRelation(fromID, toID int64, relationType entity.SignRelationType, userID uint, role entity.Role) (*entity.SignRelation, error) {
+	relationType, err := parseRelationType(relationType)
+	if err != nil {
+		return nil, err
+	}
+	if fromID == toID {
+		return nil, errs.Validation("sign不能与自身建立关系")
+	}
+	from, err := s.signRepo.GetByID(fromID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.signRepo.GetByID(toID); err != nil {
+		return nil, err
+	}
+	if !s.policy.canEdit(from, userID, role) {
+		return nil, errs.Forbidden("无权修改他人创建的sign的关系")
+	}
+
+	relation := normalizeRelation(&entity.SignRelation{FromID: fromID, ToID: toID, Type: relationType})
+
+	exists, err := s.signRelationRepo.Exists(relation.FromID, relation.ToID, relation.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check sign relation: %w", err)
+	}
+	if exists {
+		return nil, errs.Conflict("关系已存在")
+	}
+
+	if relation.Type.Hierarchical() {
+		cycle, err := s.signRelationRepo.Reachable(relation.ToID, relation.FromID, relation.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check sign relation cycle: %w", err)
+		}
+		if cycle {
+			return nil, errs.Conflict("添加该%s关系会形成环", relation.Type)
+		}
+	}
+
+	if err := s.signRelationRepo.Create(relation); err != nil {
+		return nil, err
+	}
+	return relation, nil
+}
+
+// RemoveRelation 删除与signID相连的一条关系，用户须能修改signID的sign
+func (s *signRelationService) RemoveRelation(signID, relationID int64, userID uint, role entity.Role) error {
+	relation, err := s.signRelationRepo.GetByID(relationID)
+	if err != nil {
+		return err
+	}
+	if relation.FromID != signID && relation.ToID != signID {
+		return errs.NotFound("sign关系不存在")
+	}
+	sign, err := s.signRepo.GetByID(signID)
+	if err != nil {
+		return err
+	}
+	if !s.policy.canEdit(sign, userID, role) {
+		return errs.Forbidden("无权修改他人创建的sign的关系")
+	}
+	return s.signRelationRepo.Delete(relationID)
+}
+
+// GetNeighbors 获取与sign直接相连的sign，types为空时返回全部类型
+func (s *signRelationService) GetNeighbors(signID int64, types []entity.SignRelationType) ([]*entity.SignNeighbor, error) {
+	if _, err := s.signRepo.GetByID(signID); err != nil {
+		return nil, err
+	}
+	stored, err := storedRelationTypes(types)
+	if err != nil {
+		return nil, err
+	}
+
+	relations, err := s.signRelationRepo.GetBySignIDs([]int64{signID}, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	otherIDs := make([]int64, len(relations))
+	for i, relation := range relations {
+		otherIDs[i] = otherEnd(relation, signID)
+	}
+	signs, err := s.signsByID(otherIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]*entity.SignNeighbor, 0, len(relations))
+	for _, relation := range relations {
+		sign, ok := signs[otherEnd(relation, signID)]
+		if !ok {
+			continue
+		}
+		neighbor := &entity.SignNeighbor{Relation: relation, Type: relation.Type, Sign: sign}
+		if relation.ToID == signID {
+			neighbor.Type = relation.Type.Inverse()
+		}
+		// 只筛选了基本类型，反向查询时还要按调用方要求的方向过滤
+		if len(types) > 0 && !slices.Contains(types, neighbor.Type) {
+			continue
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+	return neighbors, nil
+}
+
+// Traverse 从sign出发按广度优先遍历至多depth层，返回途经的sign及它们之间的关系
+// types按关系类型筛选且不区分方向；已访问的sign不会重复展开，因此关系中的环不会导致无限遍历；
+// 节点数超过上限时停止扩展并标记Truncated
+func (s *signRelationService) Traverse(signID int64, depth int, types []entity.SignRelationType) (*entity.SignGraph, error) {
+	root, err := s.signRepo.GetByID(signID)
+	if err != nil {
+		return nil, err
+	}
+	if depth < 0 || depth > maxGraphDepth {
+		return nil, errs.Validation("遍历深度必须在0到%d之间", maxGraphDepth)
+	}
+	stored, err := storedRelationTypes(types)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &entity.SignGraph{}
+	visited := map[int64]bool{signID: true}
+	order := []int64{signID}
+	edgeSeen := map[int64]bool{}
+	var edges []*entity.SignRelation
+
+	frontier := []int64{signID}
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		relations, err := s.signRelationRepo.GetBySignIDs(frontier, stored)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []int64
+		for _, relation := range relations {
+			if !edgeSeen[relation.ID] {
+				edgeSeen[relation.ID] = true
+				edges = append(edges, relation)
+			}
+			for _, id := range []int64{relation.FromID, relation.ToID} {
+				if visited[id] {
+					continue
+				}
+				if len(order) >= maxGraphNodes {
+					graph.Truncated = true
+					continue
+				}
+				visited[id] = true
+				order = append(order, id)
+				next = append(next, id)
+			}
+		}
+		frontier = next
+	}
+
+	signs, err := s.signsByID(order)
+	if err != nil {
+		return nil, err
+	}
+	signs[signID] = root
+	for _, id := range order {
+		if sign, ok := signs[id]; ok {
+			graph.Nodes = append(graph.Nodes, sign)
+		}
+	}
+	for _, edge := range edges {
+		if visited[edge.FromID] && visited[edge.ToID] {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+	return graph, nil
+}
+
+func (s *signRelationService) signsByID(ids []int64) (map[int64]*entity.Sign, error) {
+	signs, err := s.signRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signs: %w", err)
+	}
+	byID := make(map[int64]*entity.Sign, len(signs))
+	for _, sign := range signs {
+		byID[sign.ID] = sign
+	}
+	return byID, nil
+}
+
+// relationTypes 接口中可用的全部关系类型
+var relationTypes = []entity.SignRelationType{
+	entity.SignRelationSynonym,
+	entity.SignRelationBroader,
+	entity.SignRelationNarrower,
+	entity.SignRelationPartOf,
+	entity.SignRelationHasPart,
+	entity.SignRelationReferences,
+	entity.SignRelationReferencedBy,
+}
+
+func parseRelationType(relationType entity.SignRelationType) (entity.SignRelationType, error) {
+	if !slices.Contains(relationTypes, relationType) {
+		return "", errs.Validation("不支持的关系类型: %s", relationType)
+	}
+	return relationType, nil
+}
+
+// normalizeRelation 转换为保存时使用的方向和类型
+func normalizeRelation(relation *entity.SignRelation) *entity.SignRelation {
+	switch relation.Type {
+	case entity.SignRelationNarrower, entity.SignRelationHasPart, entity.SignRelationReferencedBy:
+		relation.FromID, relation.ToID = relation.ToID, relation.FromID
+		relation.Type = relation.Type.Inverse()
+	case entity.SignRelationSynonym:
+		if relation.FromID > relation.ToID {
+			relation.FromID, relation.ToID = relation.ToID, relation.FromID
+		}
+	}
+	return relation
+}
+
+// storedRelationTypes 将筛选用的类型转换为保存时的类型
+func storedRelationTypes(types []entity.SignRelationType) ([]entity.SignRelationType, error) {
+	var stored []entity.SignRelationType
+	for _, t := range types {
+		if _, err := parseRelationType(t); err != nil {
+			return nil, err
+		}
+		base := normalizeRelation(&entity.SignRelation{Type: t}).Type
+		if !slices.Contains(stored, base) {
+			stored = append(stored, base)
+		}
+	}
+	return stored, nil
+}
+
+func otherEnd(relation *entity.SignRelation, signID int64) int64 {
+	if relation.FromID == signID {
+		return relation.ToID
+	}
+	return relation.FromID
+}
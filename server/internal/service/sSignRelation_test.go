@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+func TestAddRelationCycle(t *testing.T) {
+	// a、b、c、d的ID依次为1到4，已有a broader b、b broader c、a partOf d
+	const a, b, c, d = 1, 2, 3, 4
+
+	tests := []struct {
+		name         string
+		from, to     int64
+		relationType entity.SignRelationType
+		wantErr      errs.Kind
+	}{
+		{"closes a two-sign cycle", b, a, entity.SignRelationBroader, errs.KindConflict},
+		{"closes a three-sign cycle", c, a, entity.SignRelationBroader, errs.KindConflict},
+		{"inverse type closes a cycle", a, c, entity.SignRelationNarrower, errs.KindConflict},
+		{"partOf cycle", d, a, entity.SignRelationPartOf, errs.KindConflict},
+		{"hasPart closes a partOf cycle", a, d, entity.SignRelationHasPart, errs.KindConflict},
+		{"shortcut along the hierarchy", a, c, entity.SignRelationBroader, ""},
+		{"inverse shortcut", c, a, entity.SignRelationNarrower, ""},
+		{"new branch", d, b, entity.SignRelationBroader, ""},
+		{"other hierarchy is independent", c, a, entity.SignRelationPartOf, ""},
+		{"references may loop", c, a, entity.SignRelationReferences, ""},
+		{"synonym may loop", c, a, entity.SignRelationSynonym, ""},
+		{"duplicate relation", a, b, entity.SignRelationBroader, errs.KindConflict},
+		{"duplicate through the inverse type", b, a, entity.SignRelationNarrower, errs.KindConflict},
+		{"self relation", a, a, entity.SignRelationBroader, errs.KindValidation},
+		{"unknown type", a, d, "sameAs", errs.KindValidation},
+		{"missing sign", a, 99, entity.SignRelationBroader, errs.KindNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			signRepo := must[signRepository](t)(repo.NewSignRepo(db))
+			relations := NewSignRelationService(must[signRelationRepository](t)(repo.NewSignRelationRepo(db)), signRepo, SignPolicy{})
+			for _, signifier := range []string{"a", "b", "c", "d"} {
+				if err := signRepo.Create(&entity.Sign{Signifier: signifier, Signified: signifier, CreatedBy: 1}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, r := range []struct {
+				from, to     int64
+				relationType entity.SignRelationType
+			}{{a, b, entity.SignRelationBroader}, {b, c, entity.SignRelationBroader}, {a, d, entity.SignRelationPartOf}} {
+				if _, err := relations.AddRelation(r.from, r.to, r.relationType, 1, entity.RoleUser); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := relations.AddRelation(tt.from, tt.to, tt.relationType, 1, entity.RoleUser)
+			if got := errKind(err); got != tt.wantErr {
+				t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+			}
+		})
+	}
+}
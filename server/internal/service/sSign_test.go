@@ -0,0 +1,329 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+// newTestSignRepo 创建signRepo，unique为true时建立能指唯一索引
+func newTestSignRepo(t *testing.T, db *sql.DB, unique bool) interface {
+	signRepository
+	signExchangeRepository
+} {
+	t.Helper()
+	signRepo, err := repo.NewSignRepo(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signRepo.EnforceUniqueSignifiers(unique); err != nil {
+		t.Fatal(err)
+	}
+	return signRepo
+}
+
+func TestUniqueSignifiers(t *testing.T) {
+	policy := SignPolicy{UniqueSignifiers: true, EditorRoles: []entity.Role{entity.RoleAdmin}}
+	labeled := func() *entity.Sign {
+		return &entity.Sign{Signifier: "cat", Signified: "a small feline", Labels: []entity.SignLabel{{Lang: "zh", Value: "猫"}}}
+	}
+
+	tests := []struct {
+		name    string
+		policy  SignPolicy
+		write   func(t *testing.T, signs *signService, exchange *signExchangeService) error
+		wantErr errs.Kind
+	}{
+		{
+			name:   "create duplicate signifier",
+			policy: policy,
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				return signs.CreateSign(&entity.Sign{Signifier: "cat", Signified: "other"}, 1)
+			},
+			wantErr: errs.KindConflict,
+		},
+		{
+			name:   "create signifier used as a label",
+			policy: policy,
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				return signs.CreateSign(&entity.Sign{Signifier: "猫", Signified: "other"}, 1)
+			},
+			wantErr: errs.KindConflict,
+		},
+		{
+			name:   "create label used as a signifier",
+			policy: policy,
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				return signs.CreateSign(&entity.Sign{Signifier: "kitty", Signified: "other", Labels: []entity.SignLabel{{Lang: "en", Value: "cat"}}}, 1)
+			},
+			wantErr: errs.KindConflict,
+		},
+		{
+			name:   "create label used as another label",
+			policy: policy,
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				return signs.CreateSign(&entity.Sign{Signifier: "neko", Signified: "other", Labels: []entity.SignLabel{{Lang: "ja", Value: "猫"}}}, 1)
+			},
+			wantErr: errs.KindConflict,
+		},
+		{
+			name:   "sign repeats its own signifier as labels",
+			policy: policy,
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				labels := []entity.SignLabel{{Lang: "en", Value: "dog"}, {Lang: "en-GB", Value: "dog"}}
+				return signs.CreateSign(&entity.Sign{Signifier: "dog", Signified: "a canine", Labels: labels}, 1)
+			},
+		},
+		{
+			name:   "update keeps its own labels",
+			policy: policy,
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				cat := labeled()
+				cat.ID = 1
+				cat.Labels = append(cat.Labels, entity.SignLabel{Lang: "ja", Value: "猫"})
+				return signs.UpdateSign(cat, 1, entity.RoleUser)
+			},
+		},
+		{
+			name:   "update adds a label used by another sign",
+			policy: policy,
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				dog := &entity.Sign{Signifier: "dog", Signified: "a canine"}
+				if err := signs.CreateSign(dog, 1); err != nil {
+					t.Fatal(err)
+				}
+				dog.Labels = []entity.SignLabel{{Lang: "zh", Value: "猫"}}
+				return signs.UpdateSign(dog, 1, entity.RoleUser)
+			},
+			wantErr: errs.KindConflict,
+		},
+		{
+			name:   "rename to an existing signifier",
+			policy: policy,
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				dog := &entity.Sign{Signifier: "dog", Signified: "a canine"}
+				if err := signs.CreateSign(dog, 1); err != nil {
+					t.Fatal(err)
+				}
+				dog.Signifier = "cat"
+				return signs.UpdateSign(dog, 1, entity.RoleUser)
+			},
+			wantErr: errs.KindConflict,
+		},
+		{
+			name:   "duplicates allowed when disabled",
+			policy: SignPolicy{},
+			write: func(t *testing.T, signs *signService, _ *signExchangeService) error {
+				return signs.CreateSign(&entity.Sign{Signifier: "cat", Signified: "other"}, 1)
+			},
+		},
+		{
+			name:   "import updates the sign with the same signifier",
+			policy: policy,
+			write: func(t *testing.T, _ *signService, exchange *signExchangeService) error {
+				_, err := exchange.ImportSigns([]*entity.Sign{{Signifier: "cat", Signified: "updated"}}, 1, entity.RoleAdmin, false)
+				return err
+			},
+		},
+		{
+			name:   "import signifier used as a label",
+			policy: policy,
+			write: func(t *testing.T, _ *signService, exchange *signExchangeService) error {
+				_, err := exchange.ImportSigns([]*entity.Sign{{Signifier: "猫", Signified: "other"}}, 1, entity.RoleAdmin, false)
+				return err
+			},
+			wantErr: errs.KindConflict,
+		},
+		{
+			name:   "import label used by another sign",
+			policy: policy,
+			write: func(t *testing.T, _ *signService, exchange *signExchangeService) error {
+				labels := []entity.SignLabel{{Lang: "ja", Value: "猫"}}
+				_, err := exchange.ImportSigns([]*entity.Sign{{Signifier: "neko", Signified: "other", Labels: labels}}, 1, entity.RoleAdmin, false)
+				return err
+			},
+			wantErr: errs.KindConflict,
+		},
+		{
+			name:   "import updates its own labels",
+			policy: policy,
+			write: func(t *testing.T, _ *signService, exchange *signExchangeService) error {
+				labels := []entity.SignLabel{{Lang: "zh", Value: "猫"}, {Lang: "ja", Value: "猫"}}
+				_, err := exchange.ImportSigns([]*entity.Sign{{Signifier: "cat", Signified: "updated", Labels: labels}}, 1, entity.RoleAdmin, false)
+				return err
+			},
+		},
+		{
+			name:   "imported records share a signifier",
+			policy: policy,
+			write: func(t *testing.T, _ *signService, exchange *signExchangeService) error {
+				signs := []*entity.Sign{
+					{Signifier: "dog", Signified: "a canine", Labels: []entity.SignLabel{{Lang: "en", Value: "hound"}}},
+					{Signifier: "hound", Signified: "a hunting dog"},
+				}
+				_, err := exchange.ImportSigns(signs, 1, entity.RoleAdmin, false)
+				return err
+			},
+			wantErr: errs.KindConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			signRepo := newTestSignRepo(t, db, tt.policy.UniqueSignifiers)
+			signs := NewSignService(signRepo, tt.policy)
+			exchange := NewSignExchangeService(signRepo, must[signRelationRepository](t)(repo.NewSignRelationRepo(db)), tt.policy)
+			if err := signs.CreateSign(labeled(), 1); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := errKind(tt.write(t, signs, exchange)); got != tt.wantErr {
+				t.Fatalf("err kind = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUniqueSignifierIndex(t *testing.T) {
+	t.Run("conflict when the service check is bypassed", func(t *testing.T) {
+		// 服务层检查与写入之间存在竞争窗口，由唯一索引拦截
+		signRepo := newTestSignRepo(t, newTestDB(t), true)
+		if err := signRepo.Create(&entity.Sign{Signifier: "cat", Signified: "a"}); err != nil {
+			t.Fatal(err)
+		}
+		err := signRepo.Create(&entity.Sign{Signifier: "cat", Signified: "b"})
+		if errs.KindOf(err) != errs.KindConflict {
+			t.Fatalf("err = %v, want conflict", err)
+		}
+	})
+
+	t.Run("cannot enable with existing duplicates", func(t *testing.T) {
+		db := newTestDB(t)
+		signRepo, err := repo.NewSignRepo(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, signified := range []string{"a", "b"} {
+			if err := signRepo.Create(&entity.Sign{Signifier: "cat", Signified: signified}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := signRepo.EnforceUniqueSignifiers(true); err == nil {
+			t.Fatal("enabled the unique index over duplicate signifiers")
+		}
+		if err := signRepo.EnforceUniqueSignifiers(false); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSharedVocabularyPolicy(t *testing.T) {
+	// sign 1由用户1创建，sign 2由用户2创建；只有管理员可以修改他人创建的sign和onton
+	const alice, bob = 1, 2
+	const own, shared = 1, 2
+
+	type env struct {
+		relations *signRelationService
+		links     *signLinkService
+		ontons    *ontonService
+	}
+	tests := []struct {
+		name    string
+		write   func(e env) error
+		wantErr errs.Kind
+	}{
+		{"add relation from own sign", func(e env) error {
+			_, err := e.relations.AddRelation(own, shared, entity.SignRelationBroader, alice, entity.RoleUser)
+			return err
+		}, ""},
+		{"add relation from shared sign", func(e env) error {
+			_, err := e.relations.AddRelation(shared, own, entity.SignRelationNarrower, alice, entity.RoleUser)
+			return err
+		}, errs.KindForbidden},
+		{"editor adds relation from shared sign", func(e env) error {
+			_, err := e.relations.AddRelation(shared, own, entity.SignRelationNarrower, alice, entity.RoleAdmin)
+			return err
+		}, ""},
+		{"remove relation through own sign", func(e env) error {
+			relation, err := e.relations.AddRelation(shared, own, entity.SignRelationReferences, bob, entity.RoleUser)
+			if err != nil {
+				return err
+			}
+			return e.relations.RemoveRelation(own, relation.ID, alice, entity.RoleUser)
+		}, ""},
+		{"remove relation through shared sign", func(e env) error {
+			relation, err := e.relations.AddRelation(shared, own, entity.SignRelationReferences, bob, entity.RoleUser)
+			if err != nil {
+				return err
+			}
+			return e.relations.RemoveRelation(shared, relation.ID, alice, entity.RoleUser)
+		}, errs.KindForbidden},
+		{"link own sign", func(e env) error {
+			return e.links.LinkSign(own, entity.SignTargetEvent, 1, alice, entity.RoleUser)
+		}, ""},
+		{"link shared sign", func(e env) error {
+			return e.links.LinkSign(shared, entity.SignTargetEvent, 1, alice, entity.RoleUser)
+		}, errs.KindForbidden},
+		{"editor links shared sign", func(e env) error {
+			return e.links.LinkSign(shared, entity.SignTargetEvent, 1, alice, entity.RoleAdmin)
+		}, ""},
+		{"unlink shared sign", func(e env) error {
+			if err := e.links.LinkSign(shared, entity.SignTargetEvent, 1, bob, entity.RoleUser); err != nil {
+				return err
+			}
+			return e.links.UnlinkSign(shared, entity.SignTargetEvent, 1, alice, entity.RoleUser)
+		}, errs.KindForbidden},
+		{"create onton", func(e env) error {
+			return e.ontons.CreateOnton(&entity.Onton{Adic: 1}, entity.RoleUser)
+		}, errs.KindForbidden},
+		{"update onton", func(e env) error {
+			return e.ontons.UpdateOnton(&entity.Onton{ID: 1, Adic: 2}, entity.RoleUser)
+		}, errs.KindForbidden},
+		{"delete onton", func(e env) error {
+			return e.ontons.DeleteOnton(1, entity.RoleUser)
+		}, errs.KindForbidden},
+		{"editor deletes onton", func(e env) error {
+			return e.ontons.DeleteOnton(1, entity.RoleAdmin)
+		}, ""},
+		{"own sign denotes onton", func(e env) error {
+			return e.ontons.LinkSign(1, own, alice, entity.RoleUser)
+		}, ""},
+		{"shared sign denotes onton", func(e env) error {
+			return e.ontons.LinkSign(1, shared, alice, entity.RoleUser)
+		}, errs.KindForbidden},
+		{"unlink shared sign from onton", func(e env) error {
+			return e.ontons.UnlinkSign(1, shared, alice, entity.RoleUser)
+		}, errs.KindForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			signRepo, eventRepo, linkRepo := newTestEventRepos(t, db)
+			policy := SignPolicy{EditorRoles: []entity.Role{entity.RoleAdmin}}
+			ontonRepo := must[ontonRepository](t)(repo.NewOntonRepo(db))
+			e := env{
+				relations: NewSignRelationService(must[signRelationRepository](t)(repo.NewSignRelationRepo(db)), signRepo, policy),
+				links:     NewSignLinkService(linkRepo, signRepo, eventRepo, must[taskRepository](t)(repo.NewTaskRepo(db)), must[todoRepository](t)(repo.NewTodoRepo(db)), policy),
+				ontons:    NewOntonService(ontonRepo, signRepo, policy),
+			}
+			for _, sign := range []*entity.Sign{{Signifier: "kitten", CreatedBy: alice}, {Signifier: "cat", CreatedBy: bob}} {
+				if err := signRepo.Create(sign); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := eventRepo.Create(&entity.Event{Title: "vet visit"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.ontons.CreateOnton(&entity.Onton{Adic: 1}, entity.RoleAdmin); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := errKind(tt.write(e)); got != tt.wantErr {
+				t.Fatalf("err kind = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
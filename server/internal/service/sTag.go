@@ -0,0 +1,80 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+// tagService 实现handler.tagService接口
+type tagService struct {
+	tagRepo tagRepository
+}
+
+type tagRepository interface {
+	GetAll() ([]*entity.Tag, error)
+	GetByID(id int64) (*entity.Tag, error)
+	GetByName(name string) (*entity.Tag, error)
+	Rename(id int64, name string) error
+	Merge(sourceID, targetID int64) error
+	Delete(id int64) error
+}
+
+// NewTagService 创建新的TagService实例
+func NewTagService(tagRepo tagRepository) *tagService {
+	return &tagService{tagRepo: tagRepo}
+}
+
+// GetAllTags 获取所有tag及其使用次数
+func (s *tagService) GetAllTags() ([]*entity.Tag, error) {
+	return s.tagRepo.GetAll()
+}
+
+// GetTagByID 根据ID获取tag
+func (s *tagService) GetTagByID(id int64) (*entity.Tag, error) {
+	return s.tagRepo.GetByID(id)
+}
+
+// RenameTag 修改tag名称，可只改变大小写；与其他tag重名时应改用合并
+func (s *tagService) RenameTag(id int64, name string) (*entity.Tag, error) {
+	if _, err := s.tagRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+	existing, err := s.tagRepo.GetByName(name)
+	switch {
+	case err == nil && existing.ID != id:
+		return nil, errs.Conflict("标签%q已存在，请使用合并", existing.Name)
+	case err != nil && !errs.IsNotFound(err):
+		return nil, err
+	}
+
+	if err := s.tagRepo.Rename(id, name); err != nil {
+		return nil, err
+	}
+	return s.tagRepo.GetByID(id)
+}
+
+// MergeTag 将source合并到target：使用source的event改为使用target，然后删除source
+func (s *tagService) MergeTag(sourceID, targetID int64) (*entity.Tag, error) {
+	if sourceID == targetID {
+		return nil, errs.Validation("不能将标签合并到自身")
+	}
+	if _, err := s.tagRepo.GetByID(sourceID); err != nil {
+		return nil, err
+	}
+	if _, err := s.tagRepo.GetByID(targetID); err != nil {
+		if errs.IsNotFound(err) {
+			return nil, errs.NotFound("目标标签不存在")
+		}
+		return nil, err
+	}
+
+	if err := s.tagRepo.Merge(sourceID, targetID); err != nil {
+		return nil, err
+	}
+	return s.tagRepo.GetByID(targetID)
+}
+
+// DeleteTag 删除tag，使用它的event不再带有该标签
+func (s *tagService) DeleteTag(id int64) error {
+	return s.tagRepo.Delete(id)
+}
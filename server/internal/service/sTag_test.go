@@ -0,0 +1,253 @@
+package service
+
+import (
+	"database/sql"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+// taggedEvents event 1到4的标签，每个event下有一个task
+var taggedEvents = [][]string{{"work", "urgent"}, {"work"}, {"Urgent"}, {}}
+
+// newTestTagServices 按taggedEvents创建event和task
+func newTestTagServices(t *testing.T) (*sql.DB, *tagService, *eventService, *taskService) {
+	t.Helper()
+	db := newTestDB(t)
+	_, eventRepo, _ := newTestEventRepos(t, db)
+	taskRepo := must[taskRepository](t)(repo.NewTaskRepo(db))
+	todoRepo := must[todoRepository](t)(repo.NewTodoRepo(db))
+	tagRepo := must[tagRepository](t)(repo.NewTagRepo(db))
+
+	events := NewEventService(eventRepo, taskRepo)
+	for i, tags := range taggedEvents {
+		if err := events.CreateEvent(&entity.Event{Title: "event", Tags: tags}); err != nil {
+			t.Fatal(err)
+		}
+		task := &entity.Task{Description: "task", EventID: uint(i + 1), PreTaskIDs: []uint{}, Status: entity.StatusPending, CreatedAt: time.Now()}
+		if err := taskRepo.Create(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, NewTagService(tagRepo), events, NewTaskService(taskRepo, todoRepo)
+}
+
+// tagUsage 返回全部tag的"名称:使用次数"
+func tagUsage(t *testing.T, tags *tagService) []string {
+	t.Helper()
+	all, err := tags.GetAllTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := make([]string, len(all))
+	for i, tag := range all {
+		usage[i] = tag.Name + ":" + strconv.Itoa(tag.Usage)
+	}
+	return usage
+}
+
+// eventTags 返回event当前的标签
+func eventTags(t *testing.T, events *eventService, id uint) []string {
+	t.Helper()
+	event, err := events.GetEventByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event.Tags
+}
+
+func TestTagUsage(t *testing.T) {
+	_, tags, events, _ := newTestTagServices(t)
+	// 不区分大小写的重复标签沿用已有写法
+	if got, want := tagUsage(t, tags), []string{"urgent:2", "work:2"}; !slices.Equal(got, want) {
+		t.Fatalf("usage = %v, want %v", got, want)
+	}
+	if got, want := eventTags(t, events, 3), []string{"urgent"}; !slices.Equal(got, want) {
+		t.Fatalf("event 3 tags = %v, want %v", got, want)
+	}
+}
+
+func TestMergeTag(t *testing.T) {
+	tests := []struct {
+		name           string
+		source, target string
+		wantErr        errs.Kind
+		wantUsage      []string
+		wantEvent1Tags []string
+	}{
+		{
+			name:   "overlapping events keep one link",
+			source: "urgent", target: "work",
+			wantUsage:      []string{"work:3"},
+			wantEvent1Tags: []string{"work"},
+		},
+		{
+			name:   "into itself",
+			source: "work", target: "work",
+			wantErr:        errs.KindValidation,
+			wantUsage:      []string{"urgent:2", "work:2"},
+			wantEvent1Tags: []string{"urgent", "work"},
+		},
+		{
+			name:   "missing target",
+			source: "work", target: "",
+			wantErr:        errs.KindNotFound,
+			wantUsage:      []string{"urgent:2", "work:2"},
+			wantEvent1Tags: []string{"urgent", "work"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, tags, events, _ := newTestTagServices(t)
+			ids := map[string]int64{"": 99}
+			all, err := tags.GetAllTags()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tag := range all {
+				ids[tag.Name] = tag.ID
+			}
+
+			merged, err := tags.MergeTag(ids[tt.source], ids[tt.target])
+			if got := errKind(err); got != tt.wantErr {
+				t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+			}
+			if err == nil && merged.Name != tt.target {
+				t.Fatalf("merged = %+v", merged)
+			}
+			if got := tagUsage(t, tags); !slices.Equal(got, tt.wantUsage) {
+				t.Fatalf("usage = %v, want %v", got, tt.wantUsage)
+			}
+			if got := eventTags(t, events, 1); !slices.Equal(got, tt.wantEvent1Tags) {
+				t.Fatalf("event 1 tags = %v, want %v", got, tt.wantEvent1Tags)
+			}
+		})
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		from, to  string
+		wantErr   errs.Kind
+		wantUsage []string
+	}{
+		{"case only", "work", "Work", "", []string{"urgent:2", "Work:2"}},
+		{"new name", "work", "job", "", []string{"job:2", "urgent:2"}},
+		{"differs from another tag only in case", "work", "URGENT", errs.KindConflict, []string{"urgent:2", "work:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, tags, events, _ := newTestTagServices(t)
+			all, err := tags.GetAllTags()
+			if err != nil {
+				t.Fatal(err)
+			}
+			id := all[slices.IndexFunc(all, func(tag *entity.Tag) bool { return tag.Name == tt.from })].ID
+
+			renamed, err := tags.RenameTag(id, tt.to)
+			if got := errKind(err); got != tt.wantErr {
+				t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+			}
+			if err == nil && (renamed.Name != tt.to || renamed.Usage != 2) {
+				t.Fatalf("renamed = %+v", renamed)
+			}
+			if got := tagUsage(t, tags); !slices.Equal(got, tt.wantUsage) {
+				t.Fatalf("usage = %v, want %v", got, tt.wantUsage)
+			}
+			if err == nil && !slices.Contains(eventTags(t, events, 2), tt.to) {
+				t.Fatalf("event 2 tags = %v, want %q", eventTags(t, events, 2), tt.to)
+			}
+		})
+	}
+}
+
+func TestTagFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		tags  []string
+		match entity.TagMatch
+		want  []uint // 匹配的event ID，由其派生的task ID与之相同
+	}{
+		{"any", []string{"work", "urgent"}, entity.TagMatchAny, []uint{1, 2, 3}},
+		{"all", []string{"work", "urgent"}, entity.TagMatchAll, []uint{1}},
+		{"all ignores case", []string{"WORK", "Urgent"}, entity.TagMatchAll, []uint{1}},
+		{"single tag", []string{"urgent"}, entity.TagMatchAll, []uint{1, 3}},
+		{"any with an unknown tag", []string{"work", "errand"}, entity.TagMatchAny, []uint{1, 2}},
+		{"all with an unknown tag", []string{"work", "errand"}, entity.TagMatchAll, []uint{}},
+	}
+	_, _, events, tasks := newTestTagServices(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := entity.TagFilter{Tags: tt.tags, Match: tt.match}
+			matchedEvents, err := events.GetEventsByTags(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matchedTasks, err := tasks.GetTasksByEventTags(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			eventIDs := make([]uint, len(matchedEvents))
+			for i, event := range matchedEvents {
+				eventIDs[i] = event.ID
+			}
+			taskIDs := make([]uint, len(matchedTasks))
+			for i, task := range matchedTasks {
+				taskIDs[i] = task.ID
+			}
+			slices.Sort(eventIDs)
+			slices.Sort(taskIDs)
+			if !slices.Equal(eventIDs, tt.want) || !slices.Equal(taskIDs, tt.want) {
+				t.Fatalf("events = %v, tasks = %v, want %v", eventIDs, taskIDs, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventCategoryBecomesTag(t *testing.T) {
+	db, tags, events, _ := newTestTagServices(t)
+
+	event := &entity.Event{Title: "standup", Category: "errand", Tags: []string{"work"}}
+	if err := events.CreateEvent(event); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"errand", "work"}; !slices.Equal(event.Tags, want) || event.Category != "" {
+		t.Fatalf("created tags = %v, category = %q, want %v and no category", event.Tags, event.Category, want)
+	}
+
+	// 省略标签时追加category，已有标签保持不变
+	update := &entity.Event{ID: event.ID, Title: "standup", Category: "Urgent"}
+	if err := events.UpdateEvent(update); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"errand", "urgent", "work"}; !slices.Equal(update.Tags, want) {
+		t.Fatalf("updated tags = %v, want %v", update.Tags, want)
+	}
+
+	// 旧版本直接存储在events.category中的分类在启动时转换
+	if _, err := db.Exec("INSERT INTO events (isTemplate, title, category) VALUES (0, 'legacy', ' home ')"); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := repo.NewTagRepo(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var remaining int
+	if err := db.QueryRow("SELECT COUNT(*) FROM events WHERE category IS NOT NULL").Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 0 {
+		t.Fatalf("%d events still have a category", remaining)
+	}
+	if got, want := tagUsage(t, tags), []string{"errand:1", "home:1", "urgent:3", "work:3"}; !slices.Equal(got, want) {
+		t.Fatalf("usage = %v, want %v", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"brb/internal/entity"
 	"fmt"
+	"time"
 )
 
 // taskService 实现handler.taskService接口
@@ -16,7 +17,10 @@ type taskRepository interface {
 	HaveID(id uint) bool
 	GetAll() ([]*entity.Task, error)
 	GetByID(id uint) (*entity.Task, error)
+	GetBySignID(signID int64) ([]*entity.Task, error)
+	GetByEventTags(f entity.TagFilter) ([]*entity.Task, error)
 	Update(task *entity.Task) error
+	GetByAssigneeID(assigneeID uint) ([]*entity.Task, error)
 	Delete(id uint) error
 	DeleteByEventID(eventID uint) error
 }
@@ -31,6 +35,9 @@ func NewTaskService(taskRepo taskRepository, todoRepo todoRepository) *taskServi
 
 // CreateTask 创建新的task
 func (s *taskService) CreateTask(task *entity.Task) error {
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
 	return s.taskRepo.Create(task)
 }
 
@@ -39,6 +46,16 @@ func (s *taskService) GetAllTasks() ([]*entity.Task, error) {
 	return s.taskRepo.GetAll()
 }
 
+// GetTasksBySign 获取关联了指定sign的task
+func (s *taskService) GetTasksBySign(signID int64) ([]*entity.Task, error) {
+	return s.taskRepo.GetBySignID(signID)
+}
+
+// GetTasksByEventTags 获取所属event带有指定标签的task
+func (s *taskService) GetTasksByEventTags(f entity.TagFilter) ([]*entity.Task, error) {
+	return s.taskRepo.GetByEventTags(f)
+}
+
 // GetTaskByID 根据ID获取task
 func (s *taskService) GetTaskByID(id uint) (*entity.Task, error) {
 	return s.taskRepo.GetByID(id)
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+)
+
+// templateService 实现handler.templateService接口
+type templateService struct {
+	eventRepo    eventRepository
+	templateRepo templateRepository
+}
+
+type templateRepository interface {
+	GetChecklist(eventID uint) (*entity.Checklist, error)
+	SetChecklist(eventID uint, checklist *entity.Checklist) error
+	Instantiate(tree *entity.TaskTree) error
+}
+
+// NewTemplateService 创建新的TemplateService实例
+func NewTemplateService(eventRepo eventRepository, templateRepo templateRepository) *templateService {
+	return &templateService{
+		eventRepo:    eventRepo,
+		templateRepo: templateRepo,
+	}
+}
+
+// GetChecklist 获取event的清单
+func (s *templateService) GetChecklist(eventID uint) (*entity.Checklist, error) {
+	if _, err := s.eventRepo.GetByID(eventID); err != nil {
+		return nil, err
+	}
+	return s.templateRepo.GetChecklist(eventID)
+}
+
+// SetChecklist 替换模板event的清单
+func (s *templateService) SetChecklist(eventID uint, checklist *entity.Checklist) error {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return err
+	}
+	if !event.IsTemplate {
+		return errs.Validation("只有模板event可以设置清单")
+	}
+	return s.templateRepo.SetChecklist(eventID, checklist)
+}
+
+// Instantiate 按模板event在时间窗口内创建task，并按清单创建子task和todo
+// 描述中的占位符按时间窗口填充，清单项的计划时间相对窗口开始（全天窗口为loc中首日0点）计算
+func (s *templateService) Instantiate(eventID uint, window entity.TimeSpan, loc *time.Location) (*entity.TaskTree, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !event.IsTemplate {
+		return nil, errs.Validation("event不是模板")
+	}
+	checklist, err := s.templateRepo.GetChecklist(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := *window.Start, *window.End
+	if window.AllDay {
+		start, end = localMidnight(start, loc), localMidnight(end, loc)
+	}
+	if err := checkChecklistFits(checklist, end.Sub(start)); err != nil {
+		return nil, err
+	}
+
+	values := placeholderValues(event, window, loc)
+	now := time.Now()
+	newTask := func(description string, planned entity.TimeSpan) *entity.Task {
+		return &entity.Task{
+			Description:     fillPlaceholders(description, values),
+			PreTaskIDs:      []uint{},
+			AllowedTime:     window,
+			PlannedDuration: planned,
+			Status:          entity.StatusPending,
+			CreatedAt:       now,
+			EventID:         event.ID,
+		}
+	}
+	newTodos := func(items []entity.ChecklistTodo) []*entity.Todo {
+		todos := make([]*entity.Todo, len(items))
+		for i, item := range items {
+			todos[i] = &entity.Todo{PlannedTime: item.Plan.Span(start), Status: entity.StatusPending}
+		}
+		return todos
+	}
+
+	description := event.Description
+	if description == "" {
+		description = event.Title
+	}
+	tree := &entity.TaskTree{
+		Task:     newTask(description, entity.TimeSpan{}),
+		Todos:    newTodos(checklist.Todos),
+		Subtasks: make([]*entity.TaskTree, len(checklist.Tasks)),
+	}
+	for i, item := range checklist.Tasks {
+		tree.Subtasks[i] = &entity.TaskTree{
+			Task:     newTask(item.Description, item.Plan.Span(start)),
+			Todos:    newTodos(item.Todos),
+			Subtasks: []*entity.TaskTree{},
+		}
+	}
+
+	if err := s.templateRepo.Instantiate(tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// localMidnight 全天时间段的日期在loc中的0点
+// 日期按t自身的时区取：存储的全天时间为UTC 0点，调用方也可能传入其他时区的0点
+func localMidnight(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// checkChecklistFits 清单项的计划时间须在时间窗口内
+func checkChecklistFits(checklist *entity.Checklist, window time.Duration) error {
+	var fields []errs.FieldError
+	check := func(field string, plan entity.ChecklistPlan) {
+		if plan.Duration > 0 && plan.Offset+plan.Duration > window {
+			fields = append(fields, errs.FieldError{Field: field, Message: "计划时间超出时间窗口"})
+		}
+	}
+	for i, todo := range checklist.Todos {
+		check(fmt.Sprintf("checklist.todos[%d]", i), todo.Plan)
+	}
+	for i, task := range checklist.Tasks {
+		check(fmt.Sprintf("checklist.tasks[%d]", i), task.Plan)
+		for j, todo := range task.Todos {
+			check(fmt.Sprintf("checklist.tasks[%d].todos[%d]", i, j), todo.Plan)
+		}
+	}
+	if len(fields) > 0 {
+		return errs.Validation("清单不适用于该时间窗口").WithFields(fields...)
+	}
+	return nil
+}
+
+// placeholder 描述中的占位符，如{{date}}
+var placeholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// placeholderValues 占位符的值：date为窗口首日，start、end为窗口起止（全天窗口为日期，结束日期包含当天），
+// weekday为首日的星期，title为模板标题
+func placeholderValues(event *entity.Event, window entity.TimeSpan, loc *time.Location) map[string]string {
+	const dateLayout, timeLayout = "2006-01-02", "2006-01-02 15:04"
+	start, end := window.Start.In(loc), window.End.In(loc)
+	values := map[string]string{"title": event.Title}
+	if window.AllDay {
+		start, end = localMidnight(*window.Start, loc), localMidnight(*window.End, loc).AddDate(0, 0, -1)
+		values["start"], values["end"] = start.Format(dateLayout), end.Format(dateLayout)
+	} else {
+		values["start"], values["end"] = start.Format(timeLayout), end.Format(timeLayout)
+	}
+	values["date"] = start.Format(dateLayout)
+	values["weekday"] = start.Weekday().String()
+	return values
+}
+
+// fillPlaceholders 替换已知的占位符，未知的保持原样
+func fillPlaceholders(text string, values map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		if value, ok := values[placeholder.FindStringSubmatch(match)[1]]; ok {
+			return value
+		}
+		return match
+	})
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/internal/repo"
+)
+
+// templateChecklist 模板event 1的清单：一个带todo的子task，以及一个有计划时间和一个没有计划时间的todo
+var templateChecklist = &entity.Checklist{
+	Tasks: []entity.ChecklistTask{{
+		Description: "Prepare {{title}} for {{date}}",
+		Plan:        entity.ChecklistPlan{Offset: time.Hour, Duration: 2 * time.Hour},
+		Todos:       []entity.ChecklistTodo{{Plan: entity.ChecklistPlan{Duration: 30 * time.Minute}}},
+	}},
+	Todos: []entity.ChecklistTodo{
+		{Plan: entity.ChecklistPlan{Offset: 9 * time.Hour, Duration: time.Hour}},
+		{},
+	},
+}
+
+// newTestTemplateService 创建带清单的模板event 1和普通event 2
+func newTestTemplateService(t *testing.T) (*sql.DB, *templateService) {
+	t.Helper()
+	db := newTestDB(t)
+	_, eventRepo, _ := newTestEventRepos(t, db)
+	templateRepo := must[templateRepository](t)(repo.NewTemplateRepo(db))
+
+	template := &entity.Event{IsTemplate: true, Title: "Release", Description: "{{title}} {{date}} ({{weekday}}) {{start}}..{{end}} {{owner}}"}
+	for _, event := range []*entity.Event{template, {Title: "standup"}} {
+		if err := eventRepo.Create(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewTemplateService(eventRepo, templateRepo)
+	if err := s.SetChecklist(template.ID, templateChecklist); err != nil {
+		t.Fatal(err)
+	}
+	return db, s
+}
+
+// timeSpan 构造时间段，全天时间段的start和end须为0点
+func timeSpan(start, end time.Time, allDay bool) entity.TimeSpan {
+	return entity.TimeSpan{Start: &start, End: &end, AllDay: allDay}
+}
+
+// formatPlan 将计划时间格式化为UTC的"开始/结束"，没有计划时间时为空
+func formatPlan(plan entity.TimeSpan) string {
+	if plan.Start == nil {
+		return ""
+	}
+	return plan.Start.UTC().Format(time.RFC3339) + "/" + plan.End.UTC().Format(time.RFC3339)
+}
+
+func TestInstantiate(t *testing.T) {
+	east, west := time.FixedZone("UTC+8", 8*3600), time.FixedZone("UTC-5", -5*3600)
+	date := func(day int, loc *time.Location) time.Time { return time.Date(2025, 3, day, 0, 0, 0, 0, loc) }
+
+	tests := []struct {
+		name    string
+		eventID uint
+		window  entity.TimeSpan
+		loc     *time.Location
+		wantErr errs.Kind
+		// 生成的task描述、子task描述，以及根task下第一个todo和子task的计划时间（UTC）
+		wantDescription, wantSubtask  string
+		wantTodoPlan, wantSubtaskPlan string
+	}{
+		{
+			name: "all-day window east of UTC", eventID: 1,
+			// 3月1日到3月2日，结束日期包含当天
+			window:          timeSpan(date(1, time.UTC), date(3, time.UTC), true),
+			loc:             east,
+			wantDescription: "Release 2025-03-01 (Saturday) 2025-03-01..2025-03-02 {{owner}}",
+			wantSubtask:     "Prepare Release for 2025-03-01",
+			wantTodoPlan:    "2025-03-01T01:00:00Z/2025-03-01T02:00:00Z",
+			wantSubtaskPlan: "2025-02-28T17:00:00Z/2025-02-28T19:00:00Z",
+		},
+		{
+			name: "all-day window west of UTC", eventID: 1,
+			window:          timeSpan(date(1, time.UTC), date(3, time.UTC), true),
+			loc:             west,
+			wantDescription: "Release 2025-03-01 (Saturday) 2025-03-01..2025-03-02 {{owner}}",
+			wantSubtask:     "Prepare Release for 2025-03-01",
+			wantTodoPlan:    "2025-03-01T14:00:00Z/2025-03-01T15:00:00Z",
+			wantSubtaskPlan: "2025-03-01T06:00:00Z/2025-03-01T08:00:00Z",
+		},
+		{
+			name: "all-day window given as local midnight", eventID: 1,
+			window:          timeSpan(date(1, east), date(3, east), true),
+			loc:             east,
+			wantDescription: "Release 2025-03-01 (Saturday) 2025-03-01..2025-03-02 {{owner}}",
+			wantSubtask:     "Prepare Release for 2025-03-01",
+			wantTodoPlan:    "2025-03-01T01:00:00Z/2025-03-01T02:00:00Z",
+			wantSubtaskPlan: "2025-02-28T17:00:00Z/2025-02-28T19:00:00Z",
+		},
+		{
+			name: "timed window uses the local date", eventID: 1,
+			// UTC已是3月2日，loc中仍是3月1日
+			window:          timeSpan(time.Date(2025, 3, 1, 23, 0, 0, 0, west), time.Date(2025, 3, 2, 11, 0, 0, 0, west), false),
+			loc:             west,
+			wantDescription: "Release 2025-03-01 (Saturday) 2025-03-01 23:00..2025-03-02 11:00 {{owner}}",
+			wantSubtask:     "Prepare Release for 2025-03-01",
+			wantTodoPlan:    "2025-03-02T13:00:00Z/2025-03-02T14:00:00Z",
+			wantSubtaskPlan: "2025-03-02T05:00:00Z/2025-03-02T07:00:00Z",
+		},
+		{
+			name: "checklist does not fit the window", eventID: 1,
+			window:  timeSpan(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC), false),
+			loc:     time.UTC,
+			wantErr: errs.KindValidation,
+		},
+		{
+			name: "not a template", eventID: 2,
+			window:  timeSpan(date(1, time.UTC), date(2, time.UTC), true),
+			loc:     time.UTC,
+			wantErr: errs.KindValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, s := newTestTemplateService(t)
+
+			tree, err := s.Instantiate(tt.eventID, tt.window, tt.loc)
+			if got := errKind(err); got != tt.wantErr {
+				t.Fatalf("err = %v, want kind %q", err, tt.wantErr)
+			}
+			var tasks int
+			if err := db.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&tasks); err != nil {
+				t.Fatal(err)
+			}
+			if err != nil {
+				if tasks != 0 {
+					t.Fatalf("%d tasks created for a failed instantiation", tasks)
+				}
+				return
+			}
+
+			// 根task、一个子task，根task下两个todo，子task下一个todo
+			if tasks != 2 || len(tree.Todos) != 2 || len(tree.Subtasks) != 1 || len(tree.Subtasks[0].Todos) != 1 || len(tree.Subtasks[0].Subtasks) != 0 {
+				t.Fatalf("tree has %d tasks, %d todos and %d subtasks", tasks, len(tree.Todos), len(tree.Subtasks))
+			}
+			root, sub := tree.Task, tree.Subtasks[0].Task
+			if root.Description != tt.wantDescription || sub.Description != tt.wantSubtask {
+				t.Fatalf("descriptions = %q, %q, want %q, %q", root.Description, sub.Description, tt.wantDescription, tt.wantSubtask)
+			}
+			if root.EventID != tt.eventID || sub.EventID != tt.eventID || sub.ParentTaskID == nil || *sub.ParentTaskID != root.ID {
+				t.Fatalf("root = %+v, subtask = %+v", root, sub)
+			}
+			for _, task := range []*entity.Task{root, sub} {
+				if task.AllowedTime != tt.window || task.Status != entity.StatusPending {
+					t.Fatalf("task %d allowed time = %+v, status = %q", task.ID, task.AllowedTime, task.Status)
+				}
+			}
+			if !slices.EqualFunc(tree.Todos, []uint{root.ID, root.ID}, func(todo *entity.Todo, id uint) bool { return todo.TaskID == id }) || tree.Subtasks[0].Todos[0].TaskID != sub.ID {
+				t.Fatalf("todos are not linked to their tasks")
+			}
+
+			plans := []string{formatPlan(tree.Todos[0].PlannedTime), formatPlan(sub.PlannedDuration), formatPlan(root.PlannedDuration), formatPlan(tree.Todos[1].PlannedTime)}
+			if want := []string{tt.wantTodoPlan, tt.wantSubtaskPlan, "", ""}; !slices.Equal(plans, want) {
+				t.Fatalf("plans = %q, want %q", plans, want)
+			}
+		})
+	}
+}
+
+func TestInstantiateChecklistFields(t *testing.T) {
+	_, s := newTestTemplateService(t)
+	window := timeSpan(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC), false)
+
+	_, err := s.Instantiate(1, window, time.UTC)
+	var e *errs.Error
+	if !errors.As(err, &e) || e.Kind != errs.KindValidation {
+		t.Fatalf("err = %v, want a validation error", err)
+	}
+	// 子task的计划时间和根task下有计划时间的todo超出两小时的窗口，子task的todo和没有计划时间的todo不受限制
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f.Field
+	}
+	if want := []string{"checklist.todos[0]", "checklist.tasks[0]"}; !slices.Equal(fields, want) {
+		t.Fatalf("fields = %v, want %v", fields, want)
+	}
+}
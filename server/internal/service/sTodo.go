@@ -2,8 +2,10 @@ package service
 
 import (
 	"brb/internal/entity"
-	"brb/pkg/logger"
+	"brb/internal/errs"
+	"context"
 	"fmt"
+	"log/slog"
 )
 
 // todoService 实现handler.todoService接口
@@ -17,7 +19,9 @@ type todoRepository interface {
 	Create(todo *entity.Todo) error
 	GetAll() ([]*entity.Todo, error)
 	GetByID(id uint) (*entity.Todo, error)
+	GetBySignID(signID int64) ([]*entity.Todo, error)
 	Update(todo *entity.Todo) error
+	GetByAssigneeID(assigneeID uint) ([]*entity.Todo, error)
 	Delete(id uint) error
 	DeleteByTaskID(taskID uint) error
 }
@@ -35,7 +39,7 @@ func NewTodoService(todoRepo todoRepository, taskRepo taskRepository, eventRepo
 func (s *todoService) CreateTodo(todo *entity.Todo) error {
 	// 检查关联的Task是否存在
 	if !s.taskRepo.HaveID(todo.TaskID) {
-		return fmt.Errorf("关联的Task不存在")
+		return errs.Validation("关联的Task不存在")
 	}
 
 	// 验证Todo时间范围是否在Task的时间范围内
@@ -47,38 +51,38 @@ func (s *todoService) CreateTodo(todo *entity.Todo) error {
 	// 检查计划时间是否在任务时间范围内
 	if todo.PlannedTime.Start != nil && task.PlannedDuration.Start != nil && task.PlannedDuration.End != nil {
 		if todo.PlannedTime.Start.Before(*task.PlannedDuration.Start) || todo.PlannedTime.Start.After(*task.PlannedDuration.End) {
-			return fmt.Errorf("todo planned start time must be within task time range")
+			return errs.Validation("todo planned start time must be within task time range")
 		}
 	}
 
 	if todo.PlannedTime.End != nil && task.PlannedDuration.Start != nil && task.PlannedDuration.End != nil {
 		if todo.PlannedTime.End.Before(*task.PlannedDuration.Start) || todo.PlannedTime.End.After(*task.PlannedDuration.End) {
-			return fmt.Errorf("todo planned end time must be within task time range")
+			return errs.Validation("todo planned end time must be within task time range")
 		}
 	}
 
 	if todo.PlannedTime.Start != nil && todo.PlannedTime.End != nil {
 		if todo.PlannedTime.End.Before(*todo.PlannedTime.Start) {
-			return fmt.Errorf("todo planned end time cannot be before start time")
+			return errs.Validation("todo planned end time cannot be before start time")
 		}
 	}
 
 	// 检查实际时间是否在任务时间范围内
 	if todo.ActualTime.Start != nil && task.PlannedDuration.Start != nil && task.PlannedDuration.End != nil {
 		if todo.ActualTime.Start.Before(*task.PlannedDuration.Start) || todo.ActualTime.Start.After(*task.PlannedDuration.End) {
-			return fmt.Errorf("todo actual start time must be within task time range")
+			return errs.Validation("todo actual start time must be within task time range")
 		}
 	}
 
 	if todo.ActualTime.End != nil && task.PlannedDuration.Start != nil && task.PlannedDuration.End != nil {
 		if todo.ActualTime.End.Before(*task.PlannedDuration.Start) || todo.ActualTime.End.After(*task.PlannedDuration.End) {
-			return fmt.Errorf("todo actual end time must be within task time range")
+			return errs.Validation("todo actual end time must be within task time range")
 		}
 	}
 
 	if todo.ActualTime.Start != nil && todo.ActualTime.End != nil {
 		if todo.ActualTime.End.Before(*todo.ActualTime.Start) {
-			return fmt.Errorf("todo actual end time cannot be before start time")
+			return errs.Validation("todo actual end time cannot be before start time")
 		}
 	}
 
@@ -96,9 +100,9 @@ func (s *todoService) GetTodoByID(id uint) (*entity.Todo, error) {
 }
 
 // UpdateTodo 更新todo
-func (s *todoService) UpdateTodo(todo *entity.Todo) error {
+func (s *todoService) UpdateTodo(ctx context.Context, todo *entity.Todo) error {
 	// 验证Todo时间范围是否在Task的时间范围内
-	logger.Tip.Println("Checking todo...",todo)
+	slog.DebugContext(ctx, "Checking todo...", "todo", todo)
 	task, err := s.taskRepo.GetByID(todo.TaskID)
 	if err != nil {
 		return fmt.Errorf("failed to get task %d: %w", todo.TaskID, err)
@@ -107,38 +111,38 @@ func (s *todoService) UpdateTodo(todo *entity.Todo) error {
 	// 检查计划时间是否在任务时间范围内
 	if todo.PlannedTime.Start != nil && task.PlannedDuration.Start != nil && task.PlannedDuration.End != nil {
 		if todo.PlannedTime.Start.Before(*task.PlannedDuration.Start) || todo.PlannedTime.Start.After(*task.PlannedDuration.End) {
-			return fmt.Errorf("todo planned start time must be within task time range")
+			return errs.Validation("todo planned start time must be within task time range")
 		}
 	}
 
 	if todo.PlannedTime.End != nil && task.PlannedDuration.Start != nil && task.PlannedDuration.End != nil {
 		if todo.PlannedTime.End.Before(*task.PlannedDuration.Start) || todo.PlannedTime.End.After(*task.PlannedDuration.End) {
-			return fmt.Errorf("todo planned end time must be within task time range")
+			return errs.Validation("todo planned end time must be within task time range")
 		}
 	}
 
 	if todo.PlannedTime.Start != nil && todo.PlannedTime.End != nil {
 		if todo.PlannedTime.End.Before(*todo.PlannedTime.Start) {
-			return fmt.Errorf("todo planned end time cannot be before start time")
+			return errs.Validation("todo planned end time cannot be before start time")
 		}
 	}
 
 	// 检查实际时间是否在任务时间范围内
 	if todo.ActualTime.Start != nil && task.PlannedDuration.Start != nil && task.PlannedDuration.End != nil {
 		if todo.ActualTime.Start.Before(*task.PlannedDuration.Start) || todo.ActualTime.Start.After(*task.PlannedDuration.End) {
-			return fmt.Errorf("todo actual start time must be within task time range")
+			return errs.Validation("todo actual start time must be within task time range")
 		}
 	}
 
 	if todo.ActualTime.End != nil && task.PlannedDuration.Start != nil && task.PlannedDuration.End != nil {
 		if todo.ActualTime.End.Before(*task.PlannedDuration.Start) || todo.ActualTime.End.After(*task.PlannedDuration.End) {
-			return fmt.Errorf("todo actual end time must be within task time range")
+			return errs.Validation("todo actual end time must be within task time range")
 		}
 	}
 
 	if todo.ActualTime.Start != nil && todo.ActualTime.End != nil {
 		if todo.ActualTime.End.Before(*todo.ActualTime.Start) {
-			return fmt.Errorf("todo actual end time cannot be before start time")
+			return errs.Validation("todo actual end time cannot be before start time")
 		}
 	}
 
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/pkg/totp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	totpIssuer           = "brb"
+	totpSkew             = 1  // 允许前后各一个时间步的时钟偏差
+	recoveryCodeCount    = 10 // 每次生成的恢复码数量
+	recoveryCodeLength   = 10 // 恢复码长度
+	recoveryCodeAlpha    = "abcdefghjkmnpqrstuvwxyz23456789"
+	require2FASettingKey = "auth.require_2fa."
+)
+
+type recoveryCodeRepository interface {
+	ReplaceForUser(userID uint, codeHashes []string) error
+	Consume(userID uint, codeHash string, usedAt time.Time) (bool, error)
+	DeleteByUserID(userID uint) error
+}
+
+type settingRepository interface {
+	Get(key string) (string, bool, error)
+	Set(key, value string) error
+}
+
+// SecondFactorRequirement 判断用户登录时对第二因素的要求
+func (s *userService) SecondFactorRequirement(user *entity.User) (entity.SecondFactor, error) {
+	if user.TOTPEnabled {
+		return entity.SecondFactorVerify, nil
+	}
+
+	required, err := s.IsTwoFactorRequired(user.Role)
+	if err != nil {
+		return entity.SecondFactorNone, err
+	}
+	if required {
+		return entity.SecondFactorEnroll, nil
+	}
+	return entity.SecondFactorNone, nil
+}
+
+// IsTwoFactorRequired 查询指定角色是否必须启用两步验证
+func (s *userService) IsTwoFactorRequired(role entity.Role) (bool, error) {
+	value, ok, err := s.settingRepo.Get(require2FASettingKey + string(role))
+	if err != nil {
+		return false, err
+	}
+	return ok && value == "true", nil
+}
+
+// SetTwoFactorRequired 设置指定角色是否必须启用两步验证（仅管理员可操作）
+func (s *userService) SetTwoFactorRequired(ctx context.Context, role entity.Role, required bool) error {
+	if role != entity.RoleAdmin && role != entity.RoleUser {
+		return errs.Validation("无效的角色: %s", role)
+	}
+
+	if err := s.settingRepo.Set(require2FASettingKey+string(role), fmt.Sprint(required)); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "两步验证要求已更新", "role", role, "required", required)
+	return nil
+}
+
+// BeginTOTPEnrollment 生成新的TOTP密钥，返回密钥和供认证器扫描的otpauth地址
+func (s *userService) BeginTOTPEnrollment(userID uint) (string, string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return "", "", errs.NotFound("用户不存在")
+	}
+	if user.TOTPEnabled {
+		return "", "", errs.Conflict("两步验证已启用")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	// 密钥在确认前处于待定状态，登录时不会要求验证
+	user.TOTPSecret = secret
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return "", "", fmt.Errorf("保存TOTP密钥失败: %w", err)
+	}
+
+	return secret, totp.ProvisioningURI(totpIssuer, user.Username, secret), nil
+}
+
+// ConfirmTOTPEnrollment 用认证器生成的验证码确认绑定，启用两步验证并返回恢复码
+func (s *userService) ConfirmTOTPEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errs.Conflict("两步验证已启用")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errs.Conflict("请先开始绑定两步验证")
+	}
+
+	counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, errs.Validation("验证码错误")
+	}
+
+	codes, err := s.issueRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	user.TOTPLastCounter = counter
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("启用两步验证失败: %w", err)
+	}
+
+	slog.InfoContext(ctx, "用户已启用两步验证", "username", user.Username, "id", user.ID)
+	return codes, nil
+}
+
+// VerifySecondFactor 校验登录时的TOTP验证码或恢复码，失败计入登录失败次数
+func (s *userService) VerifySecondFactor(ctx context.Context, userID uint, code, ip string) (*entity.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, errs.Conflict("未启用两步验证")
+	}
+
+	now := time.Now()
+	attempts, err := s.beginLogin(user.Username, ip, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, code, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.failLogin(ctx, attempts, now); err != nil {
+			return nil, err
+		}
+		return nil, errs.Unauthorized("验证码错误")
+	}
+
+	if err := s.succeedLogin(attempts); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "用户两步验证成功", "username", user.Username, "id", user.ID)
+	return user, nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *userService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, errs.Conflict("未启用两步验证")
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.Validation("验证码错误")
+	}
+
+	return s.issueRecoveryCodes(user.ID)
+}
+
+// DisableTOTP 用户验证后关闭自己的两步验证
+func (s *userService) DisableTOTP(ctx context.Context, userID uint, code string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return errs.Conflict("未启用两步验证")
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.Validation("验证码错误")
+	}
+
+	return s.clearTOTP(ctx, user)
+}
+
+// ResetTOTP 管理员为丢失认证器的用户清除两步验证
+func (s *userService) ResetTOTP(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	return s.clearTOTP(ctx, user)
+}
+
+// clearTOTP 清除用户的TOTP密钥和恢复码
+func (s *userService) clearTOTP(ctx context.Context, user *entity.User) error {
+	if err := s.recoveryRepo.DeleteByUserID(user.ID); err != nil {
+		return fmt.Errorf("删除恢复码失败: %w", err)
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	user.TOTPLastCounter = 0
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("关闭两步验证失败: %w", err)
+	}
+
+	slog.InfoContext(ctx, "用户两步验证已关闭", "username", user.Username, "id", user.ID)
+	return nil
+}
+
+// checkSecondFactor 校验TOTP验证码（拒绝重放）或一次性恢复码
+func (s *userService) checkSecondFactor(ctx context.Context, user *entity.User, code string, now time.Time) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if counter, ok := totp.Validate(user.TOTPSecret, code, now, totpSkew); ok {
+		// 在数据库中以条件更新记录时间步，并发使用同一验证码时只有一个请求成功
+		advanced, err := s.userRepo.AdvanceTOTPCounter(user.ID, counter, now)
+		if err != nil {
+			return false, fmt.Errorf("更新TOTP状态失败: %w", err)
+		}
+		if advanced {
+			user.TOTPLastCounter = counter
+			user.UpdatedAt = now
+		}
+		return advanced, nil
+	}
+
+	used, err := s.recoveryRepo.Consume(user.ID, hashRecoveryCode(code), now)
+	if err != nil {
+		return false, fmt.Errorf("校验恢复码失败: %w", err)
+	}
+	if used {
+		slog.InfoContext(ctx, "用户使用了恢复码登录", "username", user.Username, "id", user.ID)
+	}
+	return used, nil
+}
+
+// issueRecoveryCodes 生成新的恢复码并替换旧的，返回明文（仅展示一次）
+func (s *userService) issueRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("生成恢复码失败: %w", err)
+		}
+		for j, b := range raw {
+			raw[j] = recoveryCodeAlpha[int(b)%len(recoveryCodeAlpha)]
+		}
+		codes[i] = string(raw[:5]) + "-" + string(raw[5:])
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.recoveryRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, fmt.Errorf("保存恢复码失败: %w", err)
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode 计算恢复码摘要，忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"brb/internal/entity"
+	"brb/internal/errs"
+	"brb/pkg/totp"
+)
+
+// enrollTOTP 注册用户并用当前时间步的验证码启用两步验证，返回用户、该时间步和恢复码
+func enrollTOTP(t *testing.T, s *userService) (*entity.User, string, int64, []string) {
+	t.Helper()
+	user, err := s.Register(t.Context(), "alice", "secret-pass-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, _, err := s.BeginTOTPEnrollment(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := totp.Counter(time.Now())
+	codes, err := s.ConfirmTOTPEnrollment(t.Context(), user.ID, totpCode(t, secret, counter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, secret, counter, codes
+}
+
+// totpCode 计算指定时间步的验证码
+func totpCode(t *testing.T, secret string, counter int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	tests := []struct {
+		name string
+		// codes 依次提交的验证码，由密钥、启用时的时间步和恢复码生成
+		codes    func(secret string, counter int64, recovery []string) []string
+		wantKind []errs.Kind
+	}{
+		{
+			name: "next code",
+			codes: func(secret string, counter int64, _ []string) []string {
+				return []string{totpCode(t, secret, counter+1)}
+			},
+			wantKind: []errs.Kind{""},
+		},
+		{
+			name: "replayed code",
+			codes: func(secret string, counter int64, _ []string) []string {
+				return []string{totpCode(t, secret, counter+1), totpCode(t, secret, counter+1)}
+			},
+			wantKind: []errs.Kind{"", errs.KindUnauthorized},
+		},
+		{
+			name:     "code used for enrollment",
+			codes:    func(secret string, counter int64, _ []string) []string { return []string{totpCode(t, secret, counter)} },
+			wantKind: []errs.Kind{errs.KindUnauthorized},
+		},
+		{
+			name:     "recovery code is single use",
+			codes:    func(_ string, _ int64, recovery []string) []string { return []string{recovery[0], recovery[0]} },
+			wantKind: []errs.Kind{"", errs.KindUnauthorized},
+		},
+		{
+			name: "recovery code ignores case and separator",
+			codes: func(_ string, _ int64, recovery []string) []string {
+				return []string{" " + strings.ToUpper(strings.ReplaceAll(recovery[1], "-", "")) + " "}
+			},
+			wantKind: []errs.Kind{""},
+		},
+		{
+			name:     "unknown code",
+			codes:    func(string, int64, []string) []string { return []string{"abcde-fghjk"} },
+			wantKind: []errs.Kind{errs.KindUnauthorized},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestUserService(t, newTestDB(t))
+			user, secret, counter, recovery := enrollTOTP(t, s)
+
+			for i, code := range tt.codes(secret, counter, recovery) {
+				_, err := s.VerifySecondFactor(t.Context(), user.ID, code, "10.0.0.1")
+				if got := errKind(err); got != tt.wantKind[i] {
+					t.Fatalf("code %d: got error %v, want kind %q", i+1, err, tt.wantKind[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVerifySecondFactorConcurrentReplay(t *testing.T) {
+	const n = 5
+	s := newTestUserService(t, newTestDB(t))
+	s.loginPolicy.BackoffThreshold = 100
+	user, secret, counter, _ := enrollTOTP(t, s)
+	code := totpCode(t, secret, counter+1)
+
+	var wg sync.WaitGroup
+	results := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i] = s.VerifySecondFactor(t.Context(), user.ID, code, "10.0.0.1")
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range results {
+		switch {
+		case err == nil:
+			succeeded++
+		case errs.KindOf(err) != errs.KindUnauthorized:
+			t.Errorf("request %d: unexpected error %v", i, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d requests accepted the same code, want exactly 1", succeeded)
+	}
+}
+
+func TestSecondFactorKeepsConcurrentChanges(t *testing.T) {
+	s := newTestUserService(t, newTestDB(t))
+	user, secret, counter, _ := enrollTOTP(t, s)
+
+	// 校验前读取到的用户信息已过时：期间管理员修改了角色
+	stale, err := s.userRepo.GetByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := *stale
+	changed.Role = entity.RoleAdmin
+	if err := s.userRepo.Update(&changed); err != nil {
+		t.Fatal(err)
+	}
+	ok, err := s.checkSecondFactor(t.Context(), stale, totpCode(t, secret, counter+1), time.Now())
+	if err != nil || !ok {
+		t.Fatalf("checkSecondFactor = %v, %v, want accepted", ok, err)
+	}
+
+	stored, err := s.userRepo.GetByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Role != entity.RoleAdmin || stored.TOTPLastCounter != counter+1 {
+		t.Fatalf("stored role = %s, counter = %d, want admin and %d", stored.Role, stored.TOTPLastCounter, counter+1)
+	}
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"brb/internal/entity"
-	"brb/pkg/logger"
+	"brb/internal/errs"
+	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -11,7 +13,13 @@ import (
 
 // userService 实现用户业务逻辑
 type userService struct {
-	userRepo userRepository
+	userRepo       userRepository
+	attemptRepo    loginAttemptRepository
+	resetRepo      passwordResetRepository
+	recoveryRepo   recoveryCodeRepository
+	settingRepo    settingRepository
+	loginPolicy    LoginPolicy
+	passwordPolicy PasswordPolicy
 }
 
 type userRepository interface {
@@ -20,39 +28,46 @@ type userRepository interface {
 	GetByUsername(username string) (*entity.User, error)
 	GetAll() ([]*entity.User, error)
 	Update(user *entity.User) error
+	AdvanceTOTPCounter(id uint, counter int64, updatedAt time.Time) (bool, error)
 	Delete(id uint) error
 	ExistsByUsername(username string) (bool, error)
 	HaveID(id uint) bool
 }
 
 // NewUserService 创建新的用户Service实例
-func NewUserService(userRepo userRepository) *userService {
+func NewUserService(userRepo userRepository, attemptRepo loginAttemptRepository, resetRepo passwordResetRepository, recoveryRepo recoveryCodeRepository, settingRepo settingRepository, loginPolicy LoginPolicy, passwordPolicy PasswordPolicy) *userService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		attemptRepo:    attemptRepo,
+		resetRepo:      resetRepo,
+		recoveryRepo:   recoveryRepo,
+		settingRepo:    settingRepo,
+		loginPolicy:    loginPolicy,
+		passwordPolicy: passwordPolicy,
 	}
 }
 
 // Register 用户注册
-func (s *userService) Register(username, password string) (*entity.User, error) {
+func (s *userService) Register(ctx context.Context, username, password string) (*entity.User, error) {
 	// 检查用户名是否已存在
 	exists, err := s.userRepo.ExistsByUsername(username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check username existence: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("用户名已存在")
+		return nil, errs.Conflict("用户名已存在")
 	}
 
-	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	// 校验并加密密码
+	hashedPassword, err := s.hashPassword(username, password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, err
 	}
 
 	// 创建用户
 	user := &entity.User{
 		Username:  username,
-		Password:  string(hashedPassword),
+		Password:  hashedPassword,
 		Role:      entity.RoleUser, // 注册时固定为user角色
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -62,24 +77,51 @@ func (s *userService) Register(username, password string) (*entity.User, error)
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	logger.Tip.Printf("用户注册成功: %s (ID: %d)", username, user.ID)
+	slog.InfoContext(ctx, "用户注册成功", "username", username, "id", user.ID)
 	return user, nil
 }
 
-// Login 用户登录
-func (s *userService) Login(username, password string) (*entity.User, error) {
-	// 根据用户名获取用户
+// Login 用户登录，被要求修改密码的用户返回ErrPasswordChangeRequired
+func (s *userService) Login(ctx context.Context, username, password, ip string) (*entity.User, error) {
+	user, err := s.authenticate(ctx, username, password, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MustChangePassword {
+		return nil, entity.ErrPasswordChangeRequired
+	}
+
+	slog.InfoContext(ctx, "用户登录成功", "username", username, "id", user.ID)
+	return user, nil
+}
+
+// authenticate 校验用户名和密码，按用户名和IP统计连续失败次数并进行退避与锁定
+func (s *userService) authenticate(ctx context.Context, username, password, ip string) (*entity.User, error) {
+	now := time.Now()
+
+	attempts, err := s.beginLogin(username, ip, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// 根据用户名获取用户并验证密码
 	user, err := s.userRepo.GetByUsername(username)
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	}
 	if err != nil {
-		return nil, fmt.Errorf("用户名或密码错误")
+		if err := s.failLogin(ctx, attempts, now); err != nil {
+			return nil, err
+		}
+		return nil, errs.Unauthorized("用户名或密码错误")
 	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, fmt.Errorf("用户名或密码错误")
+	// 登录成功后清除该用户名和IP的失败计数
+	if err := s.succeedLogin(attempts); err != nil {
+		return nil, err
 	}
 
-	logger.Tip.Printf("用户登录成功: %s (ID: %d)", username, user.ID)
 	return user, nil
 }
 
@@ -87,7 +129,7 @@ func (s *userService) Login(username, password string) (*entity.User, error) {
 func (s *userService) GetUserByID(id uint) (*entity.User, error) {
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("用户不存在")
+		return nil, errs.NotFound("用户不存在")
 	}
 	return user, nil
 }
@@ -102,11 +144,11 @@ func (s *userService) GetAllUsers() ([]*entity.User, error) {
 }
 
 // UpdateUser 更新用户信息
-func (s *userService) UpdateUser(id uint, username, password string, role entity.Role) (*entity.User, error) {
+func (s *userService) UpdateUser(ctx context.Context, id uint, username, password string, role entity.Role, timezone string) (*entity.User, error) {
 	// 获取现有用户
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("用户不存在")
+		return nil, errs.NotFound("用户不存在")
 	}
 
 	// 更新用户名（如果提供了新用户名）
@@ -114,18 +156,18 @@ func (s *userService) UpdateUser(id uint, username, password string, role entity
 		// 检查新用户名是否已被其他用户使用
 		existingUser, err := s.userRepo.GetByUsername(username)
 		if err == nil && existingUser.ID != id {
-			return nil, fmt.Errorf("用户名已被其他用户使用")
+			return nil, errs.Conflict("用户名已被其他用户使用")
 		}
 		user.Username = username
 	}
 
 	// 更新密码（如果提供了新密码）
 	if password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		hashedPassword, err := s.hashPassword(user.Username, password)
 		if err != nil {
-			return nil, fmt.Errorf("密码加密失败: %w", err)
+			return nil, err
 		}
-		user.Password = string(hashedPassword)
+		user.Password = hashedPassword
 	}
 
 	// 更新角色（如果提供了新角色）
@@ -133,71 +175,93 @@ func (s *userService) UpdateUser(id uint, username, password string, role entity
 		user.Role = role
 	}
 
+	// 更新时区（如果提供了新时区）
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, errs.Validation("无效的时区").WithFields(errs.FieldError{Field: "timezone", Message: "无效的时区"})
+		}
+		user.Timezone = timezone
+	}
+
 	user.UpdatedAt = time.Now()
 
 	if err := s.userRepo.Update(user); err != nil {
 		return nil, fmt.Errorf("更新用户失败: %w", err)
 	}
 
-	logger.Tip.Printf("用户信息已更新: %s (ID: %d)", user.Username, user.ID)
+	slog.InfoContext(ctx, "用户信息已更新", "username", user.Username, "id", user.ID)
 	return user, nil
 }
 
+// UserLocation 获取用户时区，用于解析和输出该用户请求中的时间
+func (s *userService) UserLocation(id uint) (*time.Location, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return user.Location(), nil
+}
+
 // DeleteUser 删除用户
-func (s *userService) DeleteUser(id uint) error {
+func (s *userService) DeleteUser(ctx context.Context, id uint) error {
 	// 检查用户是否存在
 	if !s.userRepo.HaveID(id) {
-		return fmt.Errorf("用户不存在")
+		return errs.NotFound("用户不存在")
 	}
 
 	if err := s.userRepo.Delete(id); err != nil {
 		return fmt.Errorf("删除用户失败: %w", err)
 	}
 
-	logger.Tip.Printf("用户已删除: ID %d", id)
+	slog.InfoContext(ctx, "用户已删除", "id", id)
 	return nil
 }
 
 // ChangePassword 修改密码
-func (s *userService) ChangePassword(id uint, oldPassword, newPassword string) error {
+func (s *userService) ChangePassword(ctx context.Context, id uint, oldPassword, newPassword string) error {
 	// 获取用户
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("用户不存在")
+		return errs.NotFound("用户不存在")
 	}
 
 	// 验证旧密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)); err != nil {
-		return fmt.Errorf("旧密码错误")
+		return errs.Validation("旧密码错误")
+	}
+
+	if newPassword == oldPassword {
+		return errs.Validation("新密码不能与旧密码相同")
 	}
 
-	// 加密新密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	// 校验并加密新密码
+	hashedPassword, err := s.hashPassword(user.Username, newPassword)
 	if err != nil {
-		return fmt.Errorf("密码加密失败: %w", err)
+		return err
 	}
 
 	// 更新密码
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
+	user.MustChangePassword = false
 	user.UpdatedAt = time.Now()
 
 	if err := s.userRepo.Update(user); err != nil {
 		return fmt.Errorf("密码更新失败: %w", err)
 	}
 
-	logger.Tip.Printf("用户密码已修改: %s (ID: %d)", user.Username, user.ID)
+	slog.InfoContext(ctx, "用户密码已修改", "username", user.Username, "id", user.ID)
 	return nil
 }
 
 // PromoteToAdmin 提升用户为管理员（仅管理员可操作）
-func (s *userService) PromoteToAdmin(id uint) error {
+func (s *userService) PromoteToAdmin(ctx context.Context, id uint) error {
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("用户不存在")
+		return errs.NotFound("用户不存在")
 	}
 
 	if user.Role == entity.RoleAdmin {
-		return fmt.Errorf("用户已是管理员")
+		return errs.Conflict("用户已是管理员")
 	}
 
 	user.Role = entity.RoleAdmin
@@ -207,19 +271,19 @@ func (s *userService) PromoteToAdmin(id uint) error {
 		return fmt.Errorf("提升用户权限失败: %w", err)
 	}
 
-	logger.Tip.Printf("用户权限已提升为管理员: %s (ID: %d)", user.Username, user.ID)
+	slog.InfoContext(ctx, "用户权限已提升为管理员", "username", user.Username, "id", user.ID)
 	return nil
 }
 
 // DemoteToUser 降级用户为普通用户（仅管理员可操作）
-func (s *userService) DemoteToUser(id uint) error {
+func (s *userService) DemoteToUser(ctx context.Context, id uint) error {
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("用户不存在")
+		return errs.NotFound("用户不存在")
 	}
 
 	if user.Role == entity.RoleUser {
-		return fmt.Errorf("用户已是普通用户")
+		return errs.Conflict("用户已是普通用户")
 	}
 
 	user.Role = entity.RoleUser
@@ -229,6 +293,6 @@ func (s *userService) DemoteToUser(id uint) error {
 		return fmt.Errorf("降级用户权限失败: %w", err)
 	}
 
-	logger.Tip.Printf("用户权限已降级为普通用户: %s (ID: %d)", user.Username, user.ID)
+	slog.InfoContext(ctx, "用户权限已降级为普通用户", "username", user.Username, "id", user.ID)
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,153 @@
+// Package langtag 校验BCP 47语言标签并按Accept-Language协商语言
+//
+// 只检查标签的语法（RFC 5646的常用子集），不核对子标签是否已注册。
+package langtag
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Canonical 校验语言标签并规范大小写：语言小写、文字首字母大写、地区大写，如zh-Hans-CN
+// 扩展和私用部分（如-x-...）按小写保留
+func Canonical(tag string) (string, bool) {
+	parts := strings.Split(tag, "-")
+	if !isAlpha(parts[0]) || len(parts[0]) < 2 || len(parts[0]) > 8 || len(parts[0]) == 4 {
+		return "", false
+	}
+	for i, part := range parts {
+		if part == "" || len(part) > 8 || !isAlnum(part) {
+			return "", false
+		}
+		lower := strings.ToLower(part)
+		switch {
+		case i == 0:
+			parts[i] = lower
+		case len(part) == 1:
+			// 单字符子标签之后是扩展或私用部分，不再区分文字和地区
+			for j := i; j < len(parts); j++ {
+				if parts[j] == "" || len(parts[j]) > 8 || !isAlnum(parts[j]) {
+					return "", false
+				}
+				parts[j] = strings.ToLower(parts[j])
+			}
+			if i == len(parts)-1 {
+				return "", false
+			}
+			return strings.Join(parts, "-"), true
+		case len(part) == 4 && isAlpha(part):
+			parts[i] = strings.ToUpper(lower[:1]) + lower[1:]
+		case len(part) == 2 && isAlpha(part), len(part) == 3 && isDigit(part):
+			parts[i] = strings.ToUpper(part)
+		default:
+			parts[i] = lower
+		}
+	}
+	return strings.Join(parts, "-"), true
+}
+
+// ParseAcceptLanguage 解析Accept-Language请求头，按权重从高到低返回语言范围
+// 权重相同时保持原顺序，q=0和无效的项被忽略，通配符*不返回（即回退到默认值）
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var ranges []weighted
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		tag = strings.TrimSpace(tag)
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			var err error
+			if q, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		if tag == "" || tag == "*" || q == 0 {
+			continue
+		}
+		if canonical, ok := Canonical(tag); ok {
+			ranges = append(ranges, weighted{canonical, q})
+		}
+	}
+	slices.SortStableFunc(ranges, func(a, b weighted) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	tags := make([]string, len(ranges))
+	for i, r := range ranges {
+		tags[i] = r.tag
+	}
+	return tags
+}
+
+// Match 按优先顺序为每个语言范围在tags中查找可用的语言，返回第一个匹配
+// 先逐级去掉末尾子标签精确匹配（zh-Hans-CN→zh-Hans→zh），
+// 都不匹配时再按同样顺序接受更具体的标签（zh-CN→zh→zh-Hans）
+func Match(ranges, tags []string) (string, bool) {
+	for _, r := range ranges {
+		for prefix := r; prefix != ""; prefix = truncate(prefix) {
+			for _, tag := range tags {
+				if strings.EqualFold(tag, prefix) {
+					return tag, true
+				}
+			}
+		}
+		for prefix := r; prefix != ""; prefix = truncate(prefix) {
+			for _, tag := range tags {
+				if len(tag) > len(prefix) && strings.EqualFold(tag[:len(prefix)], prefix) && tag[len(prefix)] == '-' {
+					return tag, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// truncate 去掉最后一个子标签，单字符子标签（扩展或私用前缀）随之一起去掉
+func truncate(tag string) string {
+	i := strings.LastIndexByte(tag, '-')
+	if i < 0 {
+		return ""
+	}
+	tag = tag[:i]
+	if j := strings.LastIndexByte(tag, '-'); j >= 0 && j == len(tag)-2 {
+		tag = tag[:j]
+	}
+	return tag
+}
+
+func isAlpha(s string) bool {
+	for _, c := range s {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isDigit(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isAlnum(s string) bool {
+	for _, c := range s {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
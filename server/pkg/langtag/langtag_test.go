@@ -0,0 +1,94 @@
+package langtag
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   string
+		wantOK bool
+	}{
+		{"en", "en", true},
+		{"EN-us", "en-US", true},
+		{"zh-hans-cn", "zh-Hans-CN", true},
+		{"sr-latn", "sr-Latn", true},
+		{"es-419", "es-419", true},
+		{"de-CH-1996", "de-CH-1996", true},
+		{"en-x-Private", "en-x-private", true},
+		{"en-US-u-ca-Gregory", "en-US-u-ca-gregory", true},
+		{"", "", false},
+		{"e", "", false},
+		{"abcd", "", false},
+		{"abcdefghi", "", false},
+		{"1en", "", false},
+		{"en_US", "", false},
+		{"en--US", "", false},
+		{"en-", "", false},
+		{"en-abcdefghi", "", false},
+		{"en-x", "", false},
+		{"en-x-", "", false},
+		{"x-private", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Canonical(tt.tag)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Canonical(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", nil},
+		{"fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5", []string{"fr-CH", "fr", "en", "de"}},
+		{"en;q=0.5, zh-hans", []string{"zh-Hans", "en"}},
+		{"en, fr", []string{"en", "fr"}},
+		{"en;q=0.8, fr;q=0.8, de;q=0.9", []string{"de", "en", "fr"}},
+		{"de;q=0, en", []string{"en"}},
+		{"en;q=2, fr;q=abc, de", []string{"de"}},
+		{"en_US, en", []string{"en"}},
+		{" EN-us ; q=0.5 ", []string{"en-US"}},
+		{"en;level=1", []string{"en"}},
+		{"*", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseAcceptLanguage(tt.header); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []string
+		tags   []string
+		want   string
+	}{
+		{"exact", []string{"zh-Hans"}, []string{"en", "zh-Hans"}, "zh-Hans"},
+		{"case insensitive", []string{"en-us"}, []string{"en-US"}, "en-US"},
+		{"truncated", []string{"zh-Hans-CN"}, []string{"en", "zh-Hans", "zh"}, "zh-Hans"},
+		{"truncated to language", []string{"zh-Hans-CN"}, []string{"en", "zh"}, "zh"},
+		{"more specific", []string{"zh-CN"}, []string{"en", "zh-Hans"}, "zh-Hans"},
+		{"more specific before the next range", []string{"fr", "en"}, []string{"en", "fr-CA"}, "fr-CA"},
+		{"next range", []string{"de", "en"}, []string{"fr", "en"}, "en"},
+		{"private use dropped", []string{"en-x-private"}, []string{"en"}, "en"},
+		{"prefix is a whole subtag", []string{"zh"}, []string{"zhx"}, ""},
+		{"no match", []string{"de"}, []string{"en"}, ""},
+		{"no ranges", nil, []string{"en"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Match(tt.ranges, tt.tags)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Fatalf("Match(%q, %q) = %q, %v, want %q", tt.ranges, tt.tags, got, ok, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,79 @@
+// Package logger 基于log/slog的结构化日志
+//
+// 通过Setup配置slog的默认Logger；有请求上下文时使用slog.InfoContext等方法，
+// 日志会自动带上request_id和user_id。
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
 )
 
-var (
-	Tip   = log.New(os.Stdout, "TIP: ", log.Ltime|log.Lshortfile)
-	Info  = log.New(os.Stdout, "INFO: ", log.Ltime|log.Lshortfile)
-	Error = log.New(os.Stderr, "ERROR: ", log.Ltime|log.Lshortfile)
-)
+func init() {
+	setDefault(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+}
+
+// Setup 按格式（json或text）和级别（debug、info、warn、error）配置全局日志
+func Setup(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("无效的日志级别: %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "json":
+		setDefault(slog.NewJSONHandler(w, opts))
+	case "text":
+		setDefault(slog.NewTextHandler(w, opts))
+	default:
+		return fmt.Errorf("无效的日志格式: %q", format)
+	}
+	return nil
+}
+
+func setDefault(h slog.Handler) {
+	slog.SetDefault(slog.New(contextHandler{h}))
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 将请求ID放入上下文
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 获取上下文中的请求ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler 从上下文中取出请求ID和认证用户ID附加到每条日志
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if userID, ok := ctx.Value("userID").(uint); ok {
+			r.AddAttrs(slog.Uint64("user_id", uint64(userID)))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
 
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,64 @@
+// Package mergepatch 实现JSON Merge Patch（RFC 7396）
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// ContentType Merge Patch请求的内容类型
+const ContentType = "application/merge-patch+json"
+
+// Apply 将patch合并到doc：对象逐键合并，值为null的键被删除，其他类型的值整体替换
+func Apply(doc, patch []byte) ([]byte, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	var d any
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &d); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merge(d, p))
+}
+
+func merge(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = merge(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// ApplyTo 将patch合并到结构体target（指针）的JSON表示上，再解码回target
+// 被删除的字段重置为零值
+func ApplyTo(target any, patch []byte) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("mergepatch: target必须是非nil指针")
+	}
+	doc, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	merged, err := Apply(doc, patch)
+	if err != nil {
+		return err
+	}
+	v.Elem().SetZero()
+	return json.Unmarshal(merged, target)
+}
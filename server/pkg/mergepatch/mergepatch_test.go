@@ -0,0 +1,120 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// jsonEqual 按JSON语义比较，忽略键的顺序
+func jsonEqual(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result %s is not JSON: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want %s is not JSON: %v", want, err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+func TestApply(t *testing.T) {
+	// RFC 7396附录A的测试用例
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		// 空文档按null处理
+		{``, `{"a":1}`, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("Apply(%s, %s): %v", tt.doc, tt.patch, err)
+		}
+		if !jsonEqual(t, got, tt.want) {
+			t.Errorf("Apply(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+	}{
+		{"invalid patch", `{}`, `{"a":`},
+		{"invalid doc", `{"a":`, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Apply([]byte(tt.doc), []byte(tt.patch)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestApplyTo(t *testing.T) {
+	type item struct {
+		Title string   `json:"title"`
+		Note  *string  `json:"note,omitempty"`
+		Tags  []string `json:"tags,omitempty"`
+		Count int      `json:"count"`
+	}
+	note := "keep"
+
+	tests := []struct {
+		name  string
+		patch string
+		want  item
+	}{
+		{"replace field", `{"title":"new"}`, item{Title: "new", Note: &note, Tags: []string{"a"}, Count: 2}},
+		{"null resets to zero value", `{"note":null,"count":null}`, item{Title: "old", Tags: []string{"a"}}},
+		{"array replaced as a whole", `{"tags":["b","c"]}`, item{Title: "old", Note: &note, Tags: []string{"b", "c"}, Count: 2}},
+		{"unknown field ignored", `{"other":1}`, item{Title: "old", Note: &note, Tags: []string{"a"}, Count: 2}},
+		{"empty patch", `{}`, item{Title: "old", Note: &note, Tags: []string{"a"}, Count: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := item{Title: "old", Note: &note, Tags: []string{"a"}, Count: 2}
+			if err := ApplyTo(&target, []byte(tt.patch)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(target, tt.want) {
+				t.Fatalf("got %+v, want %+v", target, tt.want)
+			}
+		})
+	}
+
+	t.Run("non-pointer target", func(t *testing.T) {
+		if err := ApplyTo(item{}, []byte(`{}`)); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+	t.Run("nil pointer target", func(t *testing.T) {
+		if err := ApplyTo((*item)(nil), []byte(`{}`)); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+	t.Run("type mismatch", func(t *testing.T) {
+		target := item{}
+		if err := ApplyTo(&target, []byte(`{"count":"two"}`)); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
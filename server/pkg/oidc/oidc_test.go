@@ -0,0 +1,167 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"brb/pkg/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const redirectURL = "http://app.test/callback"
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Server) {
+	t.Helper()
+	idp := oidctest.NewServer("brb", "secret")
+	t.Cleanup(idp.Close)
+	provider := NewProvider(Config{
+		IssuerURL:    idp.URL,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  redirectURL,
+	})
+	return provider, idp
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	provider, idp := newTestProvider(t)
+	raw, err := provider.AuthCodeURL(context.Background(), "st", "nc", CodeChallenge("verifier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != idp.URL+"/authorize" {
+		t.Fatalf("authorization endpoint = %s", got)
+	}
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "brb",
+		"redirect_uri":          redirectURL,
+		"scope":                 "openid profile email",
+		"state":                 "st",
+		"nonce":                 "nc",
+		"code_challenge":        CodeChallenge("verifier"),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range want {
+		if got := u.Query().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		name         string
+		verifier     string // 换取令牌时使用的code_verifier
+		nonce        string // 换取令牌时期望的nonce
+		reuseCode    bool   // 第二次使用同一授权码
+		wantErr      string
+		wantUsername string
+	}{
+		{name: "valid", verifier: "verifier", nonce: "nonce", wantUsername: "alice"},
+		{name: "wrong verifier", verifier: "other", nonce: "nonce", wantErr: "invalid_grant"},
+		{name: "nonce mismatch", verifier: "verifier", nonce: "other", wantErr: "nonce mismatch"},
+		{name: "code reused", verifier: "verifier", nonce: "nonce", reuseCode: true, wantErr: "invalid_grant"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, idp := newTestProvider(t)
+			ctx := context.Background()
+
+			authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", CodeChallenge("verifier"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			callback, err := idp.Authorize(authURL, map[string]any{"sub": "u-1", "preferred_username": "alice"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			cb, _ := url.Parse(callback)
+			code := cb.Query().Get("code")
+			if cb.Query().Get("state") != "state" {
+				t.Fatalf("callback state = %q", cb.Query().Get("state"))
+			}
+
+			if tt.reuseCode {
+				if _, err := provider.Exchange(ctx, code, tt.verifier, tt.nonce); err != nil {
+					t.Fatalf("first exchange: %v", err)
+				}
+			}
+			claims, err := provider.Exchange(ctx, code, tt.verifier, tt.nonce)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims["sub"] != "u-1" || claims["preferred_username"] != tt.wantUsername {
+				t.Fatalf("unexpected claims %v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	provider, idp := newTestProvider(t)
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   idp.URL,
+			"aud":   idp.ClientID,
+			"sub":   "u-1",
+			"nonce": "nonce",
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Minute).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(jwt.MapClaims)
+		wantErr bool
+	}{
+		{"valid", func(jwt.MapClaims) {}, false},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.test" }, true},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other-client" }, true},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() }, true},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }, true},
+		{"missing sub", func(c jwt.MapClaims) { delete(c, "sub") }, true},
+		{"wrong nonce", func(c jwt.MapClaims) { c["nonce"] = "other" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+			raw, err := idp.SignIDToken(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = provider.VerifyIDToken(context.Background(), raw, "nonce")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("hmac signed", func(t *testing.T) {
+		raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid()).SignedString([]byte(idp.ClientSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := provider.VerifyIDToken(context.Background(), raw, "nonce"); err == nil {
+			t.Fatal("accepted an HS256 token signed with the client secret")
+		}
+	})
+}
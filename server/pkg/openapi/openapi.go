@@ -0,0 +1,324 @@
+// Package openapi 根据路由声明和Go结构体生成OpenAPI 3.0文档
+//
+// 结构体的字段名取自json标签，具名结构体放入components/schemas并以$ref引用，
+// 指针字段视为可空。请求和响应共用结构体，字段不标记required。
+package openapi
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DocsHTML 文档页面，从同目录的openapi.json加载文档
+//
+//go:embed docs.html
+var DocsHTML []byte
+
+// Document OpenAPI文档
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info 文档信息
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem 同一路径下按小写方法名区分的操作
+type PathItem map[string]*Operation
+
+// Operation 一个接口
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter 路径或查询参数
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response 响应
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType 某种内容类型的结构
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema JSON Schema的子集
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Components 可复用的结构和认证方式
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 认证方式
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Endpoint 一个路由的文档声明
+type Endpoint struct {
+	Method      string
+	Path        string // 与注册路由时相同的完整路径，如/v1/api/todos/{id}
+	Tag         string
+	Summary     string
+	Auth        bool        // 需要Bearer令牌
+	Query       []Parameter // 查询参数，In可省略
+	Request     any         // 请求体类型的零值，nil表示没有请求体
+	Form        bool        // 请求体也接受表单提交
+	MergePatch  bool        // 请求体也接受application/merge-patch+json
+	Consumes    []string    // 请求体也接受的非JSON内容类型，如text/csv
+	Response    any         // 成功响应体类型的零值，nil表示没有响应体
+	Status      int         // 成功状态码，默认200
+	ContentType string      // 非JSON响应的内容类型，如text/plain
+}
+
+const bearerAuth = "bearerAuth"
+
+var pathParam = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(\.\.\.)?\}`)
+
+// Builder 逐个添加接口生成文档
+type Builder struct {
+	doc        *Document
+	errorBody  *Schema
+	operations map[string]bool
+}
+
+// NewBuilder 创建文档生成器，errorResponse为错误响应类型的零值
+func NewBuilder(info Info, errorResponse any) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+				SecuritySchemes: map[string]SecurityScheme{
+					bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				},
+			},
+		},
+		operations: map[string]bool{},
+	}
+	if errorResponse != nil {
+		b.errorBody = b.schema(reflect.TypeOf(errorResponse))
+	}
+	return b
+}
+
+// Add 添加一个接口，同一方法和路径重复添加时返回错误
+func (b *Builder) Add(e Endpoint) error {
+	method := strings.ToLower(e.Method)
+	path := pathParam.ReplaceAllString(e.Path, "{$1}")
+	key := method + " " + path
+	if b.operations[key] {
+		return fmt.Errorf("重复的接口声明: %s %s", e.Method, e.Path)
+	}
+	b.operations[key] = true
+
+	op := &Operation{
+		Summary:     e.Summary,
+		OperationID: operationID(method, path),
+		Responses:   map[string]Response{},
+	}
+	if e.Tag != "" {
+		op.Tags = []string{e.Tag}
+	}
+	if e.Auth {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(e.Path, -1) {
+		schema := &Schema{Type: "string"}
+		if m[1] == "id" {
+			schema.Type = "integer"
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: schema})
+	}
+	for _, p := range e.Query {
+		if p.In == "" {
+			p.In = "query"
+		}
+		if p.Schema == nil {
+			p.Schema = &Schema{Type: "string"}
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	if e.Request != nil {
+		schema := b.schema(reflect.TypeOf(e.Request))
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+		if e.Form {
+			op.RequestBody.Content["application/x-www-form-urlencoded"] = MediaType{Schema: schema}
+		}
+		if e.MergePatch {
+			op.RequestBody.Content["application/merge-patch+json"] = MediaType{Schema: schema}
+		}
+		for _, contentType := range e.Consumes {
+			op.RequestBody.Content[contentType] = MediaType{Schema: &Schema{Type: "string"}}
+		}
+	}
+
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	switch {
+	case e.ContentType != "":
+		success.Content = map[string]MediaType{e.ContentType: {Schema: &Schema{Type: "string"}}}
+	case e.Response != nil:
+		success.Content = map[string]MediaType{"application/json": {Schema: b.schema(reflect.TypeOf(e.Response))}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	if b.errorBody != nil {
+		op.Responses["default"] = Response{Description: "错误", Content: map[string]MediaType{"application/json": {Schema: b.errorBody}}}
+	}
+
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = PathItem{}
+		b.doc.Paths[path] = item
+	}
+	item[method] = op
+	return nil
+}
+
+// Document 返回生成的文档
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema 生成类型的结构，具名结构体登记到components后返回引用
+func (b *Builder) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		// $ref不能带其他属性，可空的结构体引用不再标记nullable
+		return b.ref(t)
+	case t.Kind() == reflect.Struct:
+		s = b.object(t)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		s = &Schema{Type: "string", Format: "byte"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		s = &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case t.Kind() == reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s = &Schema{Type: "integer"}
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			s.Format = "int64"
+		}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s = &Schema{Type: "number"}
+	case t.Kind() == reflect.String:
+		s = &Schema{Type: "string"}
+	default:
+		s = &Schema{}
+	}
+	s.Nullable = nullable
+	return s
+}
+
+func (b *Builder) ref(t reflect.Type) *Schema {
+	name := t.Name()
+	if _, ok := b.doc.Components.Schemas[name]; !ok {
+		// 先占位，防止自引用的结构体无限递归
+		b.doc.Components.Schemas[name] = &Schema{}
+		*b.doc.Components.Schemas[name] = *b.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// object 按json标签生成对象结构，匿名嵌入的结构体字段展开到外层
+func (b *Builder) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			embedded := b.object(f.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = b.schema(f.Type)
+	}
+	return s
+}
+
+// operationID 由方法和路径生成唯一的操作ID，如get_v1_api_todos_id
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		sb.WriteByte('_')
+		sb.WriteString(part)
+	}
+	return sb.String()
+}
@@ -0,0 +1,116 @@
+// Package skos 将概念表以SKOS词表的形式输出为RDF Turtle
+package skos
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Concept 一个skos:Concept，关系字段保存目标概念的URI
+type Concept struct {
+	URI        string
+	PrefLabel  string
+	Lang       string  // PrefLabel的语言标签，为空时不标注
+	Labels     []Label // 带语言标签的其他名称
+	Definition string
+	Broader    []string
+	Narrower   []string
+	Related    []string
+	ExactMatch []string
+	PartOf     []string // 输出为dcterms:isPartOf
+}
+
+// Label 带语言标签的名称，首选的输出为skos:prefLabel，其余为skos:altLabel
+// 每种语言至多应有一个首选名称
+type Label struct {
+	Value     string
+	Lang      string
+	Preferred bool
+}
+
+// Scheme 一个skos:ConceptScheme及其中的全部概念
+type Scheme struct {
+	URI      string
+	Title    string
+	Concepts []Concept
+}
+
+// WriteTurtle 以Turtle格式输出词表
+func WriteTurtle(w io.Writer, scheme Scheme) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("@prefix skos: <http://www.w3.org/2004/02/skos/core#> .\n")
+	bw.WriteString("@prefix dcterms: <http://purl.org/dc/terms/> .\n\n")
+
+	bw.WriteString(iri(scheme.URI) + " a skos:ConceptScheme")
+	if scheme.Title != "" {
+		bw.WriteString(" ;\n    dcterms:title " + literal(scheme.Title, ""))
+	}
+	bw.WriteString(" .\n")
+
+	for _, c := range scheme.Concepts {
+		bw.WriteString("\n" + iri(c.URI) + " a skos:Concept ;\n")
+		bw.WriteString("    skos:inScheme " + iri(scheme.URI))
+		if c.PrefLabel != "" {
+			bw.WriteString(" ;\n    skos:prefLabel " + literal(c.PrefLabel, c.Lang))
+		}
+		for _, label := range c.Labels {
+			predicate := "skos:altLabel"
+			if label.Preferred {
+				predicate = "skos:prefLabel"
+			}
+			bw.WriteString(" ;\n    " + predicate + " " + literal(label.Value, label.Lang))
+		}
+		if c.Definition != "" {
+			bw.WriteString(" ;\n    skos:definition " + literal(c.Definition, c.Lang))
+		}
+		for _, p := range []struct {
+			predicate string
+			objects   []string
+		}{
+			{"skos:broader", c.Broader},
+			{"skos:narrower", c.Narrower},
+			{"skos:related", c.Related},
+			{"skos:exactMatch", c.ExactMatch},
+			{"dcterms:isPartOf", c.PartOf},
+		} {
+			if len(p.objects) == 0 {
+				continue
+			}
+			objects := make([]string, len(p.objects))
+			for i, o := range p.objects {
+				objects[i] = iri(o)
+			}
+			bw.WriteString(" ;\n    " + p.predicate + " " + strings.Join(objects, ", "))
+		}
+		bw.WriteString(" .\n")
+	}
+	return bw.Flush()
+}
+
+var literalEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+func literal(s, lang string) string {
+	out := `"` + literalEscaper.Replace(s) + `"`
+	if lang != "" {
+		out += "@" + lang
+	}
+	return out
+}
+
+// iri 转义IRI中Turtle不允许出现的字符
+func iri(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('<')
+	for _, r := range s {
+		switch {
+		case r <= 0x20, strings.ContainsRune(`<>"{}|^`+"`"+`\`, r):
+			fmt.Fprintf(&sb, "%%%02X", r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('>')
+	return sb.String()
+}
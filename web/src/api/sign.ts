@@ -1,5 +1,5 @@
 import { get, post, put, del } from './api';
-import type { SignCreateRequest, SignUpdateRequest, SignResponse } from './types';
+import type { SignCreateRequest, SignUpdateRequest, SignResponse, SignListParams, SignListResponse } from './types';
 
 /**
  * Sign API 函数封装
@@ -14,6 +14,19 @@ export function createSign(data: SignCreateRequest): Promise<SignResponse> {
   return post<SignResponse>('/signs', data);
 }
 
+/**
+ * 分页查询sign
+ * @param params - 能指精确/前缀查找、所指搜索及分页参数
+ * @returns 当页sign和总数
+ */
+export function listSigns(params: SignListParams = {}): Promise<SignListResponse> {
+  const query: Record<string, string | number> = {};
+  for (const [key, value] of Object.entries(params)) {
+    if (value !== undefined && value !== '') query[key] = value;
+  }
+  return get<SignListResponse>('/signs', query);
+}
+
 /**
  * 根据ID获取单个sign
  * @param id - sign的ID
@@ -44,6 +57,7 @@ export function deleteSign(id: number): Promise<void> {
 
 export default {
   createSign,
+  listSigns,
   getSign,
   updateSign,
   deleteSign,
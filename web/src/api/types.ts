@@ -3,20 +3,52 @@
  */
 
 // Sign相关类型
+// 带BCP 47语言标签的能指，每种语言至多一个首选
+export interface SignLabel {
+  lang: string;
+  value: string;
+  preferred: boolean;
+}
+
 export interface SignCreateRequest {
   signifier: string;
   signified: string;
+  labels?: SignLabel[];
 }
 
 export interface SignUpdateRequest {
   signifier: string;
   signified: string;
+  // 省略时保留原有的多语言能指，空数组表示全部删除
+  labels?: SignLabel[];
 }
 
 export interface SignResponse {
   id: number;
+  // 按Accept-Language选出的首选能指，无匹配时为默认能指
   signifier: string;
+  lang?: string;
   signified: string;
+  defaultSignifier: string;
+  labels: SignLabel[];
+  // 创建者和最后修改者的用户ID，早于记录创建者的sign没有
+  createdBy?: number;
+  updatedBy?: number;
+}
+
+export interface SignListParams {
+  signifier?: string;
+  prefix?: string;
+  q?: string;
+  limit?: number;
+  offset?: number;
+}
+
+export interface SignListResponse {
+  items: SignResponse[];
+  total: number;
+  limit: number;
+  offset: number;
 }
 
 // Todo相关类型
@@ -43,6 +75,7 @@ export interface TodoUpdateRequest {
 export interface TimeSpan {
   start?: string;
   end?: string;
+  allDay?: boolean;
 }
 
 export interface TodoResponse {
@@ -62,7 +95,9 @@ export interface EventCreateRequest {
   description: string;
   location: string;
   priority: number;
-  category: string;
+  // 已弃用：作为同名标签写入
+  category?: string;
+  tags?: string[];
 }
 
 export interface EventUpdateRequest {
@@ -71,7 +106,10 @@ export interface EventUpdateRequest {
   description: string;
   location: string;
   priority: number;
-  category: string;
+  // 已弃用：追加为同名标签
+  category?: string;
+  // 省略时保持原有标签
+  tags?: string[];
 }
 
 export interface EventResponse {
@@ -81,7 +119,41 @@ export interface EventResponse {
   description: string;
   location: string;
   priority: number;
-  category: string;
+  tags: string[];
+}
+
+// 模板清单和实例化，计划时间为相对时间窗口开始的分钟数
+export interface ChecklistTodoItem {
+  offsetMinutes: number;
+  durationMinutes: number;
+}
+
+export interface ChecklistTaskItem extends ChecklistTodoItem {
+  description: string;
+  todos: ChecklistTodoItem[];
+}
+
+export interface Checklist {
+  tasks: ChecklistTaskItem[];
+  todos: ChecklistTodoItem[];
+}
+
+export interface InstantiateRequest {
+  start: string;
+  end: string;
+}
+
+export interface TaskTreeResponse {
+  task: TaskResponse;
+  todos: TodoResponse[];
+  subtasks: TaskTreeResponse[];
+}
+
+// Tag相关类型
+export interface TagResponse {
+  id: number;
+  name: string;
+  usage: number;
 }
 
 // Task相关类型
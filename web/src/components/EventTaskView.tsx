@@ -81,8 +81,10 @@ export default function EventTaskView() {
   }
 
   // 格式化时间段显示
-  const formatTimeSpan = (timeSpan: { start?: string; end?: string }) => {
+  const formatTimeSpan = (timeSpan: { start?: string; end?: string; allDay?: boolean }) => {
     if (!timeSpan.start && !timeSpan.end) return 'No time set'
+    // 全天时间段返回的是日期，直接显示
+    if (timeSpan.allDay) return `${timeSpan.start ?? 'Not set'} - ${timeSpan.end ?? 'Not set'}`
     
     const start = timeSpan.start ? new Date(timeSpan.start).toLocaleString('zh-CN') : 'Not set'
     const end = timeSpan.end ? new Date(timeSpan.end).toLocaleString('zh-CN') : 'Not set'
@@ -177,7 +179,7 @@ export default function EventTaskView() {
                       <span class={styles.id}>ID: {event.id}</span>
                       <span class={styles.location}>Location: {event.location}</span>
                       <span class={styles.priority}>Priority: {event.priority}</span>
-                      <span class={styles.category}>Category: {event.category}</span>
+                      <span class={styles.category}>Tags: {event.tags.join(', ')}</span>
                       <span class={styles.template}>Template: {event.isTemplate ? 'Yes' : 'No'}</span>
                     </div>
                   </div>